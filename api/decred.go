@@ -0,0 +1,761 @@
+package api
+
+import (
+	"blockbook/bchain"
+	"blockbook/bchain/coins/dcr"
+	"blockbook/db"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/juju/errors"
+)
+
+// decredSearchTxsBatch is the number of transactions requested by one searchrawtransactions call
+const decredSearchTxsBatch = 100
+
+// decredMaxSearchTxs is the maximum number of the address transactions scanned by decredGetAddressTxids,
+// the transactions of the addresses with longer history are served from the index once it is built
+const decredMaxSearchTxs = 10000
+
+// decredReuseCacheSize is the maximum number of the addresses with cached first transactions
+const decredReuseCacheSize = 100000
+
+// decredGetAddressTxids gets the address transactions from dcrd, newest first as in the blockbook index,
+// at most decredMaxSearchTxs transactions are scanned. The vout filter is not supported by dcrd and is ignored.
+func (w *Worker) decredGetAddressTxids(addrDesc bchain.AddressDescriptor, filter *AddressFilter, maxResults int) ([]string, error) {
+	addresses, _, err := w.chainParser.GetAddressesFromAddrDesc(addrDesc)
+	if err != nil {
+		return nil, err
+	}
+	r := make([]string, 0)
+	if len(addresses) == 0 {
+		return r, nil
+	}
+	for skip := 0; len(r) < maxResults; skip += decredSearchTxsBatch {
+		if skip >= decredMaxSearchTxs {
+			glog.Warning("decredGetAddressTxids ", addresses[0], ": scanned ", skip, " transactions, the rest is skipped")
+			break
+		}
+		txs, err := w.decred.SearchRawTransactions(addresses[0], 1, skip, decredSearchTxsBatch, 0, true, nil)
+		if err != nil {
+			return nil, errors.Annotatef(err, "SearchRawTransactions %v", addresses[0])
+		}
+		for _, raw := range txs {
+			var tx struct {
+				Txid        string `json:"txid"`
+				BlockHeight uint32 `json:"blockheight"`
+			}
+			if err := json.Unmarshal(raw, &tx); err != nil {
+				return nil, errors.Annotatef(err, "SearchRawTransactions %v", addresses[0])
+			}
+			// the mempool transactions have no block height
+			if tx.BlockHeight == 0 || filter.ToHeight > 0 && tx.BlockHeight > filter.ToHeight {
+				continue
+			}
+			// the transactions are sorted newest first, the rest is below the range
+			if tx.BlockHeight < filter.FromHeight {
+				return r, nil
+			}
+			r = append(r, tx.Txid)
+			if len(r) >= maxResults {
+				break
+			}
+		}
+		if len(txs) < decredSearchTxsBatch {
+			break
+		}
+	}
+	return r, nil
+}
+
+// decredGetAddressTxsPage gets one page of the confirmed address transactions from dcrd, newest first,
+// only the page is fetched instead of all the transactions up to the page. The mempool transactions,
+// which are returned by dcrd first, are skipped. The bool result tells if there is a next page.
+func (w *Worker) decredGetAddressTxsPage(addrDesc bchain.AddressDescriptor, page, txsOnPage int) ([]*Tx, bool, error) {
+	addresses, _, err := w.chainParser.GetAddressesFromAddrDesc(addrDesc)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(addresses) == 0 {
+		return nil, false, nil
+	}
+	// the offset of the page is counted from the first confirmed transaction returned by dcrd,
+	// the mempool of dcrd may differ from the mempool of blockbook
+	unconfirmed, err := w.decredUnconfirmedCount(addresses[0], txsOnPage)
+	if err != nil {
+		return nil, false, err
+	}
+	// one more transaction tells if there is a next page
+	btxs, err := w.decred.GetAddressTransactionsPaged(addresses[0], unconfirmed+page*txsOnPage, txsOnPage+1)
+	if err != nil {
+		return nil, false, errors.Annotatef(err, "GetAddressTransactionsPaged %v", addresses[0])
+	}
+	bestheight, err := w.chain.GetBestBlockHeight()
+	if err != nil {
+		return nil, false, errors.Annotatef(err, "GetBestBlockHeight")
+	}
+	txs := make([]*Tx, 0, len(btxs))
+	for _, btx := range btxs {
+		// the transaction entered the mempool of dcrd after the unconfirmed transactions were counted
+		if btx.Confirmations == 0 {
+			continue
+		}
+		if len(txs) == txsOnPage {
+			return txs, true, nil
+		}
+		tx, err := w.GetTransactionFromBchainTx(btx, bestheight-btx.Confirmations+1, false, false)
+		if err != nil {
+			return nil, false, errors.Annotatef(err, "GetTransactionFromBchainTx %v", btx.Txid)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, false, nil
+}
+
+// decredUnconfirmedCount returns the number of the unconfirmed address transactions returned by dcrd
+// before the confirmed ones, the transactions are fetched in chunks of count
+func (w *Worker) decredUnconfirmedCount(address string, count int) (int, error) {
+	unconfirmed := 0
+	for {
+		btxs, err := w.decred.GetAddressTransactionsPaged(address, unconfirmed, count)
+		if err != nil {
+			return 0, errors.Annotatef(err, "GetAddressTransactionsPaged %v", address)
+		}
+		for _, btx := range btxs {
+			if btx.Confirmations > 0 {
+				return unconfirmed, nil
+			}
+			unconfirmed++
+		}
+		if len(btxs) < count {
+			return unconfirmed, nil
+		}
+	}
+}
+
+// decredGetPrevOut returns the output spent by the input txid:vout from the cache of the previous outputs of the backend
+func (w *Worker) decredGetPrevOut(txid string, vout uint32) (*bchain.Vout, error) {
+	p, err := w.decred.GetPrevOut(txid, vout)
+	if err != nil {
+		return nil, err
+	}
+	return &bchain.Vout{
+		ValueSat:     p.ValueSat,
+		N:            vout,
+		ScriptPubKey: bchain.ScriptPubKey{Hex: hex.EncodeToString(p.ScriptPubKey)},
+		Version:      p.Version,
+	}, nil
+}
+
+// decredSetUtxoInfo sets the coin control information of the utxos, the type of the output, its tree and the maturity,
+// the tree is needed to spend the output. The outputs of coinbase and vote transactions cannot be spent until they have coinbase maturity confirmations
+func (w *Worker) decredSetUtxoInfo(utxos Utxos) error {
+	dp := w.chainParser.(*dcr.DecredParser)
+	maturity := dp.CoinbaseMaturity()
+	txs := make(map[string]*bchain.Tx)
+	for i := range utxos {
+		u := &utxos[i]
+		tx, found := txs[u.Txid]
+		if !found {
+			var err error
+			// the transactions of the utxos are usually in the tx cache, dcrd is called only on a miss
+			tx, _, err = w.txCache.GetTransaction(u.Txid)
+			if err != nil {
+				return errors.Annotatef(err, "GetTransaction %v", u.Txid)
+			}
+			txs[u.Txid] = tx
+		}
+		u.UTXOType = dp.GetUtxoType(tx, int(u.Vout))
+		tree := dp.GetTxTree(tx)
+		u.Tree = &tree
+		mature := true
+		if u.Confirmations < maturity {
+			generated := len(tx.Vin) > 0 && tx.Vin[0].Coinbase != "" || dp.GetTxType(tx) == dcr.TxTypeVote
+			mature = !generated
+		}
+		u.Mature = &mature
+	}
+	return nil
+}
+
+// GetDecredOpReturnTxs returns the transaction outputs containing the OP_RETURN data,
+// the data are found only if blockbook runs with the OP_RETURN index enabled
+func (w *Worker) GetDecredOpReturnTxs(data []byte) ([]DecredOpReturnTx, error) {
+	outputs, err := w.db.GetOpReturnOutputs(data)
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetOpReturnOutputs %x", data)
+	}
+	r := make([]DecredOpReturnTx, 0, len(outputs))
+	for _, o := range outputs {
+		t := DecredOpReturnTx{Txid: o.Txid, Vout: o.Vout}
+		ta, err := w.db.GetTxAddresses(o.Txid)
+		if err != nil {
+			return nil, errors.Annotatef(err, "GetTxAddresses %v", o.Txid)
+		}
+		if ta != nil {
+			t.Blockheight = int(ta.Height)
+			bi, err := w.db.GetBlockInfo(ta.Height)
+			if err != nil {
+				return nil, errors.Annotatef(err, "GetBlockInfo %v", ta.Height)
+			}
+			if bi != nil {
+				t.Blockhash = bi.Hash
+				t.Blocktime = bi.Time
+			}
+		}
+		r = append(r, t)
+	}
+	return r, nil
+}
+
+// GetDecredMempoolVerbose returns a page of the mempool transactions with the fee and size information,
+// the newest transactions first
+func (w *Worker) GetDecredMempoolVerbose(page int, itemsOnPage int) (*DecredMempoolTxs, error) {
+	page--
+	if page < 0 {
+		page = 0
+	}
+	mempool, err := w.decred.GetRawMempoolVerbose()
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetRawMempoolVerbose")
+	}
+	txs := make([]DecredMempoolTx, 0, len(mempool))
+	for txid, e := range mempool {
+		fee := e.FeeSat
+		txs = append(txs, DecredMempoolTx{
+			Txid:    txid,
+			Time:    e.Time,
+			Size:    int(e.Size),
+			FeesSat: (*Amount)(&fee),
+			Depends: e.Depends,
+		})
+	}
+	sort.Slice(txs, func(i, j int) bool {
+		if txs[i].Time == txs[j].Time {
+			return txs[i].Txid < txs[j].Txid
+		}
+		return txs[i].Time > txs[j].Time
+	})
+	pg, from, to, _ := computePaging(len(txs), page, itemsOnPage)
+	return &DecredMempoolTxs{
+		Paging:      pg,
+		Mempool:     txs[from:to],
+		MempoolSize: len(txs),
+	}, nil
+}
+
+// Decred block approval statuses
+const (
+	decredBlockApproved    = "approved"
+	decredBlockDisapproved = "disapproved"
+	decredBlockPending     = "pending"
+)
+
+// decredBlockApproval returns the approval of the block by the votes in the next block, the vote bits are read
+// from the indexed header of the next block. The approval is pending until the next block is mined.
+func (w *Worker) decredBlockApproval(hash string, height uint32, next string) (*bool, string) {
+	if next == "" {
+		return nil, decredBlockPending
+	}
+	header, err := w.db.GetBlockHeaderBytes(height + 1)
+	if err != nil {
+		glog.Warning("GetBlockHeaderBytes ", height+1, ": ", err)
+		return nil, ""
+	}
+	// the header of the next block is not indexed if the block was connected before the headers were stored
+	approved, ok := dcr.ParentApproval(header, hash)
+	if !ok {
+		return nil, ""
+	}
+	if approved {
+		return &approved, decredBlockApproved
+	}
+	return &approved, decredBlockDisapproved
+}
+
+// decredReuseCache caches the first two transactions of the addresses, they do not change
+// once the address has two transactions in the index
+type decredReuseCache struct {
+	lock  sync.Mutex
+	txids map[string][]string
+}
+
+func newDecredReuseCache() *decredReuseCache {
+	return &decredReuseCache{txids: make(map[string][]string)}
+}
+
+func (c *decredReuseCache) get(key string) ([]string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	txids, found := c.txids[key]
+	return txids, found
+}
+
+func (c *decredReuseCache) set(key string, txids []string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.txids) >= decredReuseCacheSize {
+		c.txids = make(map[string][]string)
+	}
+	c.txids[key] = txids
+}
+
+// SetDecredAddressReuse flags the inputs and outputs whose address was used in an earlier transaction,
+// for inputs the transaction funding the spent output is not counted. It needs the address histories,
+// therefore it is called only by the endpoints returning a single transaction. Nothing is done
+// unless the backend is dcrd.
+func (w *Worker) SetDecredAddressReuse(tx *Tx) error {
+	if w.decred == nil {
+		return nil
+	}
+	for i := range tx.Vin {
+		vin := &tx.Vin[i]
+		if len(vin.AddrDesc) == 0 {
+			continue
+		}
+		first, err := w.decredFirstTxids(vin.AddrDesc)
+		if err != nil {
+			return err
+		}
+		vin.AddressReuse = decredAddressReused(first, tx.Txid, vin.Txid)
+	}
+	for i := range tx.Vout {
+		vout := &tx.Vout[i]
+		if len(vout.AddrDesc) == 0 || !vout.Searchable {
+			continue
+		}
+		first, err := w.decredFirstTxids(vout.AddrDesc)
+		if err != nil {
+			return err
+		}
+		vout.AddressReuse = decredAddressReused(first, tx.Txid, "")
+	}
+	return nil
+}
+
+// decredFirstTxids returns the first two confirmed transactions of the address, the oldest first.
+// The whole address history is iterated only once, the result is cached when it is complete.
+func (w *Worker) decredFirstTxids(addrDesc bchain.AddressDescriptor) ([]string, error) {
+	key := string(addrDesc)
+	if first, found := w.decredReuse.get(key); found {
+		return first, nil
+	}
+	// the transactions are iterated from the newest, the last two are the oldest
+	var older, oldest string
+	n := 0
+	err := w.db.GetAddrDescTransactions(addrDesc, 0, maxUint32, func(txid string, height uint32, indexes []int32) error {
+		older, oldest = oldest, txid
+		n++
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetAddrDescTransactions %v", addrDesc)
+	}
+	switch n {
+	case 0:
+		return nil, nil
+	case 1:
+		return []string{oldest}, nil
+	}
+	first := []string{oldest, older}
+	w.decredReuse.set(key, first)
+	return first, nil
+}
+
+// decredAddressReused checks if any of the first transactions of the address precedes the tx, excluding the funding
+// transaction. The mempool transactions are not in the first transactions, all the confirmed ones precede them.
+func decredAddressReused(first []string, txid string, funding string) bool {
+	for _, t := range first {
+		if t == txid {
+			return false
+		}
+		if t != funding {
+			return true
+		}
+	}
+	// the address has at most the funding transaction before the tx
+	return false
+}
+
+// Decred ticket statuses not stored in the index
+const (
+	decredTicketImmature = "immature"
+	decredTicketExpired  = "expired"
+)
+
+// GetDecredTicket returns the lifecycle of the ticket, the immature and expired statuses
+// of the unspent tickets are derived from the best block height
+func (w *Worker) GetDecredTicket(txid string) (*DecredTicket, error) {
+	ti, err := w.db.GetTicketInfo(txid)
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetTicketInfo %v", txid)
+	}
+	if ti == nil {
+		return nil, NewAPIError(fmt.Sprintf("Ticket %v not found", txid), true)
+	}
+	maturity, expiry := w.chainParser.(*dcr.DecredParser).TicketLifetime()
+	r := &DecredTicket{
+		Txid:           txid,
+		Status:         ti.Status.String(),
+		PurchaseHeight: ti.PurchaseHeight,
+		MaturityHeight: ti.PurchaseHeight + maturity,
+		ExpiryHeight:   ti.PurchaseHeight + maturity + expiry,
+		SpendTxid:      ti.SpendTxid,
+		SpendHeight:    ti.SpendHeight,
+	}
+	if len(ti.CommittedAddrDesc) > 0 {
+		addresses, _, err := w.chainParser.GetAddressesFromAddrDesc(ti.CommittedAddrDesc)
+		if err == nil && len(addresses) == 1 {
+			r.CommittedAddress = addresses[0]
+		}
+	}
+	if ti.Status == db.TicketLive {
+		bestheight, _, err := w.db.GetBestBlock()
+		if err != nil {
+			return nil, errors.Annotatef(err, "GetBestBlock")
+		}
+		if bestheight < r.MaturityHeight {
+			r.Status = decredTicketImmature
+		} else if bestheight >= r.ExpiryHeight {
+			r.Status = decredTicketExpired
+		}
+	}
+	return r, nil
+}
+
+// GetDecredAtomicSwap returns the atomic swap contract locked in the output and the way it was spent,
+// the contract is revealed only by the spending input so the unspent contracts are not found
+func (w *Worker) GetDecredAtomicSwap(txid string, vout int32) (*DecredAtomicSwap, error) {
+	as, err := w.db.GetAtomicSwapSpend(txid, vout)
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetAtomicSwapSpend %v:%v", txid, vout)
+	}
+	if as == nil {
+		return nil, NewAPIError(fmt.Sprintf("Spent atomic swap contract %v:%v not found", txid, vout), true)
+	}
+	swap, ok := w.chainParser.(*dcr.DecredParser).ParseAtomicSwapScript(as.Contract)
+	if !ok {
+		return nil, errors.Errorf("Invalid atomic swap contract of %v:%v", txid, vout)
+	}
+	return &DecredAtomicSwap{
+		Txid:             txid,
+		Vout:             vout,
+		Status:           as.Status.String(),
+		SpendTxid:        as.SpendTxid,
+		SpendHeight:      as.SpendHeight,
+		Contract:         hex.EncodeToString(as.Contract),
+		SecretHash:       hex.EncodeToString(swap.SecretHash),
+		Secret:           hex.EncodeToString(as.Secret),
+		RecipientAddress: swap.RecipientAddress,
+		RefundAddress:    swap.RefundAddress,
+		LockTime:         swap.LockTime,
+		RelativeLockTime: swap.RelativeLockTime,
+	}, nil
+}
+
+// DetectMixingSession finds the CoinShuffle++ mixes among the transactions and groups them to the batches
+// by the block and the denomination, the unconfirmed mixes are grouped by the denomination only
+func (w *Worker) DetectMixingSession(txids []string) (*DecredMixingSession, error) {
+	dp := w.chainParser.(*dcr.DecredParser)
+	r := &DecredMixingSession{Batches: []DecredMixingBatch{}}
+	batches := make(map[string]int)
+	for _, txid := range txids {
+		tx, height, err := w.txCache.GetTransaction(txid)
+		if err != nil {
+			return nil, errors.Annotatef(err, "txCache.GetTransaction %v", txid)
+		}
+		denomination, mixed, ok := dp.GetMixDenomination(tx)
+		if !ok {
+			continue
+		}
+		key := fmt.Sprint(height, ":", denomination)
+		i, found := batches[key]
+		if !found {
+			i = len(r.Batches)
+			batches[key] = i
+			r.Batches = append(r.Batches, DecredMixingBatch{
+				Height:       height,
+				Denomination: (*Amount)(denomination),
+				Txids:        []string{},
+			})
+		}
+		b := &r.Batches[i]
+		b.Txids = append(b.Txids, txid)
+		b.MixedOutputs += mixed
+		b.Inputs += len(tx.Vin)
+	}
+	return r, nil
+}
+
+// GetDecredMixingSession returns the mixing batch of the transaction with the mixes
+// of the same denomination confirmed in the same block
+func (w *Worker) GetDecredMixingSession(txid string) (*DecredMixingSession, error) {
+	tx, height, err := w.txCache.GetTransaction(txid)
+	if err != nil {
+		if err == bchain.ErrTxNotFound {
+			return nil, NewAPIError(fmt.Sprintf("Transaction '%v' not found", txid), true)
+		}
+		return nil, errors.Annotatef(err, "txCache.GetTransaction %v", txid)
+	}
+	if _, _, ok := w.chainParser.(*dcr.DecredParser).GetMixDenomination(tx); !ok {
+		return nil, NewAPIError(fmt.Sprintf("Transaction '%v' is not a mix", txid), true)
+	}
+	txids := []string{txid}
+	if height > 0 {
+		hash, err := w.db.GetBlockHash(height)
+		if err != nil {
+			return nil, errors.Annotatef(err, "GetBlockHash %v", height)
+		}
+		bi, err := w.chain.GetBlockInfo(hash)
+		if err != nil {
+			return nil, errors.Annotatef(err, "GetBlockInfo %v", hash)
+		}
+		txids = bi.Txids
+	}
+	s, err := w.DetectMixingSession(txids)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range s.Batches {
+		for _, t := range b.Txids {
+			if t == txid {
+				return &DecredMixingSession{Batches: []DecredMixingBatch{b}}, nil
+			}
+		}
+	}
+	return nil, NewAPIError(fmt.Sprintf("Transaction '%v' not found in block %v", txid, height), true)
+}
+
+// decredMaxScanGap is the maximum gap limit of ScanAddressRange
+const decredMaxScanGap = 1000
+
+// decredHardenedKeyStart is the first index of the hardened keys, which cannot be derived from an xpub
+const decredHardenedKeyStart = 0x80000000
+
+// ScanAddressRange derives the addresses xpub/branch/index from startIndex and checks them against the index
+// until count consecutive unused addresses are found, the branch is 0 for the external and 1 for the change addresses.
+// The returned addresses end with the gap, the index of the first address of the gap is the first index not used by the wallet.
+func (w *Worker) ScanAddressRange(xpub string, branch uint32, startIndex uint32, count uint32) ([]bchain.AddressScanResult, error) {
+	if branch > 1 {
+		return nil, NewAPIError(fmt.Sprintf("Invalid branch %d, must be 0 (external) or 1 (change)", branch), true)
+	}
+	if count == 0 || count > decredMaxScanGap {
+		return nil, NewAPIError(fmt.Sprintf("Invalid gap limit %d, must be between 1 and %d", count, decredMaxScanGap), true)
+	}
+	r := make([]bchain.AddressScanResult, 0, count)
+	var gap uint32
+	for from := startIndex; gap < count; {
+		// derive just enough addresses to complete the gap if none of them is used
+		to := from + count - gap
+		if to > decredHardenedKeyStart || to < from {
+			return nil, NewAPIError(fmt.Sprintf("No gap of %d unused addresses found below index %d", count, uint32(decredHardenedKeyStart)), true)
+		}
+		descriptors, err := w.chainParser.DeriveAddressDescriptorsFromTo(xpub, branch, from, to)
+		if err != nil {
+			return nil, NewAPIError(fmt.Sprintf("Invalid xpub, %v", err), true)
+		}
+		for i, ad := range descriptors {
+			ba, err := w.db.GetAddrDescBalance(ad, db.AddressBalanceDetailNoUTXO)
+			if err != nil {
+				return nil, errors.Annotatef(err, "GetAddrDescBalance")
+			}
+			sr := bchain.AddressScanResult{Index: from + uint32(i)}
+			if a, _, err := w.chainParser.GetAddressesFromAddrDesc(ad); err == nil && len(a) == 1 {
+				sr.Address = a[0]
+			}
+			if ba != nil && ba.Txs > 0 {
+				sr.Txs = ba.Txs
+				sr.Used = true
+				gap = 0
+			} else {
+				gap++
+			}
+			r = append(r, sr)
+		}
+		from = to
+	}
+	return r, nil
+}
+
+// decredDecodeVoteBits decodes the vote bits of the vote transaction using the agendas of its vote version,
+// the problems are only logged, the vote bits are not essential for the transaction
+func (w *Worker) decredDecodeVoteBits(tx *bchain.Tx) *DecredVoteBits {
+	dp := w.chainParser.(*dcr.DecredParser)
+	voteBits, version, ok := dp.GetVoteBits(tx)
+	if !ok {
+		return nil
+	}
+	agendas, err := w.decred.GetVoteAgendas(version)
+	if err != nil {
+		glog.Warning("GetVoteAgendas ", version, ": ", err)
+		agendas = nil
+	}
+	d, err := dp.DecodeVoteBits(voteBits, agendas)
+	if err != nil {
+		glog.Warning("DecodeVoteBits tx ", tx.Txid, ": ", err)
+		return nil
+	}
+	r := &DecredVoteBits{
+		VoteBits:       d.VoteBits,
+		ApprovesParent: d.ApprovesParent,
+	}
+	for _, c := range d.Choices {
+		r.Choices = append(r.Choices, DecredVoteChoice{AgendaID: c.AgendaID, ChoiceID: c.ChoiceID})
+	}
+	return r
+}
+
+// DecredDefaultMinConf is the confirmation depth of the address balance stored in the index,
+// the balance counts the outputs of the indexed blocks
+const DecredDefaultMinConf = 1
+
+// SetDecredBalanceMinConf sets the balance of the address to the value of its outputs with at least minConf
+// confirmations. For minConf 0 the unconfirmed balance is added to the balance, for minConf above the
+// default the outputs of the index with fewer confirmations are excluded.
+func (w *Worker) SetDecredBalanceMinConf(address *Address, minConf int) error {
+	switch {
+	case minConf < 0:
+		return NewAPIError(fmt.Sprintf("Invalid minconf %d", minConf), true)
+	case minConf == DecredDefaultMinConf:
+		return nil
+	case minConf == 0:
+		var b big.Int
+		b.Add((*big.Int)(address.BalanceSat), (*big.Int)(address.UnconfirmedBalanceSat))
+		address.BalanceSat = (*Amount)(&b)
+		return nil
+	}
+	utxos, err := w.GetAddressUtxo(address.AddrStr, true)
+	if err != nil {
+		return err
+	}
+	var b big.Int
+	for i := range utxos {
+		if utxos[i].Confirmations >= minConf {
+			b.Add(&b, (*big.Int)(utxos[i].AmountSat))
+		}
+	}
+	address.BalanceSat = (*Amount)(&b)
+	return nil
+}
+
+// limits of the address clustering, the cluster is truncated when they are reached
+const (
+	maxClusterDepth     = 5
+	maxClusterAddresses = 1000
+	maxClusterTxs       = 10000
+)
+
+// clusterLinkConfidence is the probability that the inputs of a transaction, which is not a mix,
+// are owned by the same owner, the confidence of an address is multiplied by it for each link
+const clusterLinkConfidence = 0.9
+
+// ClusterAddresses finds the addresses owned by the owner of the seed address by the common-input-ownership heuristic.
+// The input addresses of the transactions spending from the seed address are added to the cluster, the same is done
+// for the added addresses up to depth links from the seed. The CoinShuffle++ mixes and the ticket purchases join the inputs
+// of several owners and are skipped. The transactions are looked up in the index.
+func (w *Worker) ClusterAddresses(seedAddr string, depth int) (*DecredAddressCluster, error) {
+	if depth < 1 || depth > maxClusterDepth {
+		return nil, NewAPIError(fmt.Sprintf("Depth must be between 1 and %d", maxClusterDepth), true)
+	}
+	seed, address, err := w.getAddrDescAndNormalizeAddress(seedAddr)
+	if err != nil {
+		return nil, err
+	}
+	dp := w.chainParser.(*dcr.DecredParser)
+	r := &DecredAddressCluster{Address: address, Depth: depth, Addresses: []DecredClusterAddress{}, Confidence: 1}
+	cluster := map[string]struct{}{string(seed): {}}
+	visitedTxs := make(map[string]struct{})
+	frontier := []bchain.AddressDescriptor{seed}
+	for level := 1; level <= depth && len(frontier) > 0; level++ {
+		confidence := math.Pow(clusterLinkConfidence, float64(level))
+		var next []bchain.AddressDescriptor
+		for _, addrDesc := range frontier {
+			var txids []string
+			err := w.db.GetAddrDescTransactions(addrDesc, 0, maxUint32, func(txid string, height uint32, indexes []int32) error {
+				if _, found := visitedTxs[txid]; found {
+					return nil
+				}
+				if len(visitedTxs) >= maxClusterTxs {
+					r.Truncated = true
+					return &db.StopIteration{}
+				}
+				// all the iterated transactions count toward the limit, also those only funding the address
+				visitedTxs[txid] = struct{}{}
+				// only the transactions spending from the address link its inputs, the inputs have negative indexes
+				for _, i := range indexes {
+					if i < 0 {
+						txids = append(txids, txid)
+						break
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, errors.Annotatef(err, "GetAddrDescTransactions %v", addrDesc)
+			}
+			for _, txid := range txids {
+				ta, err := w.db.GetTxAddresses(txid)
+				if err != nil {
+					return nil, errors.Annotatef(err, "GetTxAddresses %v", txid)
+				}
+				if ta == nil || len(ta.Inputs) < 2 {
+					continue
+				}
+				if isDecredMix(dp, ta) {
+					r.SkippedMixes++
+					continue
+				}
+				// the split tickets join the inputs of several owners, the votes and revocations
+				// spend only the ticket and do not link any addresses
+				ti, err := w.db.GetTicketInfo(txid)
+				if err != nil {
+					return nil, errors.Annotatef(err, "GetTicketInfo %v", txid)
+				}
+				if ti != nil {
+					r.SkippedTickets++
+					continue
+				}
+				for i := range ta.Inputs {
+					in := ta.Inputs[i].AddrDesc
+					if len(in) == 0 {
+						continue
+					}
+					if _, found := cluster[string(in)]; found {
+						continue
+					}
+					addresses, _, err := w.chainParser.GetAddressesFromAddrDesc(in)
+					if err != nil || len(addresses) == 0 {
+						continue
+					}
+					if len(r.Addresses) == maxClusterAddresses {
+						r.Truncated = true
+						return r, nil
+					}
+					cluster[string(in)] = struct{}{}
+					next = append(next, in)
+					r.Addresses = append(r.Addresses, DecredClusterAddress{Address: addresses[0], Depth: level, Confidence: confidence})
+					r.Confidence = confidence
+				}
+			}
+		}
+		frontier = next
+	}
+	return r, nil
+}
+
+// isDecredMix checks if the indexed transaction is a CoinShuffle++ mix, only the values of the outputs
+// and the number of the inputs are needed by the heuristic
+func isDecredMix(dp *dcr.DecredParser, ta *db.TxAddresses) bool {
+	tx := &bchain.Tx{Vin: make([]bchain.Vin, len(ta.Inputs)), Vout: make([]bchain.Vout, len(ta.Outputs))}
+	for i := range ta.Outputs {
+		tx.Vout[i].ValueSat = ta.Outputs[i].ValueSat
+	}
+	_, _, ok := dp.GetMixDenomination(tx)
+	return ok
+}
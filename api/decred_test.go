@@ -0,0 +1,78 @@
+// +build unittest
+
+package api
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func Test_decredAddressReused(t *testing.T) {
+	tests := []struct {
+		name    string
+		first   []string
+		txid    string
+		funding string
+		want    bool
+	}{
+		{name: "output, first use", first: []string{"tx1"}, txid: "tx1", want: false},
+		{name: "output, first use with later txs", first: []string{"tx1", "tx2"}, txid: "tx1", want: false},
+		{name: "output, reused", first: []string{"tx1", "tx2"}, txid: "tx2", want: true},
+		{name: "output, reused later", first: []string{"tx1", "tx2"}, txid: "tx9", want: true},
+		{name: "output, mempool, new address", first: nil, txid: "tx9", want: false},
+		{name: "output, mempool, reused", first: []string{"tx1"}, txid: "tx9", want: true},
+		{name: "input, spends the funding", first: []string{"tx1", "tx2"}, txid: "tx2", funding: "tx1", want: false},
+		{name: "input, mempool, spends the funding", first: []string{"tx1"}, txid: "tx9", funding: "tx1", want: false},
+		{name: "input, funded twice", first: []string{"tx1", "tx2"}, txid: "tx3", funding: "tx1", want: true},
+		{name: "input, funding is not the first", first: []string{"tx1", "tx2"}, txid: "tx3", funding: "tx2", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decredAddressReused(tt.first, tt.txid, tt.funding); got != tt.want {
+				t.Errorf("decredAddressReused() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_decredReuseCache(t *testing.T) {
+	c := newDecredReuseCache()
+	if _, found := c.get("addr"); found {
+		t.Fatal("get() of empty cache found")
+	}
+	c.set("addr", []string{"tx1", "tx2"})
+	got, found := c.get("addr")
+	if !found || !reflect.DeepEqual(got, []string{"tx1", "tx2"}) {
+		t.Errorf("get() = %v, %v, want [tx1 tx2], true", got, found)
+	}
+	// the cache is emptied when it is full
+	for i := 0; len(c.txids) < decredReuseCacheSize; i++ {
+		c.txids[strconv.Itoa(i)] = nil
+	}
+	c.set("other", nil)
+	if _, found := c.get("addr"); found || len(c.txids) != 1 {
+		t.Errorf("full cache not emptied, %d entries", len(c.txids))
+	}
+}
+
+func TestWorker_ScanAddressRange_InvalidParams(t *testing.T) {
+	w := &Worker{}
+	for _, tt := range []struct {
+		name   string
+		branch uint32
+		count  uint32
+	}{
+		{name: "branch 2", branch: 2, count: 20},
+		{name: "hardened branch", branch: decredHardenedKeyStart, count: 20},
+		{name: "zero gap", branch: 0, count: 0},
+		{name: "gap over limit", branch: 1, count: decredMaxScanGap + 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := w.ScanAddressRange("xpub", tt.branch, 0, tt.count)
+			if _, ok := err.(*APIError); !ok {
+				t.Errorf("ScanAddressRange() error = %v, want APIError", err)
+			}
+		})
+	}
+}
@@ -112,6 +112,9 @@ type Vin struct {
 	Hex        string                   `json:"hex,omitempty"`
 	Asm        string                   `json:"asm,omitempty"`
 	Coinbase   string                   `json:"coinbase,omitempty"`
+	// Decred specific
+	AddressReuse bool `json:"addressReuse,omitempty"`
+	Tree         int8 `json:"tree,omitempty"`
 }
 
 // Vout contains information about single transaction output
@@ -128,6 +131,8 @@ type Vout struct {
 	Addresses   []string                 `json:"addresses"`
 	Searchable  bool                     `json:"-"`
 	Type        string                   `json:"type,omitempty"`
+	// Decred specific
+	AddressReuse bool `json:"addressReuse,omitempty"`
 }
 
 // TokenType specifies type of token
@@ -195,6 +200,11 @@ type Tx struct {
 	CoinSpecificJSON json.RawMessage   `json:"-"`
 	TokenTransfers   []TokenTransfer   `json:"tokenTransfers,omitempty"`
 	EthereumSpecific *EthereumSpecific `json:"ethereumSpecific,omitempty"`
+	// Decred specific
+	TxType       string          `json:"txType,omitempty"`
+	Mixed        bool            `json:"mixed,omitempty"`
+	VoteBits     *DecredVoteBits `json:"voteBits,omitempty"`
+	ExplorerLink string          `json:"explorerLink,omitempty"`
 }
 
 // Paging contains information about paging for address, blocks and block
@@ -232,6 +242,8 @@ type AddressFilter struct {
 	TokensToReturn TokensToReturn
 	// OnlyConfirmed set to true will ignore mempool transactions; mempool is also ignored if FromHeight/ToHeight filter is specified
 	OnlyConfirmed bool
+	// PaginationToken continues the transaction history after the last transaction of the previous page, the page is ignored
+	PaginationToken string
 }
 
 // Address holds information about address and its transactions
@@ -251,6 +263,7 @@ type Address struct {
 	UsedTokens            int                   `json:"usedTokens,omitempty"`
 	Tokens                []Token               `json:"tokens,omitempty"`
 	Erc20Contract         *bchain.Erc20Contract `json:"erc20Contract,omitempty"`
+	PaginationToken       string                `json:"paginationToken,omitempty"`
 	// helpers for explorer
 	Filter        string              `json:"-"`
 	XPubAddresses map[string]struct{} `json:"-"`
@@ -266,6 +279,10 @@ type Utxo struct {
 	Address       string  `json:"address,omitempty"`
 	Path          string  `json:"path,omitempty"`
 	Locktime      uint32  `json:"lockTime,omitempty"`
+	// Decred specific
+	Mature   *bool  `json:"mature,omitempty"`
+	UTXOType string `json:"utxoType,omitempty"`
+	Tree     *int8  `json:"tree,omitempty"`
 }
 
 // Utxos is array of Utxo
@@ -307,6 +324,102 @@ type BlockInfo struct {
 	Bits          string      `json:"bits"`
 	Difficulty    string      `json:"difficulty"`
 	Txids         []string    `json:"tx,omitempty"`
+	// Decred specific
+	StakeDifficultySat *Amount             `json:"stakeDifficulty,omitempty"`
+	Rewards            *DecredBlockRewards `json:"rewards,omitempty"`
+	Approved           *bool               `json:"approved,omitempty"`
+	Approval           string              `json:"approval,omitempty"`
+	ExtraData          string              `json:"extraData,omitempty"`
+}
+
+// DecredBlockRewards contains the breakdown of the Decred block subsidy
+type DecredBlockRewards struct {
+	PoWSat      *Amount `json:"pow"`
+	StakeSat    *Amount `json:"stake"`
+	TreasurySat *Amount `json:"treasury"`
+}
+
+// DecredVoteChoice is the choice of the vote in the agenda
+type DecredVoteChoice struct {
+	AgendaID string `json:"agendaId"`
+	ChoiceID string `json:"choiceId"`
+}
+
+// DecredVoteBits contains the decoded vote bits of the Decred vote
+type DecredVoteBits struct {
+	VoteBits       uint16             `json:"voteBits"`
+	ApprovesParent bool               `json:"approvesParent"`
+	Choices        []DecredVoteChoice `json:"choices,omitempty"`
+}
+
+// DecredOpReturnTx is the transaction output containing the OP_RETURN data
+type DecredOpReturnTx struct {
+	Txid        string `json:"txid"`
+	Vout        int32  `json:"vout"`
+	Blockhash   string `json:"blockHash,omitempty"`
+	Blockheight int    `json:"blockHeight"`
+	Blocktime   int64  `json:"blockTime,omitempty"`
+}
+
+// DecredTicket contains the lifecycle of the Decred ticket
+type DecredTicket struct {
+	Txid             string `json:"txid"`
+	Status           string `json:"status"`
+	PurchaseHeight   uint32 `json:"purchaseHeight"`
+	MaturityHeight   uint32 `json:"maturityHeight"`
+	ExpiryHeight     uint32 `json:"expiryHeight"`
+	CommittedAddress string `json:"committedAddress,omitempty"`
+	SpendTxid        string `json:"spendTxid,omitempty"`
+	SpendHeight      uint32 `json:"spendHeight,omitempty"`
+}
+
+// DecredAtomicSwap contains the atomic swap contract and its spend
+type DecredAtomicSwap struct {
+	Txid             string `json:"txid"`
+	Vout             int32  `json:"vout"`
+	Status           string `json:"status"`
+	SpendTxid        string `json:"spendTxid"`
+	SpendHeight      uint32 `json:"spendHeight"`
+	Contract         string `json:"contract"`
+	SecretHash       string `json:"secretHash"`
+	Secret           string `json:"secret,omitempty"`
+	RecipientAddress string `json:"recipientAddress"`
+	RefundAddress    string `json:"refundAddress"`
+	LockTime         int64  `json:"lockTime"`
+	RelativeLockTime bool   `json:"relativeLockTime,omitempty"`
+}
+
+// DecredMixingBatch contains the CoinShuffle++ mixes of the same denomination confirmed in the same block
+type DecredMixingBatch struct {
+	Height       uint32   `json:"height"`
+	Denomination *Amount  `json:"denomination"`
+	Txids        []string `json:"txids"`
+	MixedOutputs int      `json:"mixedOutputs"`
+	Inputs       int      `json:"inputs"`
+}
+
+// DecredClusterAddress is an address of the cluster, Depth is the number of the common-input links to the seed address
+type DecredClusterAddress struct {
+	Address    string  `json:"address"`
+	Depth      int     `json:"depth"`
+	Confidence float64 `json:"confidence"`
+}
+
+// DecredAddressCluster contains the addresses probably owned by the owner of the seed address,
+// Confidence is the confidence of the least certain address of the cluster
+type DecredAddressCluster struct {
+	Address        string                 `json:"address"`
+	Depth          int                    `json:"depth"`
+	Addresses      []DecredClusterAddress `json:"addresses"`
+	Confidence     float64                `json:"confidence"`
+	SkippedMixes   int                    `json:"skippedMixes"`
+	SkippedTickets int                    `json:"skippedTickets"`
+	Truncated      bool                   `json:"truncated,omitempty"`
+}
+
+// DecredMixingSession contains the mixing batches detected among the transactions
+type DecredMixingSession struct {
+	Batches []DecredMixingBatch `json:"batches"`
 }
 
 // Block contains information about block
@@ -353,6 +466,7 @@ type BackendInfo struct {
 	ProtocolVersion string  `json:"protocolVersion,omitempty"`
 	Timeoffset      float64 `json:"timeOffset,omitempty"`
 	Warnings        string  `json:"warnings,omitempty"`
+	SyncProgress    float64 `json:"syncProgress,omitempty"`
 }
 
 // SystemInfo contains information about the running blockbook and backend instance
@@ -367,6 +481,22 @@ type MempoolTxid struct {
 	Txid string `json:"txid"`
 }
 
+// DecredMempoolTx contains the fee and size information of the Decred mempool transaction
+type DecredMempoolTx struct {
+	Txid    string   `json:"txid"`
+	Time    int64    `json:"time"`
+	Size    int      `json:"size"`
+	FeesSat *Amount  `json:"fees"`
+	Depends []string `json:"depends,omitempty"`
+}
+
+// DecredMempoolTxs contains a page of the verbose Decred mempool
+type DecredMempoolTxs struct {
+	Paging
+	Mempool     []DecredMempoolTx `json:"mempool"`
+	MempoolSize int               `json:"mempoolSize"`
+}
+
 // MempoolTxids contains a list of mempool txids with paging information
 type MempoolTxids struct {
 	Paging
@@ -2,10 +2,13 @@ package api
 
 import (
 	"blockbook/bchain"
+	"blockbook/bchain/coins"
+	"blockbook/bchain/coins/dcr"
 	"blockbook/bchain/coins/eth"
 	"blockbook/common"
 	"blockbook/db"
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -28,6 +31,8 @@ type Worker struct {
 	chainType   bchain.ChainType
 	mempool     bchain.Mempool
 	is          *common.InternalState
+	decred      *dcr.DecredRPC
+	decredReuse *decredReuseCache
 }
 
 // NewWorker creates new api worker
@@ -41,6 +46,10 @@ func NewWorker(db *db.RocksDB, chain bchain.BlockChain, mempool bchain.Mempool,
 		mempool:     mempool,
 		is:          is,
 	}
+	if d := dcr.GetDecredRPC(coins.GetBlockChainBackend(chain)); d != nil {
+		w.decred = d
+		w.decredReuse = newDecredReuseCache()
+	}
 	return w, nil
 }
 
@@ -151,6 +160,7 @@ func (w *Worker) GetTransactionFromBchainTx(bchainTx *bchain.Tx, height uint32,
 		vin.Sequence = int64(bchainVin.Sequence)
 		vin.Hex = bchainVin.ScriptSig.Hex
 		vin.Coinbase = bchainVin.Coinbase
+		vin.Tree = bchainVin.Tree
 		if w.chainType == bchain.ChainBitcoinType {
 			//  bchainVin.Txid=="" is coinbase transaction
 			if bchainVin.Txid != "" {
@@ -161,7 +171,16 @@ func (w *Worker) GetTransactionFromBchainTx(bchainTx *bchain.Tx, height uint32,
 				}
 				if tas == nil {
 					// try to load from backend
-					otx, _, err := w.txCache.GetTransaction(bchainVin.Txid)
+					var vout *bchain.Vout
+					if w.decred != nil {
+						// the backend caches the spent outputs, only the output of the input is resolved
+						vout, err = w.decredGetPrevOut(bchainVin.Txid, bchainVin.Vout)
+					} else {
+						var otx *bchain.Tx
+						if otx, _, err = w.txCache.GetTransaction(bchainVin.Txid); err == nil && len(otx.Vout) > int(vin.Vout) {
+							vout = &otx.Vout[vin.Vout]
+						}
+					}
 					if err != nil {
 						if err == bchain.ErrTxNotFound {
 							// try to get AddrDesc using coin specific handling and continue processing the tx
@@ -182,8 +201,7 @@ func (w *Worker) GetTransactionFromBchainTx(bchainTx *bchain.Tx, height uint32,
 							glog.Warning("DB inconsistency:  tx ", bchainVin.Txid, ": not found in txAddresses")
 						}
 					}
-					if len(otx.Vout) > int(vin.Vout) {
-						vout := &otx.Vout[vin.Vout]
+					if vout != nil {
 						vin.ValueSat = (*Amount)(&vout.ValueSat)
 						vin.AddrDesc, vin.Addresses, vin.Searchable, err = w.getAddressesFromVout(vout)
 						if err != nil {
@@ -204,6 +222,9 @@ func (w *Worker) GetTransactionFromBchainTx(bchainTx *bchain.Tx, height uint32,
 				if vin.ValueSat != nil {
 					valInSat.Add(&valInSat, (*big.Int)(vin.ValueSat))
 				}
+			} else if len(bchainVin.Addresses) > 0 {
+				// synthetic sender address set by the parser, for example Decred treasury
+				vin.Addresses = bchainVin.Addresses
 			}
 		} else if w.chainType == bchain.ChainEthereumType {
 			if len(bchainVin.Addresses) > 0 {
@@ -228,6 +249,10 @@ func (w *Worker) GetTransactionFromBchainTx(bchainTx *bchain.Tx, height uint32,
 		if err != nil {
 			glog.V(2).Infof("getAddressesFromVout error %v, %v, output %v", err, bchainTx.Txid, bchainVout.N)
 		}
+		if dp, ok := w.chainParser.(*dcr.DecredParser); ok {
+			vout.Type = dp.GetScriptType(bchainVout)
+			vout.Asm = dp.GetScriptDisassembly(bchainVout)
+		}
 		if ta != nil {
 			vout.Spent = ta.Outputs[i].Spent
 			if spendingTxs && vout.Spent {
@@ -324,6 +349,16 @@ func (w *Worker) GetTransactionFromBchainTx(bchainTx *bchain.Tx, height uint32,
 		TokenTransfers:   tokens,
 		EthereumSpecific: ethSpecific,
 	}
+	if dp, ok := w.chainParser.(*dcr.DecredParser); ok {
+		r.TxType = dp.GetTxType(bchainTx)
+		_, _, r.Mixed = dp.GetMixDenomination(bchainTx)
+	}
+	if w.decred != nil {
+		if r.TxType == dcr.TxTypeVote {
+			r.VoteBits = w.decredDecodeVoteBits(bchainTx)
+		}
+		r.ExplorerLink = w.decred.ExplorerTxLink(r.Txid)
+	}
 	return r, nil
 }
 
@@ -341,19 +376,10 @@ func (w *Worker) getAddressTxids(addrDesc bchain.AddressDescriptor, mempool bool
 		}
 	} else {
 		callback = func(txid string, height uint32, indexes []int32) error {
-			for _, index := range indexes {
-				vout := index
-				if vout < 0 {
-					vout = ^vout
-				}
-				if (filter.Vout == AddressFilterVoutInputs && index < 0) ||
-					(filter.Vout == AddressFilterVoutOutputs && index >= 0) ||
-					(vout == int32(filter.Vout)) {
-					txids = append(txids, txid)
-					if len(txids) >= maxResults {
-						return &db.StopIteration{}
-					}
-					break
+			if filter.matchVout(indexes) {
+				txids = append(txids, txid)
+				if len(txids) >= maxResults {
+					return &db.StopIteration{}
 				}
 			}
 			return nil
@@ -374,6 +400,12 @@ func (w *Worker) getAddressTxids(addrDesc bchain.AddressDescriptor, mempool bool
 				}
 			}
 		}
+	} else if w.decred != nil && w.is.InitialSync {
+		// the index is being built, serve the transactions from the dcrd address index
+		txids, err = w.decredGetAddressTxids(addrDesc, filter, maxResults)
+		if err != nil {
+			return nil, err
+		}
 	} else {
 		to := filter.ToHeight
 		if to == 0 {
@@ -471,6 +503,61 @@ func (w *Worker) txFromTxAddress(txid string, ta *db.TxAddresses, bi *db.BlockIn
 	return r
 }
 
+// matchVout checks if any of the indexes of the address in the transaction passes the vout filter
+func (filter *AddressFilter) matchVout(indexes []int32) bool {
+	if filter.Vout == AddressFilterVoutOff {
+		return true
+	}
+	for _, index := range indexes {
+		vout := index
+		if vout < 0 {
+			vout = ^vout
+		}
+		if (filter.Vout == AddressFilterVoutInputs && index < 0) ||
+			(filter.Vout == AddressFilterVoutOutputs && index >= 0) ||
+			(vout == int32(filter.Vout)) {
+			return true
+		}
+	}
+	return false
+}
+
+// getAddressTxidsAfter returns up to maxResults positions of the confirmed address transactions following the cursor
+func (w *Worker) getAddressTxidsAfter(addrDesc bchain.AddressDescriptor, filter *AddressFilter, cursor *db.AddressTxCursor, maxResults int) ([]db.AddressTxCursor, error) {
+	r := make([]db.AddressTxCursor, 0, maxResults)
+	err := w.db.GetAddrDescTransactionsAfter(addrDesc, filter.FromHeight, cursor, func(txid string, height uint32, indexes []int32) error {
+		if filter.matchVout(indexes) {
+			r = append(r, db.AddressTxCursor{Height: height, Txid: txid})
+			if len(r) >= maxResults {
+				return &db.StopIteration{}
+			}
+		}
+		return nil
+	})
+	return r, err
+}
+
+// encodePaginationToken encodes the position of the last transaction of the page as base64 of height:txid
+func encodePaginationToken(c *db.AddressTxCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(c.Height), 10) + ":" + c.Txid))
+}
+
+func decodePaginationToken(token string) (*db.AddressTxCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	i := bytes.IndexByte(b, ':')
+	if i <= 0 || i == len(b)-1 {
+		return nil, errors.New("Invalid pagination token")
+	}
+	height, err := strconv.ParseUint(string(b[:i]), 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	return &db.AddressTxCursor{Height: uint32(height), Txid: string(b[i+1:])}, nil
+}
+
 func computePaging(count, page, itemsOnPage int) (Paging, int, int, int) {
 	from := page * itemsOnPage
 	totalPages := (count - 1) / itemsOnPage
@@ -684,6 +771,17 @@ func (w *Worker) GetAddress(address string, page int, txsOnPage int, option Acco
 	if err != nil {
 		return nil, err
 	}
+	var cursor *db.AddressTxCursor
+	var paginationToken string
+	if filter.PaginationToken != "" {
+		if w.chainType != bchain.ChainBitcoinType {
+			return nil, NewAPIError("Pagination token is not supported", true)
+		}
+		cursor, err = decodePaginationToken(filter.PaginationToken)
+		if err != nil {
+			return nil, NewAPIError(fmt.Sprintf("Invalid pagination token, %v", err), true)
+		}
+	}
 	if w.chainType == bchain.ChainEthereumType {
 		var n uint64
 		ba, tokens, erc20c, n, nonTokenTxs, totalResults, err = w.getEthereumTypeAddressBalances(addrDesc, option, filter)
@@ -706,10 +804,15 @@ func (w *Worker) GetAddress(address string, page int, txsOnPage int, option Acco
 			}
 		}
 	}
+	// the index is being built, the pages of the address history are fetched from the dcrd address index
+	decredPaging := w.decred != nil && w.is.InitialSync && filter.Vout == AddressFilterVoutOff && filter.FromHeight == 0 && filter.ToHeight == 0
 	// if there are only unconfirmed transactions, there is no paging
 	if ba == nil {
 		ba = &db.AddrBalance{}
-		page = 0
+		// the address may not be indexed yet
+		if !decredPaging {
+			page = 0
+		}
 	}
 	// process mempool, only if toHeight is not specified
 	if filter.ToHeight == 0 && !filter.OnlyConfirmed {
@@ -728,7 +831,8 @@ func (w *Worker) GetAddress(address string, page int, txsOnPage int, option Acco
 					unconfirmedTxs++
 					uBalSat.Add(&uBalSat, tx.getAddrVoutValue(addrDesc))
 					uBalSat.Sub(&uBalSat, tx.getAddrVinValue(addrDesc))
-					if page == 0 {
+					// the mempool transactions are on the first page, the pages of the token follow it
+					if page == 0 && cursor == nil {
 						if option == AccountDetailsTxidHistory {
 							txids = append(txids, tx.Txid)
 						} else if option >= AccountDetailsTxHistoryLight {
@@ -741,23 +845,70 @@ func (w *Worker) GetAddress(address string, page int, txsOnPage int, option Acco
 	}
 	// get tx history if requested by option or check mempool if there are some transactions for a new address
 	if option >= AccountDetailsTxidHistory {
-		txc, err := w.getAddressTxids(addrDesc, false, filter, (page+1)*txsOnPage)
-		if err != nil {
-			return nil, errors.Annotatef(err, "getAddressTxids %v false", addrDesc)
+		var txc []string
+		var from, to int
+		if cursor != nil {
+			// one more transaction tells if there is a next page
+			tc, err := w.getAddressTxidsAfter(addrDesc, filter, cursor, txsOnPage+1)
+			if err != nil {
+				return nil, errors.Annotatef(err, "getAddressTxidsAfter %v", addrDesc)
+			}
+			if len(tc) > txsOnPage {
+				tc = tc[:txsOnPage]
+				paginationToken = encodePaginationToken(&tc[len(tc)-1])
+			}
+			txc = make([]string, len(tc))
+			for i := range tc {
+				txc[i] = tc[i].Txid
+			}
+			pg = Paging{ItemsOnPage: txsOnPage}
+			from, to = 0, len(txc)
+		} else if decredPaging {
+			// fetch only the requested page from the dcrd address index
+			pt, next, err := w.decredGetAddressTxsPage(addrDesc, page, txsOnPage)
+			if err != nil {
+				return nil, err
+			}
+			// the number of the transactions is not known until the index is built
+			pg = Paging{ItemsOnPage: txsOnPage, Page: page + 1, TotalPages: page + 1}
+			if next {
+				pg.TotalPages = -1
+			}
+			for _, tx := range pt {
+				if option == AccountDetailsTxidHistory {
+					txids = append(txids, tx.Txid)
+				} else {
+					txs = append(txs, tx)
+				}
+			}
+		} else {
+			txc, err = w.getAddressTxids(addrDesc, false, filter, (page+1)*txsOnPage)
+			if err != nil {
+				return nil, errors.Annotatef(err, "getAddressTxids %v false", addrDesc)
+			}
+			pg, from, to, page = computePaging(len(txc), page, txsOnPage)
+			if len(txc) >= txsOnPage {
+				if totalResults < 0 {
+					pg.TotalPages = -1
+				} else {
+					pg, _, _, _ = computePaging(totalResults, page, txsOnPage)
+				}
+			}
+			// the token continues after the full page, the next page may be empty
+			if to-from == txsOnPage && w.chainType == bchain.ChainBitcoinType {
+				ta, err := w.db.GetTxAddresses(txc[to-1])
+				if err != nil {
+					return nil, errors.Annotatef(err, "GetTxAddresses %v", txc[to-1])
+				}
+				if ta != nil {
+					paginationToken = encodePaginationToken(&db.AddressTxCursor{Height: ta.Height, Txid: txc[to-1]})
+				}
+			}
 		}
 		bestheight, _, err := w.db.GetBestBlock()
 		if err != nil {
 			return nil, errors.Annotatef(err, "GetBestBlock")
 		}
-		var from, to int
-		pg, from, to, page = computePaging(len(txc), page, txsOnPage)
-		if len(txc) >= txsOnPage {
-			if totalResults < 0 {
-				pg.TotalPages = -1
-			} else {
-				pg, _, _, _ = computePaging(totalResults, page, txsOnPage)
-			}
-		}
 		for i := from; i < to; i++ {
 			txid := txc[i]
 			if option == AccountDetailsTxidHistory {
@@ -790,6 +941,7 @@ func (w *Worker) GetAddress(address string, page int, txsOnPage int, option Acco
 		Tokens:                tokens,
 		Erc20Contract:         erc20c,
 		Nonce:                 nonce,
+		PaginationToken:       paginationToken,
 	}
 	glog.Info("GetAddress ", address, " finished in ", time.Since(start))
 	return r, nil
@@ -897,6 +1049,11 @@ func (w *Worker) getAddrDescUtxo(addrDesc bchain.AddressDescriptor, ba *db.AddrB
 			}
 		}
 	}
+	if w.decred != nil {
+		if err = w.decredSetUtxoInfo(r); err != nil {
+			return nil, err
+		}
+	}
 	return r, nil
 }
 
@@ -970,7 +1127,14 @@ func (w *Worker) GetBlock(bid string, page int, txsOnPage int) (*Block, error) {
 	if hash == "" {
 		return nil, NewAPIError("Block not found", true)
 	}
-	bi, err := w.chain.GetBlockInfo(hash)
+	var bi *bchain.BlockInfo
+	var r *dcr.DecredBlockRewards
+	if w.decred != nil {
+		// the rewards are computed from the transactions of the same getblock call
+		bi, r, err = w.decred.GetBlockInfoRewards(hash)
+	} else {
+		bi, err = w.chain.GetBlockInfo(hash)
+	}
 	if err != nil {
 		if err == bchain.ErrBlockNotFound {
 			return nil, NewAPIError("Block not found", true)
@@ -1005,6 +1169,28 @@ func (w *Worker) GetBlock(bid string, page int, txsOnPage int) (*Block, error) {
 	}
 	txs = txs[:txi]
 	bi.Txids = nil
+	var stakeDifficulty *Amount
+	if bi.StakeDifficulty != "" {
+		sd, err := w.chainParser.AmountToBigInt(bi.StakeDifficulty)
+		if err != nil {
+			glog.Warning("GetBlock ", bid, ", stake difficulty ", bi.StakeDifficulty, ": ", err)
+		} else {
+			stakeDifficulty = (*Amount)(&sd)
+		}
+	}
+	var rewards *DecredBlockRewards
+	if r != nil {
+		rewards = &DecredBlockRewards{
+			PoWSat:      (*Amount)(&r.PoW),
+			StakeSat:    (*Amount)(&r.Stake),
+			TreasurySat: (*Amount)(&r.Treasury),
+		}
+	}
+	var approved *bool
+	var approval string
+	if w.decred != nil {
+		approved, approval = w.decredBlockApproval(bi.Hash, bi.Height, bi.Next)
+	}
 	glog.Info("GetBlock ", bid, ", page ", page, " finished in ", time.Since(start))
 	return &Block{
 		Paging: pg,
@@ -1022,6 +1208,12 @@ func (w *Worker) GetBlock(bid string, page int, txsOnPage int) (*Block, error) {
 			Nonce:         string(bi.Nonce),
 			Txids:         bi.Txids,
 			Version:       bi.Version,
+
+			StakeDifficultySat: stakeDifficulty,
+			Rewards:            rewards,
+			Approved:           approved,
+			Approval:           approval,
+			ExtraData:          bi.ExtraData,
 		},
 		TxCount:      txCount,
 		Transactions: txs,
@@ -1143,6 +1335,7 @@ func (w *Worker) GetSystemInfo(internal bool) (*SystemInfo, error) {
 		Timeoffset:      ci.Timeoffset,
 		Version:         ci.Version,
 		Warnings:        ci.Warnings,
+		SyncProgress:    ci.SyncProgress,
 	}
 	glog.Info("GetSystemInfo finished in ", time.Since(start))
 	return &SystemInfo{blockbookInfo, backendInfo}, nil
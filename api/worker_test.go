@@ -0,0 +1,26 @@
+// +build unittest
+
+package api
+
+import (
+	"blockbook/db"
+	"reflect"
+	"testing"
+)
+
+func Test_decodePaginationToken(t *testing.T) {
+	cursor := &db.AddressTxCursor{Height: 225494, Txid: "7c3be24063f268aaa1ed81b64776798f56088757641a34fb156c4f51ed2e9d25"}
+	token := encodePaginationToken(cursor)
+	got, err := decodePaginationToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, cursor) {
+		t.Errorf("decodePaginationToken() = %+v, want %+v", got, cursor)
+	}
+	for _, token := range []string{"", "not base64!", encodePaginationToken(&db.AddressTxCursor{Height: 1}), "MTIz"} {
+		if _, err := decodePaginationToken(token); err == nil {
+			t.Errorf("decodePaginationToken(%q) succeeded, want error", token)
+		}
+	}
+}
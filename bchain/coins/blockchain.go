@@ -136,6 +136,9 @@ func NewBlockChain(coin string, configfile string, pushHandler func(bchain.Notif
 	if err != nil {
 		return nil, nil, err
 	}
+	if bm, ok := bc.(backendWithMetrics); ok {
+		bm.SetMetrics(metrics)
+	}
 	err = bc.Initialize()
 	if err != nil {
 		return nil, nil, err
@@ -147,6 +150,19 @@ func NewBlockChain(coin string, configfile string, pushHandler func(bchain.Notif
 	return &blockChainWithMetrics{b: bc, m: metrics}, &mempoolWithMetrics{mempool: mempool, m: metrics}, nil
 }
 
+// GetBlockChainBackend returns the coin specific implementation of the chain wrapped by NewBlockChain
+func GetBlockChainBackend(chain bchain.BlockChain) bchain.BlockChain {
+	if c, ok := chain.(*blockChainWithMetrics); ok {
+		return c.b
+	}
+	return chain
+}
+
+// backendWithMetrics is implemented by the backends that collect metrics of their own RPC calls
+type backendWithMetrics interface {
+	SetMetrics(metrics *common.Metrics)
+}
+
 type blockChainWithMetrics struct {
 	b bchain.BlockChain
 	m *common.Metrics
@@ -0,0 +1,294 @@
+// +build unittest
+
+package dcr
+
+import (
+	"blockbook/bchain"
+	"blockbook/db"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	benchBlocks      = 100
+	benchTxsPerBlock = 50
+	// the time of the first block of the mainnet
+	benchFirstBlockTime = 1454954535
+)
+
+func benchBlockHash(height uint32) string {
+	return fmt.Sprintf("%064x", height)
+}
+
+func benchTxid(height uint32, i int) string {
+	return fmt.Sprintf("%056x%08x", height, i)
+}
+
+// benchBlock returns a block with the shape of the early mainnet blocks filled by regular p2pkh transfers,
+// the transactions spend the outputs of the transactions at the same position in the previous block
+func benchBlock(height uint32) map[string]interface{} {
+	txs := make([]RawTx, benchTxsPerBlock)
+	for i := range txs {
+		tx := &txs[i]
+		tx.Txid = benchTxid(height, i)
+		tx.Version = 1
+		if i == 0 || height == 1 {
+			tx.Vin = []Vin{{Coinbase: "0000000000000000000000000000000000000000"}}
+		} else {
+			tx.Vin = []Vin{{
+				Txid:      benchTxid(height-1, i),
+				AmountIn:  1,
+				ScriptSig: &ScriptSig{Hex: "47304402204b7c5d22b1a15a1ebd6f2c4b7e6a2e2fd85f7fc4e0e6f2c1f7e2f0d0b3f9b5a50220"},
+			}}
+		}
+		tx.Vout = []Vout{
+			{Value: 0.6, N: 0, ScriptPubKey: ScriptPubKeyResult{Hex: fmt.Sprintf("76a914%040x88ac", i+1), Type: "pubkeyhash"}},
+			{Value: 0.4, N: 1, ScriptPubKey: ScriptPubKeyResult{Hex: fmt.Sprintf("76a914%040x88ac", height), Type: "pubkeyhash"}},
+		}
+	}
+	b := map[string]interface{}{
+		"hash":              benchBlockHash(height),
+		"height":            height,
+		"size":              benchTxsPerBlock * 250,
+		"time":              benchFirstBlockTime + int64(height)*300,
+		"previousblockhash": benchBlockHash(height - 1),
+		"confirmations":     benchBlocks - height + 1,
+		"rawtx":             txs,
+	}
+	if height < benchBlocks {
+		b["nextblockhash"] = benchBlockHash(height + 1)
+	}
+	return b
+}
+
+// benchBackend emulates dcrd serving the benchmark blocks
+func benchBackend(b *testing.B) *httptest.Server {
+	blocks := make(map[string]map[string]interface{})
+	txs := make(map[string]*RawTx)
+	for h := uint32(1); h <= benchBlocks; h++ {
+		block := benchBlock(h)
+		blocks[benchBlockHash(h)] = block
+		rawTxs := block["rawtx"].([]RawTx)
+		for i := range rawTxs {
+			txs[rawTxs[i].Txid] = &rawTxs[i]
+		}
+	}
+	genesis := map[string]interface{}{"hash": benchBlockHash(0), "height": 0, "time": benchFirstBlockTime - 135}
+	return testRPCBackend(b, func(method string, params []json.RawMessage) interface{} {
+		switch method {
+		case "getblockhash":
+			var height uint32
+			if err := json.Unmarshal(params[0], &height); err != nil {
+				b.Fatal(err)
+			}
+			return benchBlockHash(height)
+		case "getblock", "getblockheader":
+			var hash string
+			if err := json.Unmarshal(params[0], &hash); err != nil {
+				b.Fatal(err)
+			}
+			if hash == benchBlockHash(0) {
+				return genesis
+			}
+			return blocks[hash]
+		case "getrawtransaction":
+			var txid string
+			if err := json.Unmarshal(params[0], &txid); err != nil {
+				b.Fatal(err)
+			}
+			return txs[txid]
+		}
+		b.Errorf("Unexpected rpc method %v", method)
+		return nil
+	})
+}
+
+func benchRocksDB(b *testing.B) (*db.RocksDB, string) {
+	tmp, err := ioutil.TempDir("", "benchdb")
+	if err != nil {
+		b.Fatal(err)
+	}
+	d, err := db.NewRocksDB(tmp, 1<<24, -1, testParser, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	is, err := d.LoadInternalState("coin-unittest")
+	if err != nil {
+		b.Fatal(err)
+	}
+	d.SetInternalState(is)
+	return d, tmp
+}
+
+func benchDestroyRocksDB(b *testing.B, d *db.RocksDB, path string) {
+	if err := d.Close(); err != nil {
+		b.Fatal(err)
+	}
+	os.RemoveAll(path)
+}
+
+// benchIndexBlocks fetches, parses and indexes the blocks until n operations are done,
+// the operation is a block or a transaction; the index is recreated after the last block
+func benchIndexBlocks(b *testing.B, chain *DecredRPC, n int, txs bool) {
+	d, path := benchRocksDB(b)
+	height := uint32(1)
+	b.ResetTimer()
+	for done := 0; done < n; {
+		block, err := chain.GetBlock("", height)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err = d.ConnectBlock(block); err != nil {
+			b.Fatal(err)
+		}
+		if txs {
+			done += len(block.Txs)
+		} else {
+			done++
+		}
+		if height++; height > benchBlocks && done < n {
+			b.StopTimer()
+			benchDestroyRocksDB(b, d, path)
+			d, path = benchRocksDB(b)
+			height = 1
+			b.StartTimer()
+		}
+	}
+	b.StopTimer()
+	benchDestroyRocksDB(b, d, path)
+}
+
+// BenchmarkDecredBlockIndexing measures the indexing of the blocks from the rpc fetch through the parser to the database write.
+// The ns/op of the blocks and txs sub-benchmarks is the time per indexed block and per indexed transaction,
+// the inverse values are the blocks per second and the transactions per second.
+func BenchmarkDecredBlockIndexing(b *testing.B) {
+	s := benchBackend(b)
+	defer s.Close()
+	chain := newTestDecredRPC(s.URL)
+	b.Run("blocks", func(b *testing.B) {
+		benchIndexBlocks(b, chain, b.N, false)
+	})
+	b.Run("txs", func(b *testing.B) {
+		benchIndexBlocks(b, chain, b.N, true)
+	})
+}
+
+// BenchmarkDecredResolveInputs measures the resolution of the outputs spent by the inputs of a block, the ns/op
+// is the time per block. The blocks are resolved repeatedly as by the requests of the explorer pages, with the cache
+// only the first resolution of an output calls getrawtransaction.
+func BenchmarkDecredResolveInputs(b *testing.B) {
+	s := benchBackend(b)
+	defer s.Close()
+	for _, bm := range []struct {
+		name      string
+		cacheSize int
+	}{
+		{"nocache", -1},
+		{"cache", 0},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			chain := newTestDecredRPC(s.URL)
+			chain.prevOuts = newPrevOutCache(bm.cacheSize)
+			blocks := make([]*bchain.Block, 0, benchBlocks-1)
+			for h := uint32(2); h <= benchBlocks; h++ {
+				block, err := chain.GetBlock("", h)
+				if err != nil {
+					b.Fatal(err)
+				}
+				blocks = append(blocks, block)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				block := blocks[i%len(blocks)]
+				for j := range block.Txs {
+					if _, err := chain.ResolveInputs(&block.Txs[j]); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+			if stats := chain.prevOuts.getStats(); stats.Hits+stats.Misses > 0 {
+				b.Logf("cache hit rate %.1f%% (%d hits, %d misses)", float64(stats.Hits)*100/float64(stats.Hits+stats.Misses), stats.Hits, stats.Misses)
+			}
+		})
+	}
+}
+
+// benchBestBlockCalls is the number of the sequential calls of one operation of BenchmarkDecredBestBlockHash
+const benchBestBlockCalls = 1000
+
+// BenchmarkDecredBestBlockHash compares the best block hash lookup by getbestblockhash with getbestblock,
+// which returns the height as well, the ns/op is the time of 1000 sequential calls
+func BenchmarkDecredBestBlockHash(b *testing.B) {
+	s := testRPCBackend(b, func(method string, params []json.RawMessage) interface{} {
+		switch method {
+		case "getbestblock":
+			return map[string]interface{}{"hash": benchBlockHash(benchBlocks), "height": benchBlocks}
+		case "getbestblockhash":
+			return benchBlockHash(benchBlocks)
+		}
+		b.Errorf("Unexpected rpc method %v", method)
+		return nil
+	})
+	defer s.Close()
+	chain := newTestDecredRPC(s.URL)
+	b.Run("getbestblock", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < benchBestBlockCalls; j++ {
+				if _, err := chain.getBestBlock(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("getbestblockhash", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < benchBestBlockCalls; j++ {
+				if _, err := chain.GetBestBlockHash(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+const (
+	// benchConcurrentRequests is the number of the concurrent API requests of one operation of BenchmarkDecredRPCConnections
+	benchConcurrentRequests = 100
+	// benchRPCLatency is the time dcrd spends on one request
+	benchRPCLatency = time.Millisecond
+)
+
+// BenchmarkDecredRPCConnections measures the throughput of the rpc calls with 1, 4 and 8 clients in the pool,
+// the ns/op is the time to serve 100 concurrent requests
+func BenchmarkDecredRPCConnections(b *testing.B) {
+	s := testRPCBackend(b, func(method string, params []json.RawMessage) interface{} {
+		time.Sleep(benchRPCLatency)
+		return benchBlocks
+	})
+	defer s.Close()
+	for _, connections := range []int{1, 4, 8} {
+		b.Run(fmt.Sprint(connections), func(b *testing.B) {
+			chain := newTestDecredRPC(s.URL)
+			chain.clients = newHTTPClients(connections, nil)
+			for i := 0; i < b.N; i++ {
+				var wg sync.WaitGroup
+				wg.Add(benchConcurrentRequests)
+				for j := 0; j < benchConcurrentRequests; j++ {
+					go func() {
+						defer wg.Done()
+						if _, err := chain.GetBlockCount(); err != nil {
+							b.Error(err)
+						}
+					}()
+				}
+				wg.Wait()
+			}
+		})
+	}
+}
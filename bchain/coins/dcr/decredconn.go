@@ -0,0 +1,146 @@
+package dcr
+
+import (
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// connStats are the statistics of the connections and the calls of the rpc client,
+// the counters are updated atomically by each call, the nil connStats are not updated
+type connStats struct {
+	// open is the number of the open connections of the http clients
+	open int64
+	// inFlight is the number of the running http requests, each request uses one connection
+	inFlight int64
+	// lastSuccess is the unix time in nanoseconds of the last successful call
+	lastSuccess int64
+	// the numbers of the failed calls by the type of the error
+	networkErrors  int64
+	httpErrors     int64
+	responseErrors int64
+	rpcErrors      int64
+	otherErrors    int64
+}
+
+// countedConn decrements the number of the open connections when it is closed
+type countedConn struct {
+	net.Conn
+	stats *connStats
+	once  sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(func() {
+		atomic.AddInt64(&c.stats.open, -1)
+	})
+	return c.Conn.Close()
+}
+
+// dial returns the dial function of the http transport counting the open connections
+func (s *connStats) dial(dialer *net.Dialer) func(network, addr string) (net.Conn, error) {
+	if s == nil {
+		return dialer.Dial
+	}
+	return func(network, addr string) (net.Conn, error) {
+		c, err := dialer.Dial(network, addr)
+		if err != nil {
+			return nil, err
+		}
+		atomic.AddInt64(&s.open, 1)
+		return &countedConn{Conn: c, stats: s}, nil
+	}
+}
+
+func (s *connStats) requestStarted() {
+	if s != nil {
+		atomic.AddInt64(&s.inFlight, 1)
+	}
+}
+
+func (s *connStats) requestFinished() {
+	if s != nil {
+		atomic.AddInt64(&s.inFlight, -1)
+	}
+}
+
+// callFinished records the result of the call, err is the error of the call or the error returned by dcrd
+func (s *connStats) callFinished(err error) {
+	if s == nil {
+		return
+	}
+	if err == nil {
+		atomic.StoreInt64(&s.lastSuccess, time.Now().UnixNano())
+		return
+	}
+	switch code := rpcErrorCode(err); {
+	case code == ErrCodeNetwork:
+		atomic.AddInt64(&s.networkErrors, 1)
+	case code == ErrCodeHTTP:
+		atomic.AddInt64(&s.httpErrors, 1)
+	case code == ErrCodeInvalidResponse:
+		atomic.AddInt64(&s.responseErrors, 1)
+	case code < 0:
+		atomic.AddInt64(&s.rpcErrors, 1)
+	default:
+		atomic.AddInt64(&s.otherErrors, 1)
+	}
+}
+
+// DecredConnectionStatus describes the connection of blockbook to the backend. The connections
+// are the connections of the http clients, the active connections are used by the running requests.
+type DecredConnectionStatus struct {
+	RPCURL            string           `json:"rpcUrl"`
+	Transport         string           `json:"transport"`
+	ActiveConnections int64            `json:"activeConnections"`
+	IdleConnections   int64            `json:"idleConnections"`
+	TotalConnections  int64            `json:"totalConnections"`
+	LastSuccess       *time.Time       `json:"lastSuccess,omitempty"`
+	Errors            map[string]int64 `json:"errors"`
+}
+
+// GetConnectionStatus returns the status of the connection to the backend, the password is redacted from the rpc url.
+func (d *DecredRPC) GetConnectionStatus() (*DecredConnectionStatus, error) {
+	r := &DecredConnectionStatus{
+		RPCURL:    redactURL(d.rpcURL),
+		Transport: "http",
+		Errors:    make(map[string]int64),
+	}
+	s := d.conns
+	if s == nil {
+		return r, nil
+	}
+	r.TotalConnections = atomic.LoadInt64(&s.open)
+	r.ActiveConnections = atomic.LoadInt64(&s.inFlight)
+	// the connection of a request may be already closed or not yet counted
+	if r.ActiveConnections > r.TotalConnections {
+		r.ActiveConnections = r.TotalConnections
+	}
+	r.IdleConnections = r.TotalConnections - r.ActiveConnections
+	if t := atomic.LoadInt64(&s.lastSuccess); t != 0 {
+		lt := time.Unix(0, t).UTC()
+		r.LastSuccess = &lt
+	}
+	r.Errors["network"] = atomic.LoadInt64(&s.networkErrors)
+	r.Errors["http"] = atomic.LoadInt64(&s.httpErrors)
+	r.Errors["response"] = atomic.LoadInt64(&s.responseErrors)
+	r.Errors["rpc"] = atomic.LoadInt64(&s.rpcErrors)
+	r.Errors["other"] = atomic.LoadInt64(&s.otherErrors)
+	return r, nil
+}
+
+// redactURL replaces the password in the url, the urls which cannot be parsed are not returned
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if u.User != nil {
+		if _, set := u.User.Password(); set {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+		}
+	}
+	return u.String()
+}
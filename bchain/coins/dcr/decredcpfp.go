@@ -0,0 +1,82 @@
+package dcr
+
+import (
+	"blockbook/bchain"
+	"math/big"
+	"sort"
+)
+
+// DetectCPFP checks if the mempool transaction is bumped by its unconfirmed descendants (child pays for parent).
+// The transaction is mined with the ancestor set of a descendant if the ancestor set pays a higher fee rate
+// than the transaction with its own ancestors.
+func (d *DecredRPC) DetectCPFP(txid string) (*bchain.CPFPInfo, error) {
+	mempool, err := d.GetRawMempoolVerbose()
+	if err != nil {
+		return nil, err
+	}
+	if _, found := mempool[txid]; !found {
+		return nil, bchain.ErrTxNotFound
+	}
+	children := make(map[string][]string)
+	for child, tx := range mempool {
+		for _, parent := range tx.Depends {
+			children[parent] = append(children[parent], child)
+		}
+	}
+	r := &bchain.CPFPInfo{Txid: txid}
+	r.AncestorFeeRate = ancestorSetFeeRate(mempool, txid)
+	r.PackageFeeRate = r.AncestorFeeRate
+	visited := map[string]struct{}{txid: {}}
+	queue := []string{txid}
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		for _, child := range children[t] {
+			if _, found := visited[child]; found {
+				continue
+			}
+			visited[child] = struct{}{}
+			queue = append(queue, child)
+			r.Descendants = append(r.Descendants, child)
+		}
+	}
+	sort.Strings(r.Descendants)
+	for _, child := range r.Descendants {
+		if rate := ancestorSetFeeRate(mempool, child); rate > r.PackageFeeRate {
+			r.PackageFeeRate = rate
+			r.BumpedBy = child
+		}
+	}
+	r.Bumped = r.BumpedBy != ""
+	return r, nil
+}
+
+// ancestorSetFeeRate returns the fee rate in atoms per kilobyte of the transaction together
+// with all its ancestors in the mempool
+func ancestorSetFeeRate(mempool map[string]*DecredMempoolTxInfo, txid string) int64 {
+	var fees big.Int
+	var size int64
+	visited := map[string]struct{}{txid: {}}
+	stack := []string{txid}
+	for len(stack) > 0 {
+		t := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		tx, found := mempool[t]
+		if !found {
+			continue
+		}
+		fees.Add(&fees, &tx.FeeSat)
+		size += int64(tx.Size)
+		for _, parent := range tx.Depends {
+			if _, found := visited[parent]; !found {
+				visited[parent] = struct{}{}
+				stack = append(stack, parent)
+			}
+		}
+	}
+	if size == 0 {
+		return 0
+	}
+	fees.Mul(&fees, big.NewInt(1000))
+	return fees.Div(&fees, big.NewInt(size)).Int64()
+}
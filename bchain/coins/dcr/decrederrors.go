@@ -0,0 +1,72 @@
+package dcr
+
+import (
+	"blockbook/bchain"
+	"fmt"
+
+	"github.com/juju/errors"
+)
+
+// codes of the rpc failures detected by blockbook, the codes returned by dcrd are negative
+const (
+	// ErrCodeNetwork is the code of the failures to send the request to dcrd or to receive the response
+	ErrCodeNetwork = 1
+	// ErrCodeHTTP is the code of the http errors returned by dcrd without a json response
+	ErrCodeHTTP = 2
+	// ErrCodeInvalidResponse is the code of the responses which cannot be decoded or do not match the request
+	ErrCodeInvalidResponse = 3
+)
+
+// DecredRPCError is the error of a dcrd rpc call, Code is either the error code returned by dcrd
+// or one of the ErrCode constants of the failures detected by blockbook
+type DecredRPCError struct {
+	Code    int
+	Method  string
+	Message string
+}
+
+func (e *DecredRPCError) Error() string {
+	if e.Method == "" {
+		return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+	}
+	return fmt.Sprintf("%s: %s (code %d)", e.Method, e.Message, e.Code)
+}
+
+// newRPCError returns the error of the rpc method returned by dcrd in the response
+func newRPCError(method string, e Error) *DecredRPCError {
+	return &DecredRPCError{Code: e.Code, Method: method, Message: e.Message}
+}
+
+// newNetworkError returns the error of the rpc method which did not reach dcrd or whose response was lost
+func newNetworkError(method string, err error) *DecredRPCError {
+	return &DecredRPCError{Code: ErrCodeNetwork, Method: method, Message: err.Error()}
+}
+
+// newResponseError returns the error of the response of the rpc method which cannot be used
+func newResponseError(method string, format string, args ...interface{}) *DecredRPCError {
+	return &DecredRPCError{Code: ErrCodeInvalidResponse, Method: method, Message: fmt.Sprintf(format, args...)}
+}
+
+// rpcErrorCode returns the code of the DecredRPCError, which may be annotated, 0 for other errors
+func rpcErrorCode(err error) int {
+	if e, ok := errors.Cause(err).(*DecredRPCError); ok {
+		return e.Code
+	}
+	return 0
+}
+
+// IsNotFound returns true if the error reports an unknown transaction, block or address
+func IsNotFound(err error) bool {
+	switch errors.Cause(err) {
+	case bchain.ErrTxNotFound, bchain.ErrBlockNotFound:
+		return true
+	}
+	return rpcErrorCode(err) == rpcErrTxNotFound
+}
+
+// IsNetworkError returns true if dcrd could not be reached or did not return a response,
+// the call may succeed when repeated
+func IsNetworkError(err error) bool {
+	code := rpcErrorCode(err)
+	return code == ErrCodeNetwork || code == ErrCodeHTTP
+}
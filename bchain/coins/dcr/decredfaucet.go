@@ -0,0 +1,125 @@
+package dcr
+
+import (
+	"blockbook/bchain"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/juju/errors"
+
+	dch "github.com/decred/dcrd/chaincfg"
+)
+
+// ErrFaucetDisabled is returned by FundTestAddress if the faucet is not enabled in the configuration
+var ErrFaucetDisabled = errors.New("Faucet is disabled, set enable_testnet_faucet to enable it")
+
+// faucetResponseSize limits the size of the response of the external faucet
+const faucetResponseSize = 64 << 10
+
+// DecredFaucetResult describes the funding of the address, Txid is the transaction of the external faucet
+// on testnet, Blocks are the hashes of the blocks mined to the address on simnet
+type DecredFaucetResult struct {
+	Address string   `json:"address"`
+	Network string   `json:"network"`
+	Txid    string   `json:"txid,omitempty"`
+	Blocks  []string `json:"blocks,omitempty"`
+}
+
+type GenerateToAddressResult struct {
+	Error  Error    `json:"error"`
+	Result []string `json:"result"`
+}
+
+// TestnetFaucetEnabled returns true if the faucet is enabled and the node does not run on mainnet
+func (d *DecredRPC) TestnetFaucetEnabled() bool {
+	return d.config.EnableTestnetFaucet && d.Parser.(*DecredParser).chainParams().Net != dch.MainNetParams.Net
+}
+
+// FundTestAddress funds the address with test coins. On simnet dcrd mines a block paying to the address,
+// the coinbase can be spent after the coinbase maturity. On testnet the address is sent to the external
+// faucet at testnet_faucet_url, the amount in DCR is passed to the faucet if not empty.
+// The faucet is never available on mainnet.
+func (d *DecredRPC) FundTestAddress(address string, amount string) (*DecredFaucetResult, error) {
+	if !d.config.EnableTestnetFaucet {
+		return nil, ErrFaucetDisabled
+	}
+	params := d.Parser.(*DecredParser).chainParams()
+	a, err := dcrutil.DecodeAddress(address)
+	if err != nil {
+		return nil, &InvalidAddressError{Address: address, Reason: err.Error()}
+	}
+	if !a.IsForNet(params) {
+		return nil, &InvalidAddressError{Address: address, Reason: "address is not for network " + params.Name}
+	}
+	r := &DecredFaucetResult{Address: address, Network: params.Name}
+	switch params.Net {
+	case dch.SimNetParams.Net:
+		generateRequest := GenericCmd{
+			ID:     1,
+			Method: "generatetoaddress",
+			Params: []interface{}{1, address},
+		}
+		generateResult := GenerateToAddressResult{}
+		if err = d.Call(generateRequest, &generateResult); err != nil {
+			return nil, err
+		}
+		if generateResult.Error.Message != "" {
+			return nil, errors.Annotate(newRPCError(generateRequest.Method, generateResult.Error), "Error generating blocks")
+		}
+		r.Blocks = generateResult.Result
+	case dch.TestNet3Params.Net:
+		if d.config.TestnetFaucetURL == "" {
+			return nil, errors.New("Faucet is not configured, set testnet_faucet_url")
+		}
+		if r.Txid, err = d.externalFaucet(address, amount); err != nil {
+			return nil, errors.Annotate(err, "Faucet")
+		}
+	default:
+		return nil, bchain.ErrFeatureUnsupported
+	}
+	return r, nil
+}
+
+// externalFaucet posts the address and the amount as form values to the faucet, the faucet
+// returns json with the txid of the payment or the error
+func (d *DecredRPC) externalFaucet(address string, amount string) (string, error) {
+	form := url.Values{"address": {address}}
+	if amount != "" {
+		form.Set("amount", amount)
+	}
+	req, err := http.NewRequest("POST", d.config.TestnetFaucetURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if d.config.RPCTimeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(d.config.RPCTimeout)*time.Second)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+	res, err := d.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	var fr struct {
+		Txid  string `json:"txid"`
+		Error string `json:"error"`
+	}
+	if err = json.NewDecoder(io.LimitReader(res.Body, faucetResponseSize)).Decode(&fr); err != nil {
+		return "", errors.Annotatef(err, "%v", res.Status)
+	}
+	if fr.Error != "" {
+		return "", errors.New(fr.Error)
+	}
+	if res.StatusCode != http.StatusOK || fr.Txid == "" {
+		return "", errors.Errorf("%v, no txid returned", res.Status)
+	}
+	return fr.Txid, nil
+}
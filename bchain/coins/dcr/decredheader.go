@@ -0,0 +1,86 @@
+package dcr
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/juju/errors"
+)
+
+// decredBlockHeaderSize is the size of the serialized Decred block header
+const decredBlockHeaderSize = 180
+
+// serializeBlockHeader reconstructs the serialized block header from the fields of the verbose getblock result,
+// the BLAKE-256 hash of the header must be the hash of the block
+func serializeBlockHeader(block *GetBlockResult) ([]byte, error) {
+	r := &block.Result
+	version, err := r.Version.Int64()
+	if err != nil {
+		return nil, errors.Annotate(err, "version")
+	}
+	nonce, err := r.Nonce.Int64()
+	if err != nil {
+		return nil, errors.Annotate(err, "nonce")
+	}
+	bits, err := strconv.ParseUint(r.Bits, 16, 32)
+	if err != nil {
+		return nil, errors.Annotate(err, "bits")
+	}
+	sbits, err := dcrutil.NewAmount(r.SBits)
+	if err != nil {
+		return nil, errors.Annotate(err, "sbits")
+	}
+	finalState, err := hex.DecodeString(r.FinalState)
+	if err != nil || len(finalState) != 6 {
+		return nil, errors.Errorf("Invalid final state %v", r.FinalState)
+	}
+	extraData, err := hex.DecodeString(r.ExtraData)
+	if err != nil || len(extraData) != 32 {
+		return nil, errors.Errorf("Invalid extra data %v", r.ExtraData)
+	}
+	b := make([]byte, decredBlockHeaderSize)
+	le := binary.LittleEndian
+	le.PutUint32(b[0:], uint32(version))
+	for i, s := range []string{r.PreviousHash, r.MerkleRoot, r.StakeRoot} {
+		h, err := chainhash.NewHashFromStr(s)
+		if err != nil {
+			return nil, errors.Annotatef(err, "hash %v", s)
+		}
+		copy(b[4+i*chainhash.HashSize:], h[:])
+	}
+	le.PutUint16(b[100:], r.VoteBits)
+	copy(b[102:], finalState)
+	le.PutUint16(b[108:], r.Voters)
+	b[110] = r.FreshStake
+	b[111] = r.Revocations
+	le.PutUint32(b[112:], r.PoolSize)
+	le.PutUint32(b[116:], uint32(bits))
+	le.PutUint64(b[120:], uint64(sbits))
+	le.PutUint32(b[128:], uint32(r.Height))
+	le.PutUint32(b[132:], uint32(r.Size))
+	le.PutUint32(b[136:], uint32(r.Time))
+	le.PutUint32(b[140:], uint32(nonce))
+	copy(b[144:], extraData)
+	le.PutUint32(b[176:], r.StakeVersion)
+	if h := chainhash.HashH(b); h.String() != r.Hash {
+		return nil, errors.Errorf("Reconstructed header hash %v does not match the block", h)
+	}
+	return b, nil
+}
+
+// ParentApproval returns the approval of the parent block by the vote bits of the serialized block header,
+// ok is false if the header is not the header of a child of the parent block
+func ParentApproval(header []byte, parentHash string) (approved bool, ok bool) {
+	if len(header) != decredBlockHeaderSize {
+		return false, false
+	}
+	var prev chainhash.Hash
+	copy(prev[:], header[4:4+chainhash.HashSize])
+	if prev.String() != parentHash {
+		return false, false
+	}
+	return IsBlockApproved(binary.LittleEndian.Uint16(header[100:])), true
+}
@@ -0,0 +1,162 @@
+package dcr
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	dcrwire "github.com/decred/dcrd/wire"
+	"github.com/juju/errors"
+)
+
+// Decred transaction trees
+const (
+	TreeRegular = "regular"
+	TreeStake   = "stake"
+)
+
+// DecredMerkleProof is the merkle path of the transaction in the regular or stake tree of the block.
+// The roots of both trees are returned because after the activation of the header commitments (DCP-0005)
+// the merkle root in the block header commits to both of them. Header is the hex serialized block header.
+type DecredMerkleProof struct {
+	Txid        string   `json:"txid"`
+	BlockHash   string   `json:"blockHash"`
+	BlockHeight int64    `json:"blockHeight"`
+	Tree        string   `json:"tree"`
+	Index       int      `json:"index"`
+	Path        []string `json:"path"`
+	RegularRoot string   `json:"regularRoot"`
+	StakeRoot   string   `json:"stakeRoot"`
+	Header      string   `json:"header"`
+}
+
+// GetTxMerkleProof returns the merkle inclusion proof of the transaction,
+// if the blockHash is empty the block of the transaction is found by getrawtransaction
+func (d *DecredRPC) GetTxMerkleProof(txid string, blockHash string) (*DecredMerkleProof, error) {
+	if blockHash == "" {
+		raw, err := d.getRawTransaction(txid)
+		if err != nil {
+			return nil, err
+		}
+		var tx RawTx
+		if err = json.Unmarshal(raw, &tx); err != nil {
+			return nil, errors.Annotatef(err, "txid %v", txid)
+		}
+		if tx.BlockHash == "" {
+			return nil, errors.Errorf("Transaction %v is not in a block", txid)
+		}
+		blockHash = tx.BlockHash
+	}
+	block, err := d.getBlockVerboseTx(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	regular, regularTxids, err := txHashesFull(block.Result.RawTx)
+	if err != nil {
+		return nil, errors.Annotatef(err, "block %v", blockHash)
+	}
+	stake, stakeTxids, err := txHashesFull(block.Result.RawSTx)
+	if err != nil {
+		return nil, errors.Annotatef(err, "block %v", blockHash)
+	}
+
+	proof := &DecredMerkleProof{
+		Txid:        txid,
+		BlockHash:   block.Result.Hash,
+		BlockHeight: block.Result.Height,
+		Index:       -1,
+	}
+	regularIndex, stakeIndex := indexOf(regularTxids, txid), indexOf(stakeTxids, txid)
+	regularPath, regularRoot := merklePath(regular, regularIndex)
+	stakePath, stakeRoot := merklePath(stake, stakeIndex)
+	if regularIndex >= 0 {
+		proof.Tree, proof.Index, proof.Path = TreeRegular, regularIndex, hashStrings(regularPath)
+	} else if stakeIndex >= 0 {
+		proof.Tree, proof.Index, proof.Path = TreeStake, stakeIndex, hashStrings(stakePath)
+	} else {
+		return nil, errors.Errorf("Transaction %v not found in block %v", txid, blockHash)
+	}
+	proof.RegularRoot = regularRoot.String()
+	proof.StakeRoot = stakeRoot.String()
+	header, err := serializeBlockHeader(block)
+	if err != nil {
+		return nil, errors.Annotatef(err, "block %v", block.Result.Hash)
+	}
+	proof.Header = hex.EncodeToString(header)
+	return proof, nil
+}
+
+// txHashesFull returns the leaves of the merkle tree of the transactions and their txids. The leaves are
+// the hashes of the full serialization of the transactions (prefix and witness), not the txids.
+func txHashesFull(txs []RawTx) ([]chainhash.Hash, []string, error) {
+	r := make([]chainhash.Hash, len(txs))
+	txids := make([]string, len(txs))
+	for i := range txs {
+		b, err := hex.DecodeString(txs[i].Hex)
+		if err != nil {
+			return nil, nil, errors.Annotatef(err, "txid %v", txs[i].Txid)
+		}
+		var tx dcrwire.MsgTx
+		if err = tx.Deserialize(bytes.NewReader(b)); err != nil {
+			return nil, nil, errors.Annotatef(err, "txid %v", txs[i].Txid)
+		}
+		if h := tx.TxHash(); h.String() != txs[i].Txid {
+			return nil, nil, errors.Errorf("Transaction hash %v does not match txid %v", h, txs[i].Txid)
+		}
+		r[i] = tx.TxHashFull()
+		txids[i] = txs[i].Txid
+	}
+	return r, txids, nil
+}
+
+func indexOf(txids []string, txid string) int {
+	for i := range txids {
+		if txids[i] == txid {
+			return i
+		}
+	}
+	return -1
+}
+
+func hashStrings(hashes []chainhash.Hash) []string {
+	r := make([]string, len(hashes))
+	for i := range hashes {
+		r[i] = hashes[i].String()
+	}
+	return r
+}
+
+// merklePath returns the sibling hashes on the path from the leaf at the index to the root and the root,
+// the tree is built in the same way as in Bitcoin, only the BLAKE-256 hash is used.
+// The path is empty if the index is negative, the root of an empty tree is the zero hash.
+func merklePath(leaves []chainhash.Hash, index int) ([]chainhash.Hash, chainhash.Hash) {
+	if len(leaves) == 0 {
+		return nil, chainhash.Hash{}
+	}
+	var path []chainhash.Hash
+	level := append([]chainhash.Hash{}, leaves...)
+	for len(level) > 1 {
+		// the last hash of a level with an odd number of hashes is paired with itself
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		if index >= 0 {
+			path = append(path, level[index^1])
+			index /= 2
+		}
+		next := make([]chainhash.Hash, len(level)/2)
+		for i := range next {
+			next[i] = hashMerkleBranches(&level[2*i], &level[2*i+1])
+		}
+		level = next
+	}
+	return path, level[0]
+}
+
+func hashMerkleBranches(left, right *chainhash.Hash) chainhash.Hash {
+	var b [chainhash.HashSize * 2]byte
+	copy(b[:chainhash.HashSize], left[:])
+	copy(b[chainhash.HashSize:], right[:])
+	return chainhash.HashH(b[:])
+}
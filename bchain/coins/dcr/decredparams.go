@@ -0,0 +1,91 @@
+package dcr
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/martinboehm/btcd/wire"
+	"github.com/martinboehm/btcutil/chaincfg"
+
+	dch "github.com/decred/dcrd/chaincfg"
+)
+
+// decredNetParams are the dcrd consensus parameters of the networks known to blockbook
+var decredNetParams = []*dch.Params{&dch.MainNetParams, &dch.TestNet3Params, &dch.SimNetParams}
+
+// defaultExplorerTxURLs are the templates of the transaction links to the dcrdata explorers of the networks
+var defaultExplorerTxURLs = map[string]string{
+	dch.MainNetParams.Name:  "https://explorer.dcrdata.org/tx/{txid}",
+	dch.TestNet3Params.Name: "https://testnet.dcrdata.org/tx/{txid}",
+}
+
+// parserParams returns the parser parameters of the network, the network magic
+// and the address prefixes are taken from the dcrd parameters of the network
+func parserParams(np *dch.Params) chaincfg.Params {
+	params := chaincfg.MainNetParams
+	params.Name = np.Name
+	params.Net = wire.BitcoinNet(np.Net)
+	params.PubKeyHashAddrID = np.PubKeyHashAddrID[:]
+	params.ScriptHashAddrID = np.ScriptHashAddrID[:]
+	params.HDPublicKeyID = np.HDPublicKeyID
+	params.HDPrivateKeyID = np.HDPrivateKeyID
+	params.HDCoinType = np.HDCoinType
+	params.Bech32HRPSegwit = ""
+	return params
+}
+
+// netParamsByName returns the dcrd parameters of the network reported by getblockchaininfo
+func netParamsByName(name string) *dch.Params {
+	for _, p := range decredNetParams {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// DownloadChainParams returns the parser parameters of the network the dcrd node runs on.
+// The network is taken from getblockchaininfo and the genesis block of the node is checked
+// against the genesis block of the network, so that blockbook does not index a different chain
+// with the parameters of the reported network.
+func DownloadChainParams(rpc *DecredRPC) (*chaincfg.Params, error) {
+	info, err := rpc.GetDecredBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	return rpc.nodeChainParams(info.Result.Chain)
+}
+
+// nodeChainParams returns the parser parameters of the network of the given name after the genesis
+// block of the node is verified
+func (d *DecredRPC) nodeChainParams(chain string) (*chaincfg.Params, error) {
+	np := netParamsByName(chain)
+	if np == nil {
+		return nil, errors.Errorf("Unknown Decred network %v", chain)
+	}
+	genesis, err := d.GetBlockHash(0)
+	if err != nil {
+		return nil, err
+	}
+	if genesis != np.GenesisHash.String() {
+		return nil, errors.Errorf("Genesis block %v of the node does not match the genesis block %v of network %v", genesis, np.GenesisHash, np.Name)
+	}
+	params := GetChainParams(np.Name)
+	if uint32(params.Net) != uint32(np.Net) {
+		return nil, errors.Errorf("Parser parameters of network %v have magic %x, dcrd uses %x", np.Name, uint32(params.Net), uint32(np.Net))
+	}
+	return params, nil
+}
+
+// ExplorerTxLink returns the link to the transaction in the external explorer configured by explorer_tx_url,
+// or in the dcrdata explorer of the network, empty if there is no explorer of the network
+func (d *DecredRPC) ExplorerTxLink(txid string) string {
+	template := d.config.ExplorerTxURL
+	if template == "" {
+		template = defaultExplorerTxURLs[d.Parser.(*DecredParser).chainParams().Name]
+	}
+	if template == "" {
+		return ""
+	}
+	return strings.Replace(template, "{txid}", txid, -1)
+}
@@ -2,15 +2,22 @@ package dcr
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"math/big"
+	"strings"
 
 	"blockbook/bchain"
 	"blockbook/bchain/coins/btc"
 	"blockbook/bchain/coins/utils"
 
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrec/secp256k1"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/hdkeychain"
 	"github.com/decred/dcrd/txscript"
+	"github.com/juju/errors"
 	"github.com/martinboehm/btcd/wire"
 	"github.com/martinboehm/btcutil/chaincfg"
 
@@ -19,13 +26,136 @@ import (
 
 const (
 	MainnetMagic wire.BitcoinNet = 0xd9b400f9
+	TestnetMagic wire.BitcoinNet = 0xb194aa75
+	SimnetMagic  wire.BitcoinNet = 0x12141c16
 )
 
+// Decred transaction types returned by GetTxType
+const (
+	TxTypeRegular        = "regular"
+	TxTypeTicket         = "ticket"
+	TxTypeVote           = "vote"
+	TxTypeRevocation     = "revocation"
+	TxTypeAutoRevocation = "autorevocation"
+	TxTypeTreasurySpend  = "tspend"
+	TxTypeTreasurybase   = "treasurybase"
+)
+
+// Decred utxo types returned by GetUtxoType
+const (
+	UtxoTypeRegular      = "regular"
+	UtxoTypeCoinbase     = "coinbase"
+	UtxoTypeTicket       = "ticket"
+	UtxoTypeTicketChange = "ticketchange"
+	UtxoTypeStakebase    = "stakebase"
+	UtxoTypeRevocation   = "revocation"
+	UtxoTypeTreasury     = "treasury"
+)
+
+// TreasuryAddress is the synthetic address of the sender of treasury spend transactions
+const TreasuryAddress = "Treasury"
+
+// Hash256AddressPrefix is the prefix of the synthetic addresses of OP_HASH256 <hash> OP_EQUAL outputs,
+// followed by the hex encoded hash
+const Hash256AddressPrefix = "hash256:"
+
+// hash256ScriptLen is the length of the OP_HASH256 OP_DATA_32 <hash> OP_EQUAL script
+const hash256ScriptLen = 35
+
+// treasury opcodes (DCP-0006) unknown to the txscript version in use
+const (
+	opTAdd   = 0xc1
+	opTSpend = 0xc2
+	opTGen   = 0xc3
+)
+
+// voteBitsApproveParent is the vote bit of the block header set if the votes approved the previous block
+const voteBitsApproveParent = 0x0001
+
+// IsBlockApproved returns true if the vote bits of the block header approve the previous block,
+// the previous block is approved if the majority of the votes included in the block approve it
+func IsBlockApproved(voteBits uint16) bool {
+	return voteBits&voteBitsApproveParent != 0
+}
+
+// DecredVoteChoice is the choice of the vote for one agenda
+type DecredVoteChoice struct {
+	AgendaID string `json:"agendaId"`
+	ChoiceID string `json:"choiceId"`
+}
+
+// DecredVoteBitDecoding is the human readable form of the vote bits of a vote transaction
+type DecredVoteBitDecoding struct {
+	VoteBits       uint16             `json:"voteBits"`
+	ApprovesParent bool               `json:"approvesParent"`
+	Choices        []DecredVoteChoice `json:"choices,omitempty"`
+}
+
+// DecodeVoteBits decodes the approval of the previous block and the choices of the agendas
+// from the vote bits, the bits not covered by the masks of the agendas are ignored
+func (p *DecredParser) DecodeVoteBits(voteBits uint16, agendas []DecredAgenda) (*DecredVoteBitDecoding, error) {
+	r := &DecredVoteBitDecoding{
+		VoteBits:       voteBits,
+		ApprovesParent: voteBits&voteBitsApproveParent != 0,
+	}
+	for i := range agendas {
+		a := &agendas[i]
+		if a.Mask == 0 {
+			continue
+		}
+		bits := voteBits & a.Mask
+		found := false
+		for j := range a.Choices {
+			if a.Choices[j].Bits == bits {
+				r.Choices = append(r.Choices, DecredVoteChoice{AgendaID: a.ID, ChoiceID: a.Choices[j].ID})
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("Invalid vote bits %#04x of agenda %v", voteBits, a.ID)
+		}
+	}
+	return r, nil
+}
+
+// GetVoteBits returns the vote bits and the vote version from the OP_RETURN second output of the vote,
+// false if the transaction is not a vote
+func (p *DecredParser) GetVoteBits(tx *bchain.Tx) (uint16, uint32, bool) {
+	if p.GetTxType(tx) != TxTypeVote || len(tx.Vout) < 2 {
+		return 0, 0, false
+	}
+	script, err := hex.DecodeString(tx.Vout[1].ScriptPubKey.Hex)
+	if err != nil {
+		return 0, 0, false
+	}
+	data, err := p.ParseOpReturnData(script)
+	if err != nil || len(data) < 2 {
+		return 0, 0, false
+	}
+	var version uint32
+	if len(data) >= 6 {
+		version = binary.LittleEndian.Uint32(data[2:6])
+	}
+	return binary.LittleEndian.Uint16(data[:2]), version, true
+}
+
+// txVersionAutoRevocations is the transaction version of revocations created by the consensus rules (DCP-0009)
+const txVersionAutoRevocations = 2
+
 var (
+	// ErrUnknownScriptVersion is returned for outputs with a script version not defined by the consensus rules,
+	// such outputs are not indexed
+	ErrUnknownScriptVersion = errors.New("Unknown script version")
+	// ErrUnknownPubKeyFormat is returned for public keys which are neither compressed nor uncompressed secp256k1 keys
+	ErrUnknownPubKeyFormat = errors.New("Unknown public key format")
+
 	// MainNetParams are parser parameters for mainnet
 	MainNetParams chaincfg.Params
 	// TestNetParams are parser parameters for testnet
 	TestNetParams chaincfg.Params
+	// SimNetParams are parser parameters for simnet
+	SimNetParams chaincfg.Params
 )
 
 func init() {
@@ -33,6 +163,9 @@ func init() {
 	MainNetParams.Net = MainnetMagic
 	MainNetParams.PubKeyHashAddrID = []byte{0x13, 0x86}
 	MainNetParams.ScriptHashAddrID = []byte{0x07, 0x1a}
+
+	TestNetParams = parserParams(&dch.TestNet3Params)
+	SimNetParams = parserParams(&dch.SimNetParams)
 }
 
 // DecredParser handle
@@ -46,20 +179,43 @@ func NewDecredParser(params *chaincfg.Params, c *btc.Configuration) *DecredParse
 }
 
 // GetChainParams contains network parameters for the main Decred network,
-// and the test Decred network
+// the test Decred network and the simulation Decred network.
+// The simnet magic is the same as the magic of the bitcoin simnet, the parameters
+// already registered for the magic are kept.
 func GetChainParams(chain string) *chaincfg.Params {
-	if !chaincfg.IsRegistered(&MainNetParams) {
-		err := chaincfg.Register(&MainNetParams)
-		if err != nil {
-			panic(err)
+	for _, params := range []*chaincfg.Params{&MainNetParams, &TestNetParams, &SimNetParams} {
+		if !chaincfg.IsRegistered(params) {
+			err := chaincfg.Register(params)
+			if err != nil {
+				panic(err)
+			}
 		}
 	}
 	switch chain {
+	case dch.TestNet3Params.Name:
+		return &TestNetParams
+	case dch.SimNetParams.Name:
+		return &SimNetParams
 	default:
 		return &MainNetParams
 	}
 }
 
+// chainParams returns the dcrd consensus parameters of the network, testnet for an unknown network
+func (p *DecredParser) chainParams() *dch.Params {
+	for _, np := range decredNetParams {
+		if wire.BitcoinNet(np.Net) == p.Params.Net {
+			return np
+		}
+	}
+	return &dch.TestNet3Params
+}
+
+// CoinbaseMaturity returns the number of confirmations required to spend coinbase and stakebase outputs
+func (p *DecredParser) CoinbaseMaturity() int {
+	return int(p.chainParams().CoinbaseMaturity)
+}
+
 // ParseBlock parses raw block to our Block struct
 // it has special handling for Auxpow blocks that cannot be parsed by standard btc wire parser
 func (p *DecredParser) ParseBlock(b []byte) (*bchain.Block, error) {
@@ -95,7 +251,18 @@ func (p *DecredParser) ParseBlock(b []byte) (*bchain.Block, error) {
 	}, nil
 }
 
+// ParseTxFromJson parses dcrd verbose transaction json to bchain.Tx
 func (p *DecredParser) ParseTxFromJson(jsonTx json.RawMessage) (*bchain.Tx, error) {
+	return p.parseTxFromJson(jsonTx, false)
+}
+
+// ParseTxFromJsonDecodeOnly parses dcrd verbose transaction json to bchain.Tx without the output addresses,
+// used by the block sync where the addresses are derived from the output scripts anyway
+func (p *DecredParser) ParseTxFromJsonDecodeOnly(jsonTx json.RawMessage) (*bchain.Tx, error) {
+	return p.parseTxFromJson(jsonTx, true)
+}
+
+func (p *DecredParser) parseTxFromJson(jsonTx json.RawMessage, skipAddresses bool) (*bchain.Tx, error) {
 	getTxResult := GetTransactionResult{}
 	err := json.Unmarshal([]byte(jsonTx), &getTxResult.Result)
 	if err != nil {
@@ -113,6 +280,17 @@ func (p *DecredParser) ParseTxFromJson(jsonTx json.RawMessage) (*bchain.Tx, erro
 			ScriptSig: bchain.ScriptSig{},
 			Sequence:  input.Sequence,
 			Addresses: []string{},
+			Tree:      input.Tree,
+		}
+		if input.ScriptSig != nil {
+			vin.ScriptSig.Hex = input.ScriptSig.Hex
+		}
+		if input.Stakebase != "" {
+			vin.ScriptSig.Hex = input.Stakebase
+		}
+		if input.TreasurySpend != "" {
+			vin.ScriptSig.Hex = input.TreasurySpend
+			vin.Addresses = []string{TreasuryAddress}
 		}
 		vins = append(vins, vin)
 	}
@@ -123,9 +301,12 @@ func (p *DecredParser) ParseTxFromJson(jsonTx json.RawMessage) (*bchain.Tx, erro
 			ValueSat: valueSat,
 			N:        output.N,
 			ScriptPubKey: bchain.ScriptPubKey{
-				Hex:       output.ScriptPubKey.Hex,
-				Addresses: output.ScriptPubKey.Addresses,
+				Hex: output.ScriptPubKey.Hex,
 			},
+			Version: output.Version,
+		}
+		if !skipAddresses {
+			vout.ScriptPubKey.Addresses = output.ScriptPubKey.Addresses
 		}
 		vouts = append(vouts, vout)
 	}
@@ -144,30 +325,354 @@ func (p *DecredParser) ParseTxFromJson(jsonTx json.RawMessage) (*bchain.Tx, erro
 	return tx, nil
 }
 
+// sizes of the fixed parts of the serialized transaction in the Decred wire format
+const (
+	// version and serialization type, locktime, expiry
+	txFixedSize = 4 + 4 + 4
+	// previous outpoint hash, index and tree, sequence
+	txInPrefixSize = 32 + 4 + 1 + 4
+	// amount in, block height, block index
+	txInWitnessSize = 8 + 4 + 4
+	// value, script version
+	txOutFixedSize = 8 + 2
+)
+
+// CalcTxSize returns the size of the fully serialized transaction (prefix and witness).
+// If the transaction hex is not available, the size is computed from the inputs and outputs.
+func (p *DecredParser) CalcTxSize(tx *bchain.Tx) int {
+	if tx.Hex != "" {
+		return len(tx.Hex) / 2
+	}
+	size := txFixedSize + varIntSize(len(tx.Vin)) + varIntSize(len(tx.Vout))
+	// the witness data repeats the number of inputs
+	size += varIntSize(len(tx.Vin))
+	for i := range tx.Vin {
+		script := tx.Vin[i].ScriptSig.Hex
+		if tx.Vin[i].Coinbase != "" {
+			script = tx.Vin[i].Coinbase
+		}
+		l := len(script) / 2
+		size += txInPrefixSize + txInWitnessSize + varIntSize(l) + l
+	}
+	for i := range tx.Vout {
+		l := len(tx.Vout[i].ScriptPubKey.Hex) / 2
+		size += txOutFixedSize + varIntSize(l) + l
+	}
+	return size
+}
+
+// varIntSize returns the size of the variable length integer of the wire format
+func varIntSize(n int) int {
+	switch {
+	case n < 0xfd:
+		return 1
+	case n <= 0xffff:
+		return 3
+	case uint64(n) <= 0xffffffff:
+		return 5
+	}
+	return 9
+}
+
+// GetTxType classifies the transaction by the stake opcode of its first output.
+// The votes start with the OP_RETURN block reference and vote bits outputs, they are classified by the third output.
+// Automatic revocations (DCP-0009) have the transaction version 2, no fee and input without signature script,
+// they spend the ticket in the same way as the wallet revocations and are reported as a separate type
+func (p *DecredParser) GetTxType(tx *bchain.Tx) string {
+	if len(tx.Vout) == 0 {
+		return TxTypeRegular
+	}
+	if IsTreasurySpend(tx) {
+		return TxTypeTreasurySpend
+	}
+	if isTreasurybase(tx) {
+		return TxTypeTreasurybase
+	}
+	script, err := hex.DecodeString(tx.Vout[0].ScriptPubKey.Hex)
+	if err != nil || len(script) == 0 {
+		return TxTypeRegular
+	}
+	if script[0] == txscript.OP_RETURN {
+		if len(tx.Vout) < 3 || !isNullData(tx.Vout[1].ScriptPubKey.Hex) {
+			return TxTypeRegular
+		}
+		script, err = hex.DecodeString(tx.Vout[2].ScriptPubKey.Hex)
+		if err != nil || len(script) == 0 || script[0] != txscript.OP_SSGEN {
+			return TxTypeRegular
+		}
+	}
+	switch script[0] {
+	case txscript.OP_SSTX:
+		return TxTypeTicket
+	case txscript.OP_SSGEN:
+		return TxTypeVote
+	case txscript.OP_SSRTX:
+		if isAutoRevocation(tx) {
+			return TxTypeAutoRevocation
+		}
+		return TxTypeRevocation
+	}
+	return TxTypeRegular
+}
+
+// isNullData returns true for the OP_RETURN outputs
+func isNullData(hexScript string) bool {
+	script, err := hex.DecodeString(hexScript)
+	return err == nil && len(script) > 0 && script[0] == txscript.OP_RETURN
+}
+
+func isAutoRevocation(tx *bchain.Tx) bool {
+	if tx.Version != txVersionAutoRevocations || len(tx.Vin) != 1 {
+		return false
+	}
+	return tx.Vin[0].ScriptSig.Hex == ""
+}
+
+// IsTreasurySpend returns true for the treasury spend transactions, they have a single input
+// with the signature script ending by OP_TSPEND, OP_RETURN first output and OP_TGEN tagged payments
+func IsTreasurySpend(tx *bchain.Tx) bool {
+	if len(tx.Vin) != 1 || tx.Vin[0].Txid != "" || len(tx.Vout) < 2 {
+		return false
+	}
+	sigScript, err := hex.DecodeString(tx.Vin[0].ScriptSig.Hex)
+	if err != nil || len(sigScript) == 0 || sigScript[len(sigScript)-1] != opTSpend {
+		return false
+	}
+	for i := range tx.Vout {
+		script, err := hex.DecodeString(tx.Vout[i].ScriptPubKey.Hex)
+		if err != nil || len(script) == 0 {
+			return false
+		}
+		if i == 0 && script[0] != txscript.OP_RETURN || i > 0 && script[0] != opTGen {
+			return false
+		}
+	}
+	return true
+}
+
+// GetTxTree returns the tree of the block containing the transaction and its outputs,
+// the inputs spending the outputs must refer to the same tree. The treasury adds are stake transactions
+// reported as regular by GetTxType.
+func (p *DecredParser) GetTxTree(tx *bchain.Tx) int8 {
+	if p.GetTxType(tx) != TxTypeRegular || len(tx.Vout) > 0 && isTreasuryCredit(tx.Vout[0].ScriptPubKey.Hex) {
+		return txTreeStake
+	}
+	return txTreeRegular
+}
+
+// GetUtxoType classifies the output of the transaction for the coin control of the wallets
+func (p *DecredParser) GetUtxoType(tx *bchain.Tx, vout int) string {
+	if vout < 0 || vout >= len(tx.Vout) {
+		return ""
+	}
+	script, err := hex.DecodeString(tx.Vout[vout].ScriptPubKey.Hex)
+	if err != nil || len(script) == 0 {
+		return ""
+	}
+	switch script[0] {
+	case txscript.OP_SSTX:
+		return UtxoTypeTicket
+	case txscript.OP_SSTXCHANGE:
+		return UtxoTypeTicketChange
+	case txscript.OP_SSGEN:
+		return UtxoTypeStakebase
+	case txscript.OP_SSRTX:
+		return UtxoTypeRevocation
+	case opTGen:
+		return UtxoTypeTreasury
+	}
+	if len(tx.Vin) > 0 && tx.Vin[0].Coinbase != "" {
+		return UtxoTypeCoinbase
+	}
+	return UtxoTypeRegular
+}
+
+// ticketCommitmentScriptLen is the length of the OP_RETURN OP_DATA_30 <hash160> <amount> ticket commitment script
+const ticketCommitmentScriptLen = 32
+
+// GetTicketCommitment returns the descriptor of the reward address committed by the first commitment output of the ticket,
+// false if the transaction is not a ticket purchase
+func (p *DecredParser) GetTicketCommitment(tx *bchain.Tx) (bchain.AddressDescriptor, bool) {
+	if p.GetTxType(tx) != TxTypeTicket || len(tx.Vout) < 2 {
+		return nil, false
+	}
+	return p.ticketCommitment(tx.Vout[1].ScriptPubKey.Hex)
+}
+
+// ticketCommitment returns the descriptor of the reward address of the OP_RETURN ticket commitment script
+func (p *DecredParser) ticketCommitment(hexScript string) (bchain.AddressDescriptor, bool) {
+	script, err := hex.DecodeString(hexScript)
+	if err != nil || len(script) != ticketCommitmentScriptLen || script[0] != txscript.OP_RETURN || script[1] != txscript.OP_DATA_30 {
+		return nil, false
+	}
+	hash := script[2:22]
+	// the most significant bit of the little endian amount marks the script hash address
+	var a dcrutil.Address
+	if script[29]&0x80 != 0 {
+		a, err = dcrutil.NewAddressScriptHashFromHash(hash, p.chainParams())
+	} else {
+		a, err = dcrutil.NewAddressPubKeyHash(hash, p.chainParams(), dcrec.STEcdsaSecp256k1)
+	}
+	if err != nil {
+		return nil, false
+	}
+	return bchain.AddressDescriptor(a.String()), true
+}
+
+// DeriveVotingAddress returns the addresses receiving the reward when the ticket votes, one for each commitment
+// of the ticket in the order of the commitments. The ticket purchased by several participants (split ticket)
+// has a commitment output followed by a change output for each participant. The vote pays the reward of each commitment
+// by an OP_SSGEN tagged P2PKH or P2SH script to the hash committed by the ticket, the script type is flagged
+// by the most significant bit of the committed amount.
+func (p *DecredParser) DeriveVotingAddress(ticket *bchain.Tx) ([]string, error) {
+	if p.GetTxType(ticket) != TxTypeTicket {
+		return nil, errors.Errorf("Transaction %v is not a ticket purchase", ticket.Txid)
+	}
+	var r []string
+	for i := 1; i < len(ticket.Vout); i += 2 {
+		addrDesc, ok := p.ticketCommitment(ticket.Vout[i].ScriptPubKey.Hex)
+		if !ok {
+			return nil, errors.Errorf("Invalid commitment %d of ticket %v", i, ticket.Txid)
+		}
+		addresses, _, err := p.GetAddressesFromAddrDesc(addrDesc)
+		if err != nil {
+			return nil, err
+		}
+		if len(addresses) != 1 {
+			return nil, errors.Errorf("Invalid commitment %d of ticket %v", i, ticket.Txid)
+		}
+		r = append(r, addresses[0])
+	}
+	if len(r) == 0 {
+		return nil, errors.Errorf("Ticket %v has no commitment", ticket.Txid)
+	}
+	return r, nil
+}
+
+// GetSpentTicket returns the ticket spent by the vote or revocation transaction, the vote spends the ticket
+// by the input following the stakebase, the revocation by its only input
+func (p *DecredParser) GetSpentTicket(tx *bchain.Tx) (string, bool, bool) {
+	switch p.GetTxType(tx) {
+	case TxTypeVote:
+		if len(tx.Vin) > 1 {
+			return tx.Vin[1].Txid, true, true
+		}
+	case TxTypeRevocation, TxTypeAutoRevocation:
+		if len(tx.Vin) > 0 {
+			return tx.Vin[0].Txid, false, true
+		}
+	}
+	return "", false, false
+}
+
+// TicketLifetime returns the number of blocks before the ticket becomes live and the number of blocks it stays live
+func (p *DecredParser) TicketLifetime() (uint32, uint32) {
+	params := p.chainParams()
+	return uint32(params.TicketMaturity), params.TicketExpiry
+}
+
+// minMixedOutputs is the minimum number of the outputs of the same value in a CoinShuffle++ mix
+const minMixedOutputs = 3
+
+// GetMixDenomination detects the CoinShuffle++ mix by a heuristic and returns the value and the number
+// of its mixed outputs. The mix joins the inputs of several peers, the outputs of the most frequent value
+// are the mixed outputs, there are at least minMixedOutputs of them and they make at least half
+// of the outputs, the rest is the change of the peers.
+func (p *DecredParser) GetMixDenomination(tx *bchain.Tx) (*big.Int, int, bool) {
+	if len(tx.Vin) < 2 || len(tx.Vout) < minMixedOutputs {
+		return nil, 0, false
+	}
+	counts := make(map[string]int, len(tx.Vout))
+	best := -1
+	for i := range tx.Vout {
+		if tx.Vout[i].ValueSat.Sign() == 0 {
+			continue
+		}
+		k := tx.Vout[i].ValueSat.String()
+		counts[k]++
+		if best < 0 || counts[k] > counts[tx.Vout[best].ValueSat.String()] {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil, 0, false
+	}
+	n := counts[tx.Vout[best].ValueSat.String()]
+	if n < minMixedOutputs || 2*n < len(tx.Vout) {
+		return nil, 0, false
+	}
+	return new(big.Int).Set(&tx.Vout[best].ValueSat), n, true
+}
+
+// isTreasurybase returns true for the transaction crediting the treasury with its share of the block subsidy,
+// it has no input, the OP_TADD credit output and OP_RETURN output with the block height
+func isTreasurybase(tx *bchain.Tx) bool {
+	if len(tx.Vin) != 1 || tx.Vin[0].Txid != "" || len(tx.Vout) != 2 {
+		return false
+	}
+	script, err := hex.DecodeString(tx.Vout[1].ScriptPubKey.Hex)
+	if err != nil || len(script) == 0 || script[0] != txscript.OP_RETURN {
+		return false
+	}
+	return isTreasuryCredit(tx.Vout[0].ScriptPubKey.Hex)
+}
+
+// isTreasuryCredit returns true for the outputs adding funds to the treasury (OP_TADD)
+func isTreasuryCredit(hexScript string) bool {
+	return hexScript == "c1"
+}
+
 // GetAddrDescForUnknownInput returns nil AddressDescriptor
 func (p *DecredParser) GetAddrDescForUnknownInput(tx *bchain.Tx, input int) bchain.AddressDescriptor {
 	return nil
 }
 
 func (p *DecredParser) GetAddrDescFromAddress(address string) (bchain.AddressDescriptor, error) {
+	if strings.HasPrefix(address, Hash256AddressPrefix) {
+		hash, err := hex.DecodeString(address[len(Hash256AddressPrefix):])
+		if err != nil || len(hash) != 32 {
+			return nil, errors.Errorf("Invalid hash256 address %v", address)
+		}
+		return hash256Script(hash), nil
+	}
 	addressByte := []byte(address)
 	return bchain.AddressDescriptor(addressByte), nil
 }
 
 func (p *DecredParser) GetAddrDescFromVout(output *bchain.Vout) (bchain.AddressDescriptor, error) {
+	// only the script version 0 is defined, the scripts of other versions must not be interpreted
+	if output.Version != txscript.DefaultScriptVersion {
+		return nil, errors.Annotatef(ErrUnknownScriptVersion, "version %d", output.Version)
+	}
 	script, err := hex.DecodeString(output.ScriptPubKey.Hex)
 	if err != nil {
 		return nil, err
 	}
+	// treasury spend payments are standard scripts tagged by OP_TGEN
+	if len(script) > 1 && script[0] == opTGen {
+		script = script[1:]
+	}
+
+	// the hash256 scripts have no address encoding, the raw script is kept as the descriptor
+	if isHash256Script(script) {
+		return bchain.AddressDescriptor(script), nil
+	}
 
-	scriptClass, addresses, _, err := txscript.ExtractPkScriptAddrs(txscript.DefaultScriptVersion, script, &dch.TestNet3Params)
+	scriptClass, addresses, _, err := txscript.ExtractPkScriptAddrs(txscript.DefaultScriptVersion, script, p.chainParams())
 	if err != nil {
 		return nil, err
 	}
 
-	if scriptClass.String() == "nulldata" {
-		if parsedOPReturn := p.BitcoinParser.TryParseOPReturn(script); parsedOPReturn != "" {
-			return []byte(parsedOPReturn), nil
+	// nulldata outputs have no address, the raw script is kept as the descriptor
+	// so that the embedded data can be retrieved by GetScriptFromAddrDesc
+	if scriptClass == txscript.NullDataTy {
+		return bchain.AddressDescriptor(script), nil
+	}
+
+	if scriptClass == txscript.PubKeyAltTy || scriptClass == txscript.PubkeyHashAltTy {
+		addresses, err = p.altSigAddresses(script, scriptClass, addresses)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -179,12 +684,249 @@ func (p *DecredParser) GetAddrDescFromVout(output *bchain.Vout) (bchain.AddressD
 	return bchain.AddressDescriptor(addressByte), nil
 }
 
+// lengths and prefixes of the serialized secp256k1 public keys
+const (
+	pubKeyCompressedLen   = 33
+	pubKeyUncompressedLen = 65
+	pubKeyCompressedEven  = 0x02
+	pubKeyCompressedOdd   = 0x03
+	pubKeyUncompressed    = 0x04
+)
+
+// GetAddrDescFromKey returns the descriptor of the P2PKH address of the secp256k1 public key. The address is made
+// from the hash of the key as serialized, the compressed and the uncompressed form of a key have different addresses.
+// The uncompressed keys are non-standard but valid, old wallets used them. Other formats return ErrUnknownPubKeyFormat.
+func (p *DecredParser) GetAddrDescFromKey(pubKey []byte) (bchain.AddressDescriptor, error) {
+	switch {
+	case len(pubKey) == pubKeyCompressedLen && (pubKey[0] == pubKeyCompressedEven || pubKey[0] == pubKeyCompressedOdd):
+	case len(pubKey) == pubKeyUncompressedLen && pubKey[0] == pubKeyUncompressed:
+	default:
+		return nil, errors.Annotatef(ErrUnknownPubKeyFormat, "key %x", pubKey)
+	}
+	if _, err := secp256k1.ParsePubKey(pubKey); err != nil {
+		return nil, errors.Annotatef(err, "key %x", pubKey)
+	}
+	script := make([]byte, 0, 25)
+	script = append(script, txscript.OP_DUP, txscript.OP_HASH160, txscript.OP_DATA_20)
+	script = append(script, dcrutil.Hash160(pubKey)...)
+	script = append(script, txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)
+	return p.GetAddrDescFromVout(&bchain.Vout{ScriptPubKey: bchain.ScriptPubKey{Hex: hex.EncodeToString(script)}})
+}
+
 func (p *DecredParser) GetAddressesFromAddrDesc(addrDesc bchain.AddressDescriptor) ([]string, bool, error) {
 	var addrs []string
 
+	if isNullDataAddrDesc(addrDesc) {
+		return []string{}, false, nil
+	}
+
+	if isHash256Script(addrDesc) {
+		return []string{Hash256AddressPrefix + hex.EncodeToString(addrDesc[2:34])}, true, nil
+	}
+
 	if addrDesc != nil {
 		addrs = append(addrs, string(addrDesc))
 	}
 
 	return addrs, true, nil
 }
+
+// decredExtKey decodes the Decred extended public key and checks that it belongs to the network of the parser
+func (p *DecredParser) decredExtKey(xpub string) (*hdkeychain.ExtendedKey, error) {
+	extKey, err := hdkeychain.NewKeyFromString(xpub)
+	if err != nil {
+		return nil, err
+	}
+	if !extKey.IsForNet(p.chainParams()) {
+		return nil, errors.Errorf("Extended key is not for network %v", p.chainParams().Name)
+	}
+	return extKey, nil
+}
+
+// addrDescFromExtKey returns the descriptor of the P2PKH address of the key, the only address type of the Decred HD wallets,
+// the descriptor is made from the P2PKH script so that it matches the descriptors of the indexed outputs
+func (p *DecredParser) addrDescFromExtKey(extKey *hdkeychain.ExtendedKey) (bchain.AddressDescriptor, error) {
+	a, err := extKey.Address(p.chainParams())
+	if err != nil {
+		return nil, err
+	}
+	script, err := txscript.PayToAddrScript(a)
+	if err != nil {
+		return nil, err
+	}
+	return p.GetAddrDescFromVout(&bchain.Vout{ScriptPubKey: bchain.ScriptPubKey{Hex: hex.EncodeToString(script)}})
+}
+
+// DeriveAddressDescriptors derives address descriptors from given xpub for listed indexes,
+// Decred extended keys use their own encoding and hash function and cannot be derived by the bitcoin parser
+func (p *DecredParser) DeriveAddressDescriptors(xpub string, change uint32, indexes []uint32) ([]bchain.AddressDescriptor, error) {
+	extKey, err := p.decredExtKey(xpub)
+	if err != nil {
+		return nil, err
+	}
+	changeExtKey, err := extKey.Child(change)
+	if err != nil {
+		return nil, err
+	}
+	ad := make([]bchain.AddressDescriptor, len(indexes))
+	for i, index := range indexes {
+		indexExtKey, err := changeExtKey.Child(index)
+		if err != nil {
+			return nil, err
+		}
+		ad[i], err = p.addrDescFromExtKey(indexExtKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ad, nil
+}
+
+// DeriveAddressDescriptorsFromTo derives address descriptors from given xpub for addresses in index range
+func (p *DecredParser) DeriveAddressDescriptorsFromTo(xpub string, change uint32, fromIndex uint32, toIndex uint32) ([]bchain.AddressDescriptor, error) {
+	if toIndex <= fromIndex {
+		return nil, errors.New("toIndex<=fromIndex")
+	}
+	extKey, err := p.decredExtKey(xpub)
+	if err != nil {
+		return nil, err
+	}
+	changeExtKey, err := extKey.Child(change)
+	if err != nil {
+		return nil, err
+	}
+	ad := make([]bchain.AddressDescriptor, toIndex-fromIndex)
+	for index := fromIndex; index < toIndex; index++ {
+		indexExtKey, err := changeExtKey.Child(index)
+		if err != nil {
+			return nil, err
+		}
+		ad[index-fromIndex], err = p.addrDescFromExtKey(indexExtKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ad, nil
+}
+
+// GetScriptType returns the dcrd script class name of the output, for example "nulldata"
+func (p *DecredParser) GetScriptType(output *bchain.Vout) string {
+	script, err := hex.DecodeString(output.ScriptPubKey.Hex)
+	if err != nil {
+		return ""
+	}
+	// the treasury scripts are not known to the txscript version in use
+	if len(script) > 0 {
+		switch script[0] {
+		case opTAdd:
+			return "treasuryadd"
+		case opTGen:
+			return "treasurygen"
+		}
+	}
+	if isHash256Script(script) {
+		return "hash256"
+	}
+	return txscript.GetScriptClass(txscript.DefaultScriptVersion, script).String()
+}
+
+// GetScriptDisassembly returns the human readable form of the output script,
+// for example "OP_SSTX OP_DUP OP_HASH160 <hash> OP_EQUALVERIFY OP_CHECKSIG" for a ticket purchase
+func (p *DecredParser) GetScriptDisassembly(output *bchain.Vout) string {
+	return disassembleScript(output.ScriptPubKey.Hex)
+}
+
+func disassembleScript(hexScript string) string {
+	script, err := hex.DecodeString(hexScript)
+	if err != nil {
+		return ""
+	}
+	// a script that fails to parse is disassembled up to the failure point
+	s, _ := txscript.DisasmString(script)
+	return s
+}
+
+// ParseOpReturnData returns the data pushed by the OP_RETURN script, for example
+// the merkle root of the timestamped hashes embedded by dcrtime
+func (p *DecredParser) ParseOpReturnData(script []byte) ([]byte, error) {
+	if len(script) == 0 || script[0] != txscript.OP_RETURN {
+		return nil, errors.New("Not an OP_RETURN script")
+	}
+	if txscript.GetScriptClass(txscript.DefaultScriptVersion, script) != txscript.NullDataTy {
+		return nil, errors.New("Nonstandard OP_RETURN script")
+	}
+	pushes, err := txscript.PushedData(script[1:])
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	for _, push := range pushes {
+		data = append(data, push...)
+	}
+	return data, nil
+}
+
+// isNullDataAddrDesc returns true if the descriptor is a raw OP_RETURN script
+func isNullDataAddrDesc(addrDesc bchain.AddressDescriptor) bool {
+	return len(addrDesc) > 0 && addrDesc[0] == txscript.OP_RETURN
+}
+
+// isHash256Script returns true if the script is OP_HASH256 OP_DATA_32 <hash> OP_EQUAL,
+// the script is not standard in dcrd but it is used by some contracts to lock the outputs by a preimage
+func isHash256Script(script []byte) bool {
+	return len(script) == hash256ScriptLen &&
+		script[0] == txscript.OP_HASH256 &&
+		script[1] == txscript.OP_DATA_32 &&
+		script[34] == txscript.OP_EQUAL
+}
+
+func hash256Script(hash []byte) []byte {
+	script := make([]byte, 0, hash256ScriptLen)
+	script = append(script, txscript.OP_HASH256, txscript.OP_DATA_32)
+	script = append(script, hash...)
+	return append(script, txscript.OP_EQUAL)
+}
+
+// altSigType decodes the signature algorithm of pubkeyalt and pubkeyhashalt scripts,
+// it is pushed as a small integer just before the final OP_CHECKSIGALT
+func altSigType(script []byte) (dcrec.SignatureType, error) {
+	if len(script) < 2 || script[len(script)-1] != txscript.OP_CHECKSIGALT {
+		return 0, errors.New("Invalid OP_CHECKSIGALT script")
+	}
+	op := script[len(script)-2]
+	if op < txscript.OP_1 || op > txscript.OP_16 {
+		return 0, errors.New("Invalid OP_CHECKSIGALT signature type")
+	}
+	sigType := dcrec.SignatureType(op - txscript.OP_1 + 1)
+	if sigType != dcrec.STEd25519 && sigType != dcrec.STSchnorrSecp256k1 {
+		return 0, errors.Errorf("Unsupported OP_CHECKSIGALT signature type %d", sigType)
+	}
+	return sigType, nil
+}
+
+// altSigAddresses returns the pubkey hash address with the correct signature algorithm for alt signature scripts
+// pubkeyalt outputs are converted to the pubkey hash form to share the address history with pubkeyhashalt outputs
+func (p *DecredParser) altSigAddresses(script []byte, scriptClass txscript.ScriptClass, addresses []dcrutil.Address) ([]dcrutil.Address, error) {
+	sigType, err := altSigType(script)
+	if err != nil {
+		return nil, err
+	}
+	var hash []byte
+	if scriptClass == txscript.PubkeyHashAltTy {
+		// OP_DUP OP_HASH160 OP_DATA_20 <hash> OP_EQUALVERIFY <sigtype> OP_CHECKSIGALT
+		if len(script) != 26 {
+			return nil, errors.New("Invalid pubkeyhashalt script")
+		}
+		hash = script[3:23]
+	} else {
+		if len(addresses) != 1 {
+			return nil, errors.New("Invalid pubkeyalt script")
+		}
+		hash = dcrutil.Hash160(addresses[0].ScriptAddress())
+	}
+	a, err := dcrutil.NewAddressPubKeyHash(hash, p.chainParams(), sigType)
+	if err != nil {
+		return nil, err
+	}
+	return []dcrutil.Address{a}, nil
+}
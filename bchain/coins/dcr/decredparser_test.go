@@ -0,0 +1,1205 @@
+//go:build unittest
+// +build unittest
+
+package dcr
+
+import (
+	"blockbook/bchain"
+	"blockbook/bchain/coins/btc"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/hdkeychain"
+	"github.com/juju/errors"
+	"github.com/martinboehm/btcutil/chaincfg"
+
+	dch "github.com/decred/dcrd/chaincfg"
+)
+
+var testParser *DecredParser
+
+func TestMain(m *testing.M) {
+	testParser = NewDecredParser(GetChainParams("mainnet"), &btc.Configuration{})
+	c := m.Run()
+	chaincfg.ResetParams()
+	os.Exit(c)
+}
+
+func Test_GetChainParams(t *testing.T) {
+	tests := []struct {
+		chain string
+		net   uint32
+		want  *dch.Params
+	}{
+		{chain: "mainnet", net: uint32(MainnetMagic), want: &dch.MainNetParams},
+		{chain: "testnet3", net: uint32(TestnetMagic), want: &dch.TestNet3Params},
+		{chain: "simnet", net: uint32(SimnetMagic), want: &dch.SimNetParams},
+		{chain: "unknown", net: uint32(MainnetMagic), want: &dch.MainNetParams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.chain, func(t *testing.T) {
+			params := GetChainParams(tt.chain)
+			if uint32(params.Net) != tt.net {
+				t.Errorf("GetChainParams() net = %#x, want %#x", uint32(params.Net), tt.net)
+			}
+			if uint32(tt.want.Net) != tt.net {
+				t.Errorf("dcrd params net = %#x, want %#x", uint32(tt.want.Net), tt.net)
+			}
+			p := NewDecredParser(params, &btc.Configuration{})
+			if got := p.chainParams(); got != tt.want {
+				t.Errorf("chainParams() = %v, want %v", got.Name, tt.want.Name)
+			}
+		})
+	}
+}
+
+func Test_GetChainParams_Testnet(t *testing.T) {
+	p := NewDecredParser(GetChainParams("testnet3"), &btc.Configuration{})
+	if p.CoinbaseMaturity() != int(dch.TestNet3Params.CoinbaseMaturity) {
+		t.Errorf("CoinbaseMaturity() = %d, want %d", p.CoinbaseMaturity(), dch.TestNet3Params.CoinbaseMaturity)
+	}
+	// the testnet keys are accepted only by the testnet parser
+	xpub, extKey := testAccountXpub(t, &dch.TestNet3Params)
+	got, err := p.DeriveAddressDescriptorsFromTo(xpub, 0, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := extKey.Child(0)
+	if err == nil {
+		child, err = child.Child(0)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := child.Address(&dch.TestNet3Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addresses, _, err := p.GetAddressesFromAddrDesc(got[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addresses) != 1 || addresses[0] != a.EncodeAddress() || !strings.HasPrefix(addresses[0], "Ts") {
+		t.Errorf("GetAddressesFromAddrDesc() = %v, want [%v]", addresses, a.EncodeAddress())
+	}
+	mainnetXpub, _ := testAccountXpub(t, &dch.MainNetParams)
+	if _, err = p.DeriveAddressDescriptorsFromTo(mainnetXpub, 0, 0, 1); err == nil {
+		t.Error("DeriveAddressDescriptorsFromTo() expected error for mainnet xpub")
+	}
+}
+
+func Test_GetAddrDescFromVout_NullData(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    string
+		want      string
+		wantAddrs []string
+	}{
+		{
+			name:      "OP_RETURN ascii",
+			script:    "6a0461686f6a",
+			want:      "6a0461686f6a",
+			wantAddrs: []string{},
+		},
+		{
+			name:      "OP_RETURN hash",
+			script:    "6a20e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			want:      "6a20e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			wantAddrs: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vout := &bchain.Vout{ScriptPubKey: bchain.ScriptPubKey{Hex: tt.script}}
+			got, err := testParser.GetAddrDescFromVout(vout)
+			if err != nil {
+				t.Errorf("GetAddrDescFromVout() error = %v", err)
+				return
+			}
+			if h := hex.EncodeToString(got); h != tt.want {
+				t.Errorf("GetAddrDescFromVout() = %v, want %v", h, tt.want)
+			}
+			if testParser.IsAddrDescIndexable(got) {
+				t.Errorf("IsAddrDescIndexable() = true, want false")
+			}
+			addrs, searchable, err := testParser.GetAddressesFromAddrDesc(got)
+			if err != nil {
+				t.Errorf("GetAddressesFromAddrDesc() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(addrs, tt.wantAddrs) || searchable {
+				t.Errorf("GetAddressesFromAddrDesc() = %v, %v, want %v, false", addrs, searchable, tt.wantAddrs)
+			}
+			if st := testParser.GetScriptType(vout); st != "nulldata" {
+				t.Errorf("GetScriptType() = %v, want nulldata", st)
+			}
+		})
+	}
+}
+
+func Test_ParseOpReturnData(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "ascii",
+			script: "6a0461686f6a",
+			want:   "61686f6a",
+		},
+		{
+			name:   "hash",
+			script: "6a20e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			want:   "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+		{
+			name:   "empty",
+			script: "6a",
+			want:   "",
+		},
+		{
+			name:    "p2pkh",
+			script:  "76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, _ := hex.DecodeString(tt.script)
+			got, err := testParser.ParseOpReturnData(script)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseOpReturnData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if h := hex.EncodeToString(got); h != tt.want {
+				t.Errorf("ParseOpReturnData() = %v, want %v", h, tt.want)
+			}
+		})
+	}
+}
+
+func Test_GetAddrDescFromVout_ScriptVersion(t *testing.T) {
+	vout := &bchain.Vout{
+		ScriptPubKey: bchain.ScriptPubKey{Hex: "76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"},
+		Version:      1,
+	}
+	_, err := testParser.GetAddrDescFromVout(vout)
+	if errors.Cause(err) != ErrUnknownScriptVersion {
+		t.Errorf("GetAddrDescFromVout() error = %v, want %v", err, ErrUnknownScriptVersion)
+	}
+	vout.Version = 0
+	if _, err = testParser.GetAddrDescFromVout(vout); err != nil {
+		t.Errorf("GetAddrDescFromVout() error = %v", err)
+	}
+}
+
+func Test_GetAddrDescFromVout_TreasuryGen(t *testing.T) {
+	tgen := &bchain.Vout{ScriptPubKey: bchain.ScriptPubKey{Hex: "c376a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}}
+	p2pkh := &bchain.Vout{ScriptPubKey: bchain.ScriptPubKey{Hex: "76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}}
+	got, err := testParser.GetAddrDescFromVout(tgen)
+	if err != nil {
+		t.Fatalf("GetAddrDescFromVout() error = %v", err)
+	}
+	want, err := testParser.GetAddrDescFromVout(p2pkh)
+	if err != nil {
+		t.Fatalf("GetAddrDescFromVout() error = %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("GetAddrDescFromVout() = %v, want %v", string(got), string(want))
+	}
+}
+
+func Test_Hash256Script(t *testing.T) {
+	script := "aa20e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b85587"
+	address := "hash256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	vout := &bchain.Vout{ScriptPubKey: bchain.ScriptPubKey{Hex: script}}
+
+	got, err := testParser.GetAddrDescFromVout(vout)
+	if err != nil {
+		t.Fatalf("GetAddrDescFromVout() error = %v", err)
+	}
+	if h := hex.EncodeToString(got); h != script {
+		t.Errorf("GetAddrDescFromVout() = %v, want %v", h, script)
+	}
+	addrs, searchable, err := testParser.GetAddressesFromAddrDesc(got)
+	if err != nil {
+		t.Fatalf("GetAddressesFromAddrDesc() error = %v", err)
+	}
+	if !reflect.DeepEqual(addrs, []string{address}) || !searchable {
+		t.Errorf("GetAddressesFromAddrDesc() = %v, %v, want %v, true", addrs, searchable, address)
+	}
+	fromAddress, err := testParser.GetAddrDescFromAddress(address)
+	if err != nil {
+		t.Fatalf("GetAddrDescFromAddress() error = %v", err)
+	}
+	if h := hex.EncodeToString(fromAddress); h != script {
+		t.Errorf("GetAddrDescFromAddress() = %v, want %v", h, script)
+	}
+	if st := testParser.GetScriptType(vout); st != "hash256" {
+		t.Errorf("GetScriptType() = %v, want hash256", st)
+	}
+	if _, err = testParser.GetAddrDescFromAddress("hash256:e3b0c442"); err == nil {
+		t.Errorf("GetAddrDescFromAddress() of a short hash, want error")
+	}
+}
+
+func Test_altSigType(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		want    dcrec.SignatureType
+		wantErr bool
+	}{
+		{
+			name:   "pubkeyhashalt ed25519",
+			script: "76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d48851be",
+			want:   dcrec.STEd25519,
+		},
+		{
+			name:   "pubkeyhashalt schnorr",
+			script: "76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d48852be",
+			want:   dcrec.STSchnorrSecp256k1,
+		},
+		{
+			name:   "pubkeyalt ed25519",
+			script: "20cecc1507dc1ddd7295951c290888f095adb9044d1b73d696e6df065d683bd4fc51be",
+			want:   dcrec.STEd25519,
+		},
+		{
+			name:    "unsupported signature type",
+			script:  "76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d48853be",
+			wantErr: true,
+		},
+		{
+			name:    "not checksigalt",
+			script:  "76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, _ := hex.DecodeString(tt.script)
+			got, err := altSigType(script)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("altSigType() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("altSigType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_GetAddrDescFromVout_AltSig(t *testing.T) {
+	hash, _ := hex.DecodeString("f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d4")
+	want, err := dcrutil.NewAddressPubKeyHash(hash, &dch.MainNetParams, dcrec.STEd25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := testParser.GetAddrDescFromVout(&bchain.Vout{ScriptPubKey: bchain.ScriptPubKey{Hex: "76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d48851be"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want.String() {
+		t.Errorf("GetAddrDescFromVout() = %v, want %v", string(got), want.String())
+	}
+}
+
+func Test_GetScriptDisassembly(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{
+			name:   "sstx",
+			script: "ba76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac",
+			want:   "OP_SSTX OP_DUP OP_HASH160 f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d4 OP_EQUALVERIFY OP_CHECKSIG",
+		},
+		{
+			name:   "ssgen",
+			script: "bb76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac",
+			want:   "OP_SSGEN OP_DUP OP_HASH160 f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d4 OP_EQUALVERIFY OP_CHECKSIG",
+		},
+		{
+			name:   "invalid hex",
+			script: "xx",
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vout := &bchain.Vout{ScriptPubKey: bchain.ScriptPubKey{Hex: tt.script}}
+			if got := testParser.GetScriptDisassembly(vout); got != tt.want {
+				t.Errorf("GetScriptDisassembly() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_GetTxType(t *testing.T) {
+	tests := []struct {
+		name string
+		tx   bchain.Tx
+		want string
+	}{
+		{
+			name: "regular",
+			tx: bchain.Tx{
+				Version: 1,
+				Vin:     []bchain.Vin{{ScriptSig: bchain.ScriptSig{Hex: "47304402"}}},
+				Vout:    []bchain.Vout{{ScriptPubKey: bchain.ScriptPubKey{Hex: "76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}}},
+			},
+			want: TxTypeRegular,
+		},
+		{
+			name: "ticket",
+			tx: bchain.Tx{
+				Version: 1,
+				Vin:     []bchain.Vin{{ScriptSig: bchain.ScriptSig{Hex: "47304402"}}},
+				Vout:    []bchain.Vout{{ScriptPubKey: bchain.ScriptPubKey{Hex: "ba76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}}},
+			},
+			want: TxTypeTicket,
+		},
+		{
+			name: "vote",
+			tx: bchain.Tx{
+				Version: 1,
+				Vin:     []bchain.Vin{{}, {ScriptSig: bchain.ScriptSig{Hex: "47304402"}}},
+				Vout:    []bchain.Vout{{ScriptPubKey: bchain.ScriptPubKey{Hex: "bb76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}}},
+			},
+			want: TxTypeVote,
+		},
+		{
+			name: "revocation",
+			tx: bchain.Tx{
+				Version: 1,
+				Vin:     []bchain.Vin{{ScriptSig: bchain.ScriptSig{Hex: "47304402"}}},
+				Vout:    []bchain.Vout{{ScriptPubKey: bchain.ScriptPubKey{Hex: "bc76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}}},
+			},
+			want: TxTypeRevocation,
+		},
+		{
+			name: "tspend",
+			tx: bchain.Tx{
+				Version: 3,
+				Vin:     []bchain.Vin{{ScriptSig: bchain.ScriptSig{Hex: "4030440220c2"}}},
+				Vout: []bchain.Vout{
+					{ScriptPubKey: bchain.ScriptPubKey{Hex: "6a200000000000000000000000000000000000000000000000000000000000000000"}},
+					{ScriptPubKey: bchain.ScriptPubKey{Hex: "c376a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}},
+				},
+			},
+			want: TxTypeTreasurySpend,
+		},
+		{
+			name: "treasurybase",
+			tx: bchain.Tx{
+				Version: 3,
+				Vin:     []bchain.Vin{{}},
+				Vout: []bchain.Vout{
+					{ScriptPubKey: bchain.ScriptPubKey{Hex: "c1"}},
+					{ScriptPubKey: bchain.ScriptPubKey{Hex: "6a0c8ad40800c0a1d3e1be836b47"}},
+				},
+			},
+			want: TxTypeTreasurybase,
+		},
+		{
+			name: "autorevocation",
+			tx: bchain.Tx{
+				Version: 2,
+				Vin:     []bchain.Vin{{}},
+				Vout:    []bchain.Vout{{ScriptPubKey: bchain.ScriptPubKey{Hex: "bc76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}}},
+			},
+			want: TxTypeAutoRevocation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testParser.GetTxType(&tt.tx); got != tt.want {
+				t.Errorf("GetTxType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testVoteHex is a vote serialized in the wire format of dcrd spending a ticket and voting for the genesis block,
+// testVotePrefixHex is the serialization of its prefix from which the txid is computed
+const (
+	testVoteHex       = "01000000020000000000000000000000000000000000000000000000000000000000000000ffffffff00ffffffffb2c3d4e5f60718293a4b5c6d7e8f9a0b1c2d3e5f6a8b4c9d2e7a1c3f0b1e5b5a0000000001ffffffff0300000000000000000000266a2480d9212bf4ceb066ded2866b39d4ed89e0ab60f335c11df8e7bf85d9c35c8e290000000000000000000000000000086a0601000a0000000003437f0300000000001abb76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac000000000000000002002d31010000000000000000ffffffff02000000d6117e0300000064000000010000000447304402"
+	testVotePrefixHex = "01000100020000000000000000000000000000000000000000000000000000000000000000ffffffff00ffffffffb2c3d4e5f60718293a4b5c6d7e8f9a0b1c2d3e5f6a8b4c9d2e7a1c3f0b1e5b5a0000000001ffffffff0300000000000000000000266a2480d9212bf4ceb066ded2866b39d4ed89e0ab60f335c11df8e7bf85d9c35c8e290000000000000000000000000000086a0601000a0000000003437f0300000000001abb76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac0000000000000000"
+	testVoteTxid      = "166d9e11484535d0d195d0ed704b91696bd39ca2a42391b4de0f546a5c1a5f28"
+)
+
+func Test_GetTxType_SerializedVote(t *testing.T) {
+	// the prefix differs from the full serialization only by the serialization type and the missing witness
+	prefix, _ := hex.DecodeString(testVotePrefixHex)
+	full, _ := hex.DecodeString(testVoteHex)
+	if !bytes.HasPrefix(full[4:], prefix[4:]) {
+		t.Fatal("the vote prefix is not a part of the vote")
+	}
+	if h := chainhash.HashH(prefix); h.String() != testVoteTxid {
+		t.Fatalf("vote txid %v, want %v", h, testVoteTxid)
+	}
+	// the outputs of the vote as returned by getrawtransaction
+	tx, err := testParser.ParseTxFromJson(json.RawMessage(`{
+		"hex": "` + testVoteHex + `",
+		"txid": "` + testVoteTxid + `",
+		"version": 1,
+		"vin": [
+			{"stakebase": "0000", "sequence": 4294967295, "amountin": 0.2},
+			{"txid": "5a5b1e0b3f1c7a2e9d4c8b6a5f3e2d1c0b9a8f7e6d5c4b3a291807f6e5d4c3b2", "vout": 0, "tree": 1, "sequence": 4294967295, "amountin": 150, "scriptsig": {"hex": "47304402"}}
+		],
+		"vout": [
+			{"value": 0, "n": 0, "version": 0, "scriptPubKey": {"hex": "6a2480d9212bf4ceb066ded2866b39d4ed89e0ab60f335c11df8e7bf85d9c35c8e2900000000"}},
+			{"value": 0, "n": 1, "version": 0, "scriptPubKey": {"hex": "6a0601000a000000"}},
+			{"value": 150.2, "n": 2, "version": 0, "scriptPubKey": {"hex": "bb76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range tx.Vout {
+		if !strings.Contains(testVoteHex, v.ScriptPubKey.Hex) {
+			t.Fatalf("output %d script %v is not a part of the vote", v.N, v.ScriptPubKey.Hex)
+		}
+	}
+	if got := testParser.GetTxType(tx); got != TxTypeVote {
+		t.Errorf("GetTxType() = %v, want %v", got, TxTypeVote)
+	}
+	if bits, version, ok := testParser.GetVoteBits(tx); !ok || bits != 1 || version != 10 {
+		t.Errorf("GetVoteBits() = %v, %v, %v, want 1, 10, true", bits, version, ok)
+	}
+	// the OP_RETURN outputs followed by other than the vote payment are not a vote
+	tx.Vout[2].ScriptPubKey.Hex = "ba76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"
+	if got := testParser.GetTxType(tx); got != TxTypeRegular {
+		t.Errorf("GetTxType() = %v, want %v", got, TxTypeRegular)
+	}
+}
+
+func Test_GetUtxoType(t *testing.T) {
+	p2pkh := "76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"
+	tests := []struct {
+		name string
+		tx   bchain.Tx
+		vout int
+		want string
+	}{
+		{
+			name: "regular",
+			tx: bchain.Tx{
+				Vin:  []bchain.Vin{{Txid: "5a0a6a8ad6f4a2f1f6c5d3b2c1a09f8e7d6c5b4a39281706f5e4d3c2b1a09f8e"}},
+				Vout: []bchain.Vout{{ScriptPubKey: bchain.ScriptPubKey{Hex: p2pkh}}},
+			},
+			want: UtxoTypeRegular,
+		},
+		{
+			name: "coinbase",
+			tx: bchain.Tx{
+				Vin:  []bchain.Vin{{Coinbase: "00000000"}},
+				Vout: []bchain.Vout{{ScriptPubKey: bchain.ScriptPubKey{Hex: p2pkh}}},
+			},
+			want: UtxoTypeCoinbase,
+		},
+		{
+			name: "ticket change",
+			tx: bchain.Tx{
+				Vout: []bchain.Vout{
+					{ScriptPubKey: bchain.ScriptPubKey{Hex: "ba" + p2pkh}},
+					{ScriptPubKey: bchain.ScriptPubKey{Hex: "6a1e"}},
+					{ScriptPubKey: bchain.ScriptPubKey{Hex: "bd" + p2pkh}},
+				},
+			},
+			vout: 2,
+			want: UtxoTypeTicketChange,
+		},
+		{
+			name: "stakebase",
+			tx: bchain.Tx{
+				Vout: []bchain.Vout{
+					{ScriptPubKey: bchain.ScriptPubKey{Hex: "6a24"}},
+					{ScriptPubKey: bchain.ScriptPubKey{Hex: "6a06"}},
+					{ScriptPubKey: bchain.ScriptPubKey{Hex: "bb" + p2pkh}},
+				},
+			},
+			vout: 2,
+			want: UtxoTypeStakebase,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testParser.GetUtxoType(&tt.tx, tt.vout); got != tt.want {
+				t.Errorf("GetUtxoType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_GetMixDenomination(t *testing.T) {
+	vouts := func(values ...int64) []bchain.Vout {
+		r := make([]bchain.Vout, len(values))
+		for i, v := range values {
+			r[i].ValueSat = *big.NewInt(v)
+		}
+		return r
+	}
+	vins := func(n int) []bchain.Vin {
+		return make([]bchain.Vin, n)
+	}
+	tests := []struct {
+		name             string
+		vin              []bchain.Vin
+		vout             []bchain.Vout
+		wantDenomination int64
+		wantMixed        int
+		wantOk           bool
+	}{
+		{name: "mix with change", vin: vins(4), vout: vouts(1234, 268435456, 5678, 268435456, 268435456), wantDenomination: 268435456, wantMixed: 3, wantOk: true},
+		{name: "mix without change", vin: vins(3), vout: vouts(26843545, 26843545, 26843545, 26843545), wantDenomination: 26843545, wantMixed: 4, wantOk: true},
+		{name: "equal outputs", vin: vins(2), vout: vouts(268435456, 268435456, 268435456, 268435456), wantDenomination: 268435456, wantMixed: 4, wantOk: true},
+		{name: "single input", vin: vins(1), vout: vouts(268435456, 268435456, 268435456), wantOk: false},
+		{name: "two equal outputs", vin: vins(2), vout: vouts(268435456, 268435456, 1234), wantOk: false},
+		{name: "two outputs", vin: vins(2), vout: vouts(268435456, 268435456), wantOk: false},
+		{name: "mostly change", vin: vins(5), vout: vouts(1, 2, 3, 4, 268435456, 268435456, 268435456), wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			denomination, mixed, ok := testParser.GetMixDenomination(&bchain.Tx{Vin: tt.vin, Vout: tt.vout})
+			if ok != tt.wantOk || mixed != tt.wantMixed {
+				t.Fatalf("GetMixDenomination() = %v, %v, %v, want %v, %v", denomination, mixed, ok, tt.wantMixed, tt.wantOk)
+			}
+			if ok && denomination.Int64() != tt.wantDenomination {
+				t.Errorf("GetMixDenomination() denomination = %v, want %v", denomination, tt.wantDenomination)
+			}
+		})
+	}
+}
+
+var testTxJSON = json.RawMessage(`{
+	"hex": "",
+	"txid": "7058e7d3e8d4a9ef2bc0ef9a2b0a7d2ccad5b05fd59ad4a4aeb2b2a1a7b9e8a1",
+	"version": 1,
+	"locktime": 0,
+	"expiry": 0,
+	"vin": [{
+		"txid": "5a0a6a8ad6f4a2f1f6c5d3b2c1a09f8e7d6c5b4a39281706f5e4d3c2b1a09f8e",
+		"vout": 1,
+		"tree": 0,
+		"sequence": 4294967295,
+		"amountin": 12.5,
+		"blockheight": 300000,
+		"blockindex": 2,
+		"scriptSig": {"asm": "", "hex": ""}
+	}],
+	"vout": [{
+		"value": 2.5,
+		"n": 0,
+		"version": 0,
+		"scriptPubKey": {
+			"asm": "OP_DUP OP_HASH160 f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d4 OP_EQUALVERIFY OP_CHECKSIG",
+			"hex": "76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac",
+			"reqSigs": 1,
+			"type": "pubkeyhash",
+			"addresses": ["TsnjA4xBPYR8BTxc7UYtP7X3r6gbWpjd7Dy"]
+		}
+	}, {
+		"value": 9.99,
+		"n": 1,
+		"version": 0,
+		"scriptPubKey": {
+			"asm": "OP_RETURN 61686f6a",
+			"hex": "6a0461686f6a",
+			"type": "nulldata"
+		}
+	}],
+	"confirmations": 10,
+	"time": 1550000000000,
+	"blocktime": 1550000000
+}`)
+
+func Test_ParseTxFromJsonDecodeOnly(t *testing.T) {
+	tx, err := testParser.ParseTxFromJson(testTxJSON)
+	if err != nil {
+		t.Fatalf("ParseTxFromJson() error = %v", err)
+	}
+	decoded, err := testParser.ParseTxFromJsonDecodeOnly(testTxJSON)
+	if err != nil {
+		t.Fatalf("ParseTxFromJsonDecodeOnly() error = %v", err)
+	}
+	if len(tx.Vout[0].ScriptPubKey.Addresses) != 1 {
+		t.Errorf("ParseTxFromJson() addresses = %v, want 1 address", tx.Vout[0].ScriptPubKey.Addresses)
+	}
+	for i := range decoded.Vout {
+		if decoded.Vout[i].ScriptPubKey.Addresses != nil {
+			t.Errorf("ParseTxFromJsonDecodeOnly() vout %d addresses = %v, want nil", i, decoded.Vout[i].ScriptPubKey.Addresses)
+		}
+		if decoded.Vout[i].ScriptPubKey.Hex != tx.Vout[i].ScriptPubKey.Hex {
+			t.Errorf("ParseTxFromJsonDecodeOnly() vout %d hex = %v, want %v", i, decoded.Vout[i].ScriptPubKey.Hex, tx.Vout[i].ScriptPubKey.Hex)
+		}
+	}
+}
+
+func Test_CalcTxSize(t *testing.T) {
+	p2pkh := "76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"
+	// signature and compressed public key pushes
+	sigScript := "47" + strings.Repeat("30", 71) + "21" + strings.Repeat("02", 33)
+	tests := []struct {
+		name string
+		tx   bchain.Tx
+		want int
+	}{
+		{
+			name: "p2pkh 1 input 2 outputs",
+			tx: bchain.Tx{
+				Vin: []bchain.Vin{{Txid: "7ac8cf7a8cd1dd3e6ea357ce5cbf1f8543e864d1e4a49a2ac0e2b6e9b4ad0e53", ScriptSig: bchain.ScriptSig{Hex: sigScript}}},
+				Vout: []bchain.Vout{
+					{ScriptPubKey: bchain.ScriptPubKey{Hex: p2pkh}},
+					{ScriptPubKey: bchain.ScriptPubKey{Hex: p2pkh}},
+				},
+			},
+			// 12 fixed + 3 counts + (57 + 1 + 106) input + 2 * 36 outputs
+			want: 251,
+		},
+		{
+			name: "coinbase",
+			tx: bchain.Tx{
+				Vin: []bchain.Vin{{Coinbase: "0000000000000000"}},
+				Vout: []bchain.Vout{
+					{ScriptPubKey: bchain.ScriptPubKey{Hex: "a914f5916158e3e2c4551c1796708db8367207ed13bb87"}},
+					{ScriptPubKey: bchain.ScriptPubKey{Hex: "6a0c" + strings.Repeat("00", 12)}},
+					{ScriptPubKey: bchain.ScriptPubKey{Hex: p2pkh}},
+				},
+			},
+			// 12 fixed + 3 counts + (57 + 1 + 8) input + 34 + 25 + 36 outputs
+			want: 176,
+		},
+		{
+			name: "hex",
+			tx:   bchain.Tx{Hex: "0100000001" + strings.Repeat("00", 50)},
+			want: 55,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testParser.CalcTxSize(&tt.tx); got != tt.want {
+				t.Errorf("CalcTxSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Benchmark_ParseTxFromJson(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		testParser.ParseTxFromJson(testTxJSON)
+	}
+}
+
+func Benchmark_ParseTxFromJsonDecodeOnly(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		testParser.ParseTxFromJsonDecodeOnly(testTxJSON)
+	}
+}
+
+func Test_GetTicketCommitment(t *testing.T) {
+	// the commitment to the pubkey hash address of the p2pkh output
+	want, err := testParser.GetAddrDescFromVout(&bchain.Vout{ScriptPubKey: bchain.ScriptPubKey{Hex: "76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticket := bchain.Tx{
+		Txid:    "ticket",
+		Version: 1,
+		Vin:     []bchain.Vin{{Txid: "funding", ScriptSig: bchain.ScriptSig{Hex: "47304402"}}},
+		Vout: []bchain.Vout{
+			{ScriptPubKey: bchain.ScriptPubKey{Hex: "ba76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}},
+			{ScriptPubKey: bchain.ScriptPubKey{Hex: "6a1ef5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d400e1f505000000000058"}},
+			{ScriptPubKey: bchain.ScriptPubKey{Hex: "bd76a914000000000000000000000000000000000000000088ac"}},
+		},
+	}
+	got, ok := testParser.GetTicketCommitment(&ticket)
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTicketCommitment() = %v, %v, want %v", got, ok, want)
+	}
+	// the commitment is encoded for the network of the parser
+	hash, _ := hex.DecodeString("f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d4")
+	mainnet, err := dcrutil.NewAddressPubKeyHash(hash, &dch.MainNetParams, dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != mainnet.String() {
+		t.Errorf("GetTicketCommitment() = %v, want mainnet address %v", string(got), mainnet.String())
+	}
+	vote := bchain.Tx{
+		Version: 1,
+		Vin:     []bchain.Vin{{}, {Txid: "ticket", ScriptSig: bchain.ScriptSig{Hex: "47304402"}}},
+		Vout:    []bchain.Vout{{ScriptPubKey: bchain.ScriptPubKey{Hex: "bb76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}}},
+	}
+	if _, ok := testParser.GetTicketCommitment(&vote); ok {
+		t.Error("GetTicketCommitment() returned a commitment of a vote")
+	}
+	if spent, voted, ok := testParser.GetSpentTicket(&vote); spent != "ticket" || !voted || !ok {
+		t.Errorf("GetSpentTicket() = %v, %v, %v, want ticket, true, true", spent, voted, ok)
+	}
+	if _, _, ok := testParser.GetSpentTicket(&ticket); ok {
+		t.Error("GetSpentTicket() returned a ticket spent by a ticket purchase")
+	}
+}
+
+func Test_DeriveVotingAddress(t *testing.T) {
+	const (
+		p2pkhCommitment = "6a1ef5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d400e1f505000000000058"
+		p2shCommitment  = "6a1ef5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d400e1f505000000800058"
+		otherCommitment = "6a1e0102030405060708090a0b0c0d0e0f101112131400e1f505000000000058"
+		p2pkhVote       = "bb76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"
+		p2shVote        = "bba914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d487"
+		otherVote       = "bb76a9140102030405060708090a0b0c0d0e0f101112131488ac"
+		change          = "bd76a914000000000000000000000000000000000000000088ac"
+	)
+	tests := []struct {
+		name        string
+		outputs     []string
+		voteOutputs []string
+		wantErr     bool
+	}{
+		{name: "p2pkh", outputs: []string{p2pkhCommitment, change}, voteOutputs: []string{p2pkhVote}},
+		{name: "p2sh", outputs: []string{p2shCommitment, change}, voteOutputs: []string{p2shVote}},
+		{name: "split ticket", outputs: []string{p2shCommitment, change, otherCommitment, change}, voteOutputs: []string{p2shVote, otherVote}},
+		{name: "invalid second commitment", outputs: []string{p2pkhCommitment, change, change, change}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket := bchain.Tx{
+				Txid:    "ticket",
+				Version: 1,
+				Vin:     []bchain.Vin{{Txid: "funding", ScriptSig: bchain.ScriptSig{Hex: "47304402"}}},
+				Vout:    []bchain.Vout{{ScriptPubKey: bchain.ScriptPubKey{Hex: "ba76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}}},
+			}
+			for _, o := range tt.outputs {
+				ticket.Vout = append(ticket.Vout, bchain.Vout{ScriptPubKey: bchain.ScriptPubKey{Hex: o}})
+			}
+			got, err := testParser.DeriveVotingAddress(&ticket)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("DeriveVotingAddress() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the addresses must be the ones of the reward outputs of the vote spending the ticket
+			var want []string
+			for _, o := range tt.voteOutputs {
+				addrDesc, err := testParser.GetAddrDescFromVout(&bchain.Vout{ScriptPubKey: bchain.ScriptPubKey{Hex: o}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				want = append(want, string(addrDesc))
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("DeriveVotingAddress() = %v, want %v", got, want)
+			}
+		})
+	}
+	regular := bchain.Tx{Txid: "regular", Version: 1, Vout: []bchain.Vout{{ScriptPubKey: bchain.ScriptPubKey{Hex: "76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}}}}
+	if _, err := testParser.DeriveVotingAddress(&regular); err == nil {
+		t.Error("DeriveVotingAddress() expected error for a regular transaction")
+	}
+}
+
+func Test_DecodeVoteBits(t *testing.T) {
+	agendas := []DecredAgenda{
+		{
+			ID:   "treasury",
+			Mask: 0x0006,
+			Choices: []DecredAgendaChoice{
+				{ID: "abstain", Bits: 0x0000, IsAbstain: true},
+				{ID: "no", Bits: 0x0002, IsNo: true},
+				{ID: "yes", Bits: 0x0004},
+			},
+		},
+	}
+	vote := bchain.Tx{
+		Version: 1,
+		Vin:     []bchain.Vin{{}, {Txid: "ticket", ScriptSig: bchain.ScriptSig{Hex: "47304402"}}},
+		Vout: []bchain.Vout{
+			{ScriptPubKey: bchain.ScriptPubKey{Hex: "bb76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}},
+			{ScriptPubKey: bchain.ScriptPubKey{Hex: "6a06050009000000"}},
+		},
+	}
+	voteBits, version, ok := testParser.GetVoteBits(&vote)
+	if !ok || voteBits != 0x0005 || version != 9 {
+		t.Fatalf("GetVoteBits() = %#04x, %d, %v, want 0x0005, 9, true", voteBits, version, ok)
+	}
+	got, err := testParser.DecodeVoteBits(voteBits, agendas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &DecredVoteBitDecoding{
+		VoteBits:       0x0005,
+		ApprovesParent: true,
+		Choices:        []DecredVoteChoice{{AgendaID: "treasury", ChoiceID: "yes"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeVoteBits() = %+v, want %+v", got, want)
+	}
+	if _, err = testParser.DecodeVoteBits(0x0007, agendas); err == nil {
+		t.Error("DecodeVoteBits() did not return error for an invalid choice")
+	}
+}
+
+// testAccountXpub returns the extended public key of the wallet account 0 (m/44'/42'/0') derived from a fixed seed
+func testAccountXpub(t *testing.T, net *dch.Params) (string, *hdkeychain.ExtendedKey) {
+	seed := bytes.Repeat([]byte{0x5a}, 32)
+	master, err := hdkeychain.NewMaster(seed, net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := master
+	for _, i := range []uint32{44, 42, 0} {
+		if account, err = account.Child(hdkeychain.HardenedKeyStart + i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pub, err := account.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pub.String(), pub
+}
+
+func Test_DeriveAddressDescriptorsFromTo(t *testing.T) {
+	xpub, extKey := testAccountXpub(t, &dch.MainNetParams)
+	got, err := testParser.DeriveAddressDescriptorsFromTo(xpub, 1, 3, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	branch, err := extKey.Child(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, ad := range got {
+		child, err := branch.Child(uint32(3 + i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := child.Address(&dch.MainNetParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the descriptor of the derived address must be the descriptor of the output paying to it
+		want, err := testParser.GetAddrDescFromVout(&bchain.Vout{ScriptPubKey: bchain.ScriptPubKey{Hex: "76a914" + hex.EncodeToString(a.Hash160()[:]) + "88ac"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ad, want) {
+			t.Errorf("DeriveAddressDescriptorsFromTo()[%d] = %v, want %v", i, string(ad), string(want))
+		}
+	}
+	byIndexes, err := testParser.DeriveAddressDescriptors(xpub, 1, []uint32{3, 4, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(byIndexes, got) {
+		t.Errorf("DeriveAddressDescriptors() = %v, want %v", byIndexes, got)
+	}
+
+	// the keys of other networks are rejected
+	testnetXpub, _ := testAccountXpub(t, &dch.TestNet3Params)
+	if _, err = testParser.DeriveAddressDescriptorsFromTo(testnetXpub, 0, 0, 1); err == nil {
+		t.Error("DeriveAddressDescriptorsFromTo() expected error for testnet xpub")
+	}
+	if _, err = testParser.DeriveAddressDescriptorsFromTo(xpub, 0, 1, 1); err == nil {
+		t.Error("DeriveAddressDescriptorsFromTo() expected error for empty range")
+	}
+}
+
+func Test_GetAddrDescFromKey(t *testing.T) {
+	// the generator point of secp256k1 in both serializations
+	compressed := "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	uncompressed := "0479be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"
+	tests := []struct {
+		name          string
+		key           string
+		wantErr       bool
+		wantFormatErr bool
+	}{
+		{name: "compressed", key: compressed},
+		{name: "uncompressed", key: uncompressed},
+		{name: "hybrid", key: "06" + uncompressed[2:], wantErr: true, wantFormatErr: true},
+		{name: "truncated", key: compressed[:64], wantErr: true, wantFormatErr: true},
+		{name: "uncompressed prefix of compressed key", key: "04" + compressed[2:], wantErr: true, wantFormatErr: true},
+		{name: "not on curve", key: uncompressed[:128] + "b9", wantErr: true},
+	}
+	descriptors := make(map[string]string)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, _ := hex.DecodeString(tt.key)
+			got, err := testParser.GetAddrDescFromKey(key)
+			if (err != nil) != tt.wantErr || (errors.Cause(err) == ErrUnknownPubKeyFormat) != tt.wantFormatErr {
+				t.Fatalf("GetAddrDescFromKey() error = %v, wantErr %v, wantFormatErr %v", err, tt.wantErr, tt.wantFormatErr)
+			}
+			if err != nil {
+				return
+			}
+			want, err := testParser.GetAddrDescFromVout(&bchain.Vout{ScriptPubKey: bchain.ScriptPubKey{Hex: "76a914" + hex.EncodeToString(dcrutil.Hash160(key)) + "88ac"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("GetAddrDescFromKey() = %v, want %v", string(got), string(want))
+			}
+			descriptors[tt.name] = string(got)
+		})
+	}
+	if descriptors["compressed"] == descriptors["uncompressed"] {
+		t.Error("GetAddrDescFromKey() returned the same address for the compressed and the uncompressed key")
+	}
+}
+
+func testAtomicSwapContract(secretHash []byte, lockTime []byte, lockOp byte) []byte {
+	recipient := bytes.Repeat([]byte{0x11}, 20)
+	refund := bytes.Repeat([]byte{0x22}, 20)
+	c := []byte{0x63, 0x82, 0x01, 0x20, 0x88, 0xa8, 0x20}
+	c = append(c, secretHash...)
+	c = append(c, 0x88, 0x76, 0xa9, 0x14)
+	c = append(c, recipient...)
+	c = append(c, 0x67)
+	c = append(c, lockTime...)
+	c = append(c, lockOp, 0x75, 0x76, 0xa9, 0x14)
+	c = append(c, refund...)
+	return append(c, 0x68, 0x88, 0xac)
+}
+
+func Test_ParseAtomicSwapScript(t *testing.T) {
+	secret := bytes.Repeat([]byte{0x01}, 32)
+	secretHash := sha256.Sum256(secret)
+	recipient, err := testParser.GetAddrDescFromVout(&bchain.Vout{ScriptPubKey: bchain.ScriptPubKey{Hex: "76a914" + strings.Repeat("11", 20) + "88ac"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	refund, err := testParser.GetAddrDescFromVout(&bchain.Vout{ScriptPubKey: bchain.ScriptPubKey{Hex: "76a914" + strings.Repeat("22", 20) + "88ac"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		script []byte
+		want   *DecredAtomicSwap
+		wantOk bool
+	}{
+		{
+			name:   "locktime",
+			script: testAtomicSwapContract(secretHash[:], []byte{0x04, 0x00, 0x10, 0x5e, 0x5f}, 0xb1),
+			want: &DecredAtomicSwap{
+				SecretHash:       secretHash[:],
+				RecipientAddress: string(recipient),
+				RefundAddress:    string(refund),
+				LockTime:         1600000000,
+			},
+			wantOk: true,
+		},
+		{
+			name:   "relative locktime",
+			script: testAtomicSwapContract(secretHash[:], []byte{0x5c}, 0xb2),
+			want: &DecredAtomicSwap{
+				SecretHash:       secretHash[:],
+				RecipientAddress: string(recipient),
+				RefundAddress:    string(refund),
+				LockTime:         12,
+				RelativeLockTime: true,
+			},
+			wantOk: true,
+		},
+		{
+			name:   "negative locktime",
+			script: testAtomicSwapContract(secretHash[:], []byte{0x01, 0x81}, 0xb1),
+		},
+		{
+			name:   "other opcode",
+			script: testAtomicSwapContract(secretHash[:], []byte{0x5c}, 0xac),
+		},
+		{
+			name:   "trailing data",
+			script: append(testAtomicSwapContract(secretHash[:], []byte{0x5c}, 0xb1), 0x75),
+		},
+		{
+			name:   "p2pkh",
+			script: []byte{0x76, 0xa9, 0x14},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := testParser.ParseAtomicSwapScript(tt.script)
+			if ok != tt.wantOk || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAtomicSwapScript() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_GetAtomicSwapSpend(t *testing.T) {
+	secret := bytes.Repeat([]byte{0x01}, 32)
+	secretHash := sha256.Sum256(secret)
+	contract := testAtomicSwapContract(secretHash[:], []byte{0x5c}, 0xb1)
+	sigPushes := "0102" + "0103"
+	// the contract is longer than 75 bytes, it is pushed by OP_PUSHDATA1
+	contractPush := "4c" + hex.EncodeToString([]byte{byte(len(contract))}) + hex.EncodeToString(contract)
+	tx := bchain.Tx{
+		Vin: []bchain.Vin{
+			{Txid: "redeemed", ScriptSig: bchain.ScriptSig{Hex: sigPushes + "20" + hex.EncodeToString(secret) + "51" + contractPush}},
+			{Txid: "refunded", ScriptSig: bchain.ScriptSig{Hex: sigPushes + "00" + contractPush}},
+			{Txid: "wrongsecret", ScriptSig: bchain.ScriptSig{Hex: sigPushes + "20" + strings.Repeat("02", 32) + "51" + contractPush}},
+			{Txid: "p2pkh", ScriptSig: bchain.ScriptSig{Hex: sigPushes}},
+		},
+	}
+	if c, s, ok := testParser.GetAtomicSwapSpend(&tx, 0); !ok || !bytes.Equal(c, contract) || !bytes.Equal(s, secret) {
+		t.Errorf("GetAtomicSwapSpend(redeemed) = %x, %x, %v", c, s, ok)
+	}
+	if c, s, ok := testParser.GetAtomicSwapSpend(&tx, 1); !ok || !bytes.Equal(c, contract) || s != nil {
+		t.Errorf("GetAtomicSwapSpend(refunded) = %x, %x, %v", c, s, ok)
+	}
+	for i := 2; i < len(tx.Vin); i++ {
+		if _, _, ok := testParser.GetAtomicSwapSpend(&tx, i); ok {
+			t.Errorf("GetAtomicSwapSpend(%v) recognized a spend", tx.Vin[i].Txid)
+		}
+	}
+}
+
+func Test_GetSubsidySchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   int64
+		count   int
+		want    []DecredSubsidyPoint
+		wantErr bool
+	}{
+		{
+			name:  "genesis",
+			start: 0,
+			count: 2,
+			want: []DecredSubsidyPoint{
+				{Height: 0},
+				{Height: 6144, TotalSubsidy: 3088695703, PoWSubsidy: 1853217423, PoSSubsidy: 926608710, TreasurySubsidy: 308869570},
+			},
+		},
+		{
+			name:  "block one",
+			start: 1,
+			count: 1,
+			want: []DecredSubsidyPoint{
+				{Height: 1, TotalSubsidy: dch.MainNetParams.BlockOneSubsidy(), PoWSubsidy: dch.MainNetParams.BlockOneSubsidy()},
+			},
+		},
+		{
+			name:  "stake validation",
+			start: 4095,
+			count: 2,
+			want: []DecredSubsidyPoint{
+				{Height: 4095, TotalSubsidy: 2183707864, PoWSubsidy: 1871749598, TreasurySubsidy: 311958266},
+				{Height: 10239, TotalSubsidy: 3088695703, PoWSubsidy: 1853217423, PoSSubsidy: 926608710, TreasurySubsidy: 308869570},
+			},
+		},
+		{
+			name:  "end of subsidy",
+			start: MaxSubsidyHeight,
+			count: 5,
+			want: []DecredSubsidyPoint{
+				{Height: MaxSubsidyHeight},
+			},
+		},
+		{
+			name:    "negative height",
+			start:   -1,
+			count:   1,
+			wantErr: true,
+		},
+		{
+			name:    "height over limit",
+			start:   MaxSubsidyHeight + 1,
+			count:   1,
+			wantErr: true,
+		},
+		{
+			name:    "too many points",
+			start:   0,
+			count:   maxSubsidyPoints + 1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testParser.GetSubsidySchedule(tt.start, tt.count)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetSubsidySchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetSubsidySchedule() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_GetTxTree(t *testing.T) {
+	// the vote spends the ticket output of the stake tree, the stakebase input does not spend any output
+	voteJSON := json.RawMessage(`{
+		"txid": "vote",
+		"version": 1,
+		"vin": [
+			{"stakebase": "0000", "tree": 0, "sequence": 4294967295, "amountin": 0.2},
+			{"txid": "ticket", "vout": 0, "tree": 1, "sequence": 4294967295, "amountin": 150, "scriptsig": {"hex": "47304402"}}
+		],
+		"vout": [
+			{"value": 0, "n": 0, "version": 0, "scriptPubKey": {"hex": "6a24` + strings.Repeat("00", 36) + `"}},
+			{"value": 0, "n": 1, "version": 0, "scriptPubKey": {"hex": "6a0601000a000000"}},
+			{"value": 150.2, "n": 2, "version": 0, "scriptPubKey": {"hex": "bb76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}}
+		]
+	}`)
+	vote, err := testParser.ParseTxFromJson(voteJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vote.Vin[0].Tree != txTreeRegular || vote.Vin[1].Tree != txTreeStake {
+		t.Errorf("ParseTxFromJson() input trees %d, %d, want %d, %d", vote.Vin[0].Tree, vote.Vin[1].Tree, txTreeRegular, txTreeStake)
+	}
+	if got := testParser.GetTxType(vote); got != TxTypeVote {
+		t.Errorf("GetTxType(vote) = %v, want %v", got, TxTypeVote)
+	}
+	ticket := &bchain.Tx{
+		Vin:  []bchain.Vin{{Txid: "funding", ScriptSig: bchain.ScriptSig{Hex: "47304402"}}},
+		Vout: []bchain.Vout{{ScriptPubKey: bchain.ScriptPubKey{Hex: "ba76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}}},
+	}
+	regular := &bchain.Tx{
+		Vin:  []bchain.Vin{{Txid: "funding", ScriptSig: bchain.ScriptSig{Hex: "47304402"}}},
+		Vout: []bchain.Vout{{ScriptPubKey: bchain.ScriptPubKey{Hex: "76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}}},
+	}
+	treasuryAdd := &bchain.Tx{
+		Vin:  []bchain.Vin{{Txid: "funding", ScriptSig: bchain.ScriptSig{Hex: "47304402"}}},
+		Vout: []bchain.Vout{{ScriptPubKey: bchain.ScriptPubKey{Hex: "c1"}}},
+	}
+	for _, tt := range []struct {
+		name string
+		tx   *bchain.Tx
+		want int8
+	}{
+		{"ticket", ticket, txTreeStake},
+		{"vote", vote, txTreeStake},
+		{"treasury add", treasuryAdd, txTreeStake},
+		{"regular", regular, txTreeRegular},
+	} {
+		if got := testParser.GetTxTree(tt.tx); got != tt.want {
+			t.Errorf("GetTxTree(%v) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package dcr
+
+import (
+	"blockbook/bchain"
+	"container/list"
+	"encoding/hex"
+	"math/big"
+	"strconv"
+	"sync"
+
+	"github.com/juju/errors"
+)
+
+// defaultPrevOutCacheSize is the default number of the outputs kept in the cache of the previous outputs
+const defaultPrevOutCacheSize = 100000
+
+// DecredPrevOut is the output spent by a transaction input
+type DecredPrevOut struct {
+	ValueSat     big.Int
+	ScriptPubKey []byte
+	Version      uint16
+}
+
+// prevOutCacheStats contains the hits and misses of the cache of the previous outputs
+type prevOutCacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+type prevOutEntry struct {
+	txid    string
+	key     string
+	prevOut *DecredPrevOut
+}
+
+// prevOutCache is the LRU cache of the outputs keyed by txid:vout, the keys of the cached outputs
+// of each transaction are kept to remove the transactions of the disconnected blocks
+type prevOutCache struct {
+	lock    sync.Mutex
+	maxSize int
+	lru     *list.List
+	entries map[string]*list.Element
+	txKeys  map[string][]string
+	stats   prevOutCacheStats
+}
+
+// newPrevOutCache returns the cache of the given size, size 0 means the default size,
+// negative size disables the cache (nil is returned)
+func newPrevOutCache(size int) *prevOutCache {
+	if size < 0 {
+		return nil
+	}
+	if size == 0 {
+		size = defaultPrevOutCacheSize
+	}
+	return &prevOutCache{
+		maxSize: size,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		txKeys:  make(map[string][]string),
+	}
+}
+
+func prevOutKey(txid string, vout uint32) string {
+	return txid + ":" + strconv.FormatUint(uint64(vout), 10)
+}
+
+func (c *prevOutCache) get(txid string, vout uint32) *DecredPrevOut {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, found := c.entries[prevOutKey(txid, vout)]; found {
+		c.stats.Hits++
+		c.lru.MoveToFront(e)
+		return e.Value.(*prevOutEntry).prevOut
+	}
+	c.stats.Misses++
+	return nil
+}
+
+// addTx stores all the outputs of the fetched transaction, they are likely to be spent together
+func (c *prevOutCache) addTx(tx *bchain.Tx) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for i := range tx.Vout {
+		p, err := prevOut(tx, tx.Vout[i].N)
+		if err != nil {
+			continue
+		}
+		key := prevOutKey(tx.Txid, tx.Vout[i].N)
+		if e, found := c.entries[key]; found {
+			e.Value.(*prevOutEntry).prevOut = p
+			c.lru.MoveToFront(e)
+			continue
+		}
+		c.entries[key] = c.lru.PushFront(&prevOutEntry{txid: tx.Txid, key: key, prevOut: p})
+		c.txKeys[tx.Txid] = append(c.txKeys[tx.Txid], key)
+	}
+	for c.lru.Len() > c.maxSize {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *prevOutCache) remove(e *list.Element) {
+	pe := c.lru.Remove(e).(*prevOutEntry)
+	delete(c.entries, pe.key)
+	keys := c.txKeys[pe.txid]
+	for i := range keys {
+		if keys[i] == pe.key {
+			keys = append(keys[:i], keys[i+1:]...)
+			break
+		}
+	}
+	if len(keys) == 0 {
+		delete(c.txKeys, pe.txid)
+	} else {
+		c.txKeys[pe.txid] = keys
+	}
+}
+
+// removeTxs removes the outputs of the transactions from the cache
+func (c *prevOutCache) removeTxs(txids []string) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, txid := range txids {
+		for _, key := range c.txKeys[txid] {
+			if e, found := c.entries[key]; found {
+				c.lru.Remove(e)
+				delete(c.entries, key)
+			}
+		}
+		delete(c.txKeys, txid)
+	}
+}
+
+func (c *prevOutCache) getStats() prevOutCacheStats {
+	if c == nil {
+		return prevOutCacheStats{}
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.stats
+}
+
+// GetPrevOut returns the output spent by the input txid:vout, the transaction is fetched from dcrd
+// only if the output is not in the cache, all its outputs are cached then
+func (d *DecredRPC) GetPrevOut(txid string, vout uint32) (*DecredPrevOut, error) {
+	if p := d.prevOuts.get(txid, vout); p != nil {
+		return p, nil
+	}
+	tx, err := d.GetTransaction(txid)
+	if err != nil {
+		return nil, err
+	}
+	d.prevOuts.addTx(tx)
+	return prevOut(tx, vout)
+}
+
+// RemoveDisconnectedTxs removes the outputs of the transactions of the disconnected blocks from the cache
+// of the previous outputs, the transactions may not be in the chain any more
+func (d *DecredRPC) RemoveDisconnectedTxs(txids []string) {
+	d.prevOuts.removeTxs(txids)
+}
+
+func prevOut(tx *bchain.Tx, vout uint32) (*DecredPrevOut, error) {
+	for i := range tx.Vout {
+		if tx.Vout[i].N == vout {
+			script, err := hex.DecodeString(tx.Vout[i].ScriptPubKey.Hex)
+			if err != nil {
+				return nil, errors.Annotatef(err, "txid %v vout %v", tx.Txid, vout)
+			}
+			return &DecredPrevOut{ValueSat: tx.Vout[i].ValueSat, ScriptPubKey: script, Version: tx.Vout[i].Version}, nil
+		}
+	}
+	return nil, errors.Errorf("Output %v of transaction %v not found", vout, tx.Txid)
+}
+
+// ResolveInputs returns the outputs spent by the inputs of the transaction, the entry is nil
+// for the inputs not spending an output (coinbase, stakebase and treasury spend)
+func (d *DecredRPC) ResolveInputs(tx *bchain.Tx) ([]*DecredPrevOut, error) {
+	r := make([]*DecredPrevOut, len(tx.Vin))
+	for i := range tx.Vin {
+		vin := &tx.Vin[i]
+		if vin.Coinbase != "" || vin.Txid == "" {
+			continue
+		}
+		p, err := d.GetPrevOut(vin.Txid, vin.Vout)
+		if err != nil {
+			return nil, err
+		}
+		r[i] = p
+	}
+	return r, nil
+}
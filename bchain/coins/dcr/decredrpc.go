@@ -2,7 +2,10 @@ package dcr
 
 import (
 	"blockbook/bchain"
+	"blockbook/common"
 	"bytes"
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,22 +13,116 @@ import (
 	"math/big"
 	"net"
 	"net/http"
+	"reflect"
 	"runtime/debug"
+	"sort"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"blockbook/bchain/coins/btc"
 
+	"github.com/decred/dcrd/dcrutil"
 	"github.com/golang/glog"
 	"github.com/juju/errors"
 )
 
+// DecredRPC is an interface to JSON-RPC dcrd service.
 type DecredRPC struct {
 	*btc.BitcoinRPC
-	client      http.Client
-	rpcURL      string
-	rpcUser     string
-	rpcPassword string
+	// clients is the pool of the http clients with their own connections, the calls are distributed round-robin
+	clients        []*http.Client
+	nextClient     uint32
+	rpcURL         string
+	rpcUser        string
+	rpcPassword    string
+	config         *Configuration
+	pushHandler    func(bchain.NotificationType)
+	ws             *wsNotifier
+	metrics        *common.Metrics
+	networkStats   networkStatsCache
+	blockSizeStats blockSizeStatsCache
+	participation  participationCache
+	voteAgendas    voteAgendasCache
+	prevOuts       *prevOutCache
+	work           workSubscriptions
+	lastBlock      lastBlockTime
+	conns          *connStats
+}
+
+// Configuration represents json config file
+type Configuration struct {
+	btc.Configuration
+	// dcrd does not support ZeroMQ, notifications are received over websocket
+	WSURL string `json:"ws_url,omitempty"`
+	// WSCert is the certificate of dcrd (rpc.cert) verifying the wss connection, the system roots are used if empty
+	WSCert string `json:"ws_cert,omitempty"`
+	// WSInsecureSkipVerify disables the verification of the certificate of dcrd, intended for test environments
+	WSInsecureSkipVerify  bool `json:"ws_insecure_skip_verify,omitempty"`
+	NotifyNewTransactions bool `json:"notify_new_transactions,omitempty"`
+	// NotifyWork subscribes to the work notifications of dcrd, which requires the mining addresses in dcrd
+	NotifyWork bool `json:"notify_work,omitempty"`
+	// RPCTimeouts overrides rpc_timeout (in seconds) for the specified rpc methods
+	RPCTimeouts map[string]int `json:"rpc_timeouts,omitempty"`
+	// WSHandshakeTimeout is the timeout of the websocket handshake in seconds
+	WSHandshakeTimeout int `json:"ws_handshake_timeout,omitempty"`
+	// AllowAdminCalls enables the rpc calls changing the state of dcrd, intended for test environments
+	AllowAdminCalls bool `json:"allow_admin_calls,omitempty"`
+	// MaxResponseSize limits the size of the rpc response in bytes
+	MaxResponseSize int64 `json:"max_response_size,omitempty"`
+	// BackendType is "dcrd" (default) or "dcrwallet"
+	BackendType string `json:"backend_type,omitempty"`
+	// PrevOutCacheSize is the number of the outputs kept in the cache of the previous outputs,
+	// 0 means the default size, negative value disables the cache
+	PrevOutCacheSize int `json:"prev_out_cache_size,omitempty"`
+	// VerifyChainDepth is the number of the indexed blocks from the tip compared with dcrd on startup, 0 disables the verification
+	VerifyChainDepth int `json:"verify_chain_depth,omitempty"`
+	// RPCConnections is the number of the http clients with their own connections to dcrd, 0 means 1
+	RPCConnections int `json:"rpc_connections,omitempty"`
+	// FetchWorkers is the number of the blocks fetched concurrently in the regular sync, 0 or 1 means sequential fetching
+	FetchWorkers int `json:"fetch_workers,omitempty"`
+	// BlockPrefetchDepth is the number of the block hashes resolved ahead of the initial sync, 0 disables the prefetching
+	BlockPrefetchDepth int `json:"block_prefetch_depth,omitempty"`
+	// EnableTestnetFaucet enables the faucet funding the addresses on testnet and simnet, intended for development
+	EnableTestnetFaucet bool `json:"enable_testnet_faucet,omitempty"`
+	// TestnetFaucetURL is the external faucet used on testnet, simnet blocks are mined by dcrd
+	TestnetFaucetURL string `json:"testnet_faucet_url,omitempty"`
+	// ExplorerTxURL is the template of the link to the transaction in an external explorer, {txid} is replaced
+	// by the txid, the dcrdata explorer of the network is used if empty
+	ExplorerTxURL string `json:"explorer_tx_url,omitempty"`
+	// EnableWalletAPI exposes the accounts of the dcrwallet backend in the public api
+	EnableWalletAPI bool `json:"enable_wallet_api,omitempty"`
+}
+
+// defaultMaxResponseSize is comfortably above any valid dcrd response
+const defaultMaxResponseSize = 64 << 20
+
+// newHTTPClients returns the pool of n http clients, each client has its own transport and connections,
+// the connections are counted in conns
+func newHTTPClients(n int, conns *connStats) []*http.Client {
+	if n <= 0 {
+		n = 1
+	}
+	clients := make([]*http.Client, n)
+	for i := range clients {
+		transport := &http.Transport{
+			Dial:                conns.dial(&net.Dialer{KeepAlive: 600 * time.Second}),
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 100, // necessary to not to deplete ports
+		}
+		clients[i] = &http.Client{Transport: transport} // timeout is set for each request in Call
+	}
+	return clients
+}
+
+// httpClient returns the next client of the pool
+func (d *DecredRPC) httpClient() *http.Client {
+	if len(d.clients) == 0 {
+		return http.DefaultClient
+	}
+	n := atomic.AddUint32(&d.nextClient, 1)
+	return d.clients[n%uint32(len(d.clients))]
 }
 
 // NewDecredRPC returns new DecredRPC instance.
@@ -35,32 +132,80 @@ func NewDecredRPC(config json.RawMessage, pushHandler func(bchain.NotificationTy
 		return nil, err
 	}
 
-	var c btc.Configuration
+	var c Configuration
 	err = json.Unmarshal(config, &c)
 	if err != nil {
 		return nil, errors.Annotate(err, "Invalid configuration file")
 	}
-
-	transport := &http.Transport{
-		Dial:                (&net.Dialer{KeepAlive: 600 * time.Second}).Dial,
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 100, // necessary to not to deplete ports
+	if c.MaxResponseSize <= 0 {
+		c.MaxResponseSize = defaultMaxResponseSize
 	}
 
+	conns := &connStats{}
 	d := &DecredRPC{
 		BitcoinRPC:  b.(*btc.BitcoinRPC),
-		client:      http.Client{Timeout: time.Duration(c.RPCTimeout) * time.Second, Transport: transport},
+		clients:     newHTTPClients(c.RPCConnections, conns),
 		rpcURL:      c.RPCURL,
 		rpcUser:     c.RPCUser,
 		rpcPassword: c.RPCPass,
+		config:      &c,
+		pushHandler: pushHandler,
+		prevOuts:    newPrevOutCache(c.PrevOutCacheSize),
+		conns:       conns,
 	}
 
 	d.BitcoinRPC.RPCMarshaler = btc.JSONMarshalerV1{}
 	d.BitcoinRPC.ChainConfig.SupportsEstimateSmartFee = false
 
+	switch c.BackendType {
+	case "", "dcrd":
+	case BackendTypeWallet:
+		glog.Info("rpc: using dcrwallet backend")
+		return &DecredWalletRPC{DecredRPC: d}, nil
+	default:
+		return nil, errors.Errorf("Unsupported backend type %v", c.BackendType)
+	}
+
 	return d, nil
 }
 
+// InitializeMempool sets the mempool callbacks and subscribes to dcrd websocket notifications
+func (d *DecredRPC) InitializeMempool(addrDescForOutpoint bchain.AddrDescForOutpointFunc, onNewTxAddr bchain.OnNewTxAddrFunc) error {
+	if d.Mempool == nil {
+		return errors.New("Mempool not created")
+	}
+	d.Mempool.AddrDescForOutpoint = addrDescForOutpoint
+	d.Mempool.OnNewTxAddr = onNewTxAddr
+	if d.ws == nil {
+		ws, err := newWSNotifier(d.config, d.pushHandler, d.onWork)
+		if err != nil {
+			return err
+		}
+		if err := ws.connect(); err != nil {
+			glog.Error("rpc: websocket ", err)
+			return err
+		}
+		d.ws = ws
+		go ws.run()
+	}
+	return nil
+}
+
+// Shutdown closes the websocket notifications and other resources
+func (d *DecredRPC) Shutdown(ctx context.Context) error {
+	if d.ws != nil {
+		if err := d.ws.close(); err != nil {
+			glog.Error("websocket close error: ", err)
+		}
+	}
+	return d.BitcoinRPC.Shutdown(ctx)
+}
+
+// SetMetrics sets the collectors of the dcrd rpc metrics
+func (d *DecredRPC) SetMetrics(metrics *common.Metrics) {
+	d.metrics = metrics
+}
+
 // Initialize initializes DecredRPC instance.
 func (d *DecredRPC) Initialize() error {
 	chainInfo, err := d.GetChainInfo()
@@ -71,7 +216,12 @@ func (d *DecredRPC) Initialize() error {
 	chainName := chainInfo.Chain
 	glog.Info("Chain name ", chainName)
 
-	params := GetChainParams(chainName)
+	glog.Info("rpc: dcrd protocol version ", chainInfo.ProtocolVersion)
+
+	params, err := d.nodeChainParams(chainName)
+	if err != nil {
+		return err
+	}
 
 	// always create parser
 	d.BitcoinRPC.Parser = NewDecredParser(params, d.BitcoinRPC.ChainConfig)
@@ -95,6 +245,14 @@ type Error struct {
 	Message string `json:"message"`
 }
 
+// dcrd rpc error codes
+const (
+	// rpcErrTxNotFound is returned for unknown transactions, blocks and addresses
+	rpcErrTxNotFound = -5
+	// rpcErrMethodNotFound is the JSON-RPC error of methods not implemented by the dcrd version
+	rpcErrMethodNotFound = -32601
+)
+
 type GenericCmd struct {
 	ID     int           `json:"id"`
 	Method string        `json:"method"`
@@ -104,19 +262,63 @@ type GenericCmd struct {
 type GetBlockChainInfoResult struct {
 	Error  Error `json:"error"`
 	Result struct {
-		Chain                string  `json:"chain"`
-		Blocks               int64   `json:"blocks"`
-		Headers              int64   `json:"headers"`
-		SyncHeight           int64   `json:"syncheight"`
-		BestBlockHash        string  `json:"bestblockhash"`
-		Difficulty           uint32  `json:"difficulty"`
-		VerificationProgress float64 `json:"verificationprogress"`
-		ChainWork            string  `json:"chainwork"`
-		InitialBlockDownload bool    `json:"initialblockdownload"`
-		MaxBlockSize         int64   `json:"maxblocksize"`
+		Chain                string        `json:"chain"`
+		Blocks               int64         `json:"blocks"`
+		Headers              int64         `json:"headers"`
+		SyncHeight           int64         `json:"syncheight"`
+		BestBlockHash        string        `json:"bestblockhash"`
+		Difficulty           uint32        `json:"difficulty"`
+		VerificationProgress float64       `json:"verificationprogress"`
+		ChainWork            string        `json:"chainwork"`
+		InitialBlockDownload bool          `json:"initialblockdownload"`
+		MaxBlockSize         int64         `json:"maxblocksize"`
+		Agendas              DecredAgendas `json:"deployments"`
 	} `json:"result"`
 }
 
+// DecredAgenda is the status of the consensus rule change (DCP) voted by the stakeholders,
+// the status is one of defined, started, lockedin, active or failed.
+// The mask of the vote bits and the choices are returned only by getvoteinfo
+type DecredAgenda struct {
+	ID          string               `json:"id"`
+	Description string               `json:"description,omitempty"`
+	Status      string               `json:"status"`
+	Since       int64                `json:"since,omitempty"`
+	StartTime   uint64               `json:"starttime"`
+	ExpireTime  uint64               `json:"expiretime"`
+	Mask        uint16               `json:"mask,omitempty"`
+	Choices     []DecredAgendaChoice `json:"choices,omitempty"`
+}
+
+// DecredAgendaChoice is the choice of the agenda encoded by the bits of the vote bits
+type DecredAgendaChoice struct {
+	ID        string `json:"id"`
+	Bits      uint16 `json:"bits"`
+	IsAbstain bool   `json:"isabstain"`
+	IsNo      bool   `json:"isno"`
+	// Count is the number of the votes for the choice in the current rule change interval, reported by getvoteinfo
+	Count uint32 `json:"count,omitempty"`
+}
+
+// DecredAgendas is the list of agendas sorted by id, decoded from the deployments map of getblockchaininfo
+type DecredAgendas []DecredAgenda
+
+// UnmarshalJSON decodes the map of the agendas keyed by the agenda id
+func (a *DecredAgendas) UnmarshalJSON(data []byte) error {
+	var m map[string]DecredAgenda
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	r := make(DecredAgendas, 0, len(m))
+	for id, agenda := range m {
+		agenda.ID = id
+		r = append(r, agenda)
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].ID < r[j].ID })
+	*a = r
+	return nil
+}
+
 type GetNetworkInfoResult struct {
 	Error  Error `json:"error"`
 	Result struct {
@@ -152,6 +354,16 @@ type GetBestBlockResult struct {
 	} `json:"result"`
 }
 
+type GetBestBlockHashResult struct {
+	Error  Error  `json:"error"`
+	Result string `json:"result"`
+}
+
+type GetBlockCountResult struct {
+	Error  Error `json:"error"`
+	Result int64 `json:"result"`
+}
+
 type GetBlockHashResult struct {
 	Error  Error  `json:"error"`
 	Result string `json:"result"`
@@ -168,6 +380,7 @@ type GetBlockResult struct {
 		MerkleRoot    string      `json:"merkleroot"`
 		StakeRoot     string      `json:"stakeroot"`
 		RawTx         []RawTx     `json:"rawtx"`
+		RawSTx        []RawTx     `json:"rawstx"`
 		Tx            []string    `json:"tx,omitempty"`
 		STx           []string    `json:"stx,omitempty"`
 		Time          int64       `json:"time"`
@@ -224,16 +437,17 @@ type ScriptSig struct {
 }
 
 type Vin struct {
-	Coinbase    string     `json:"coinbase"`
-	Stakebase   string     `json:"stakebase"`
-	Txid        string     `json:"txid"`
-	Vout        uint32     `json:"vout"`
-	Tree        int8       `json:"tree"`
-	Sequence    uint32     `json:"sequence"`
-	AmountIn    float64    `json:"amountin"`
-	BlockHeight uint32     `json:"blockheight"`
-	BlockIndex  uint32     `json:"blockindex"`
-	ScriptSig   *ScriptSig `json:"scriptsig"`
+	Coinbase      string     `json:"coinbase"`
+	Stakebase     string     `json:"stakebase"`
+	TreasurySpend string     `json:"treasuryspend"`
+	Txid          string     `json:"txid"`
+	Vout          uint32     `json:"vout"`
+	Tree          int8       `json:"tree"`
+	Sequence      uint32     `json:"sequence"`
+	AmountIn      float64    `json:"amountin"`
+	BlockHeight   uint32     `json:"blockheight"`
+	BlockIndex    uint32     `json:"blockindex"`
+	ScriptSig     *ScriptSig `json:"scriptsig"`
 }
 
 type ScriptPubKeyResult struct {
@@ -252,6 +466,11 @@ type Vout struct {
 	ScriptPubKey ScriptPubKeyResult `json:"scriptPubKey"`
 }
 
+// Disassembly returns the human readable form of the output script
+func (v *Vout) Disassembly() string {
+	return disassembleScript(v.ScriptPubKey.Hex)
+}
+
 type RawTx struct {
 	Hex           string `json:"hex"`
 	Txid          string `json:"txid"`
@@ -278,9 +497,9 @@ type GetTransactionResult struct {
 type EstimateSmartFeeResult struct {
 	Error  Error `json:"error"`
 	Result struct {
-		FeeRate float64  `json:"feerate"`
-		Errors  []string `json:"errors"`
-		Blocks  int64    `json:"blocks"`
+		FeeRate json.Number `json:"feerate"`
+		Errors  []string    `json:"errors"`
+		Blocks  int64       `json:"blocks"`
 	} `json:"result"`
 }
 
@@ -304,7 +523,164 @@ type DecodeRawTransactionResult struct {
 	} `json:"result"`
 }
 
+// DecredPeerInfo contains data about a peer connected to dcrd
+type DecredPeerInfo struct {
+	ID             int32   `json:"id"`
+	Addr           string  `json:"addr"`
+	AddrLocal      string  `json:"addrlocal,omitempty"`
+	Services       string  `json:"services"`
+	RelayTxes      bool    `json:"relaytxes"`
+	LastSend       int64   `json:"lastsend"`
+	LastRecv       int64   `json:"lastrecv"`
+	BytesSent      uint64  `json:"bytessent"`
+	BytesRecv      uint64  `json:"bytesrecv"`
+	ConnTime       int64   `json:"conntime"`
+	TimeOffset     int64   `json:"timeoffset"`
+	PingTime       float64 `json:"pingtime"`
+	PingWait       float64 `json:"pingwait,omitempty"`
+	Version        uint32  `json:"version"`
+	SubVer         string  `json:"subver"`
+	Inbound        bool    `json:"inbound"`
+	StartingHeight int64   `json:"startingheight"`
+	CurrentHeight  int64   `json:"currentheight,omitempty"`
+	BanScore       int32   `json:"banscore"`
+	SyncNode       bool    `json:"syncnode"`
+}
+
+type GetPeerInfoResult struct {
+	Error  Error            `json:"error"`
+	Result []DecredPeerInfo `json:"result"`
+}
+
 func (d *DecredRPC) GetChainInfo() (*bchain.ChainInfo, error) {
+	ni, err := d.GetNodeInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	chainInfo := &bchain.ChainInfo{
+		Chain:           ni.Chain,
+		Blocks:          int(ni.Blocks),
+		Headers:         int(ni.Headers),
+		Bestblockhash:   ni.BestBlockHash,
+		Difficulty:      strconv.Itoa(int(ni.Difficulty)),
+		SizeOnDisk:      ni.SyncHeight,
+		Version:         strconv.Itoa(int(ni.Version)),
+		Subversion:      "",
+		ProtocolVersion: strconv.Itoa(int(ni.ProtocolVersion)),
+		Timeoffset:      float64(ni.TimeOffset),
+		Warnings:        "",
+		SyncProgress:    ni.VerificationProgress,
+	}
+	return chainInfo, nil
+}
+
+// nodeSyncPollInterval is the period of the checks of the dcrd sync progress by WaitForNodeSync
+var nodeSyncPollInterval = 30 * time.Second
+
+// WaitForNodeSync polls the verification progress of dcrd until it reaches progressThreshold
+// or until ctx is cancelled, in which case the error of ctx is returned. The failed calls are logged
+// and repeated, dcrd may not accept the calls until it loads the chain.
+func (d *DecredRPC) WaitForNodeSync(ctx context.Context, progressThreshold float64) error {
+	ticker := time.NewTicker(nodeSyncPollInterval)
+	defer ticker.Stop()
+	for {
+		ci, err := d.GetChainInfo()
+		if err != nil {
+			glog.Warning("WaitForNodeSync: ", err)
+		} else if ci.SyncProgress >= progressThreshold {
+			return nil
+		} else {
+			glog.Infof("WaitForNodeSync: dcrd sync progress %.4f, block %d of %d headers", ci.SyncProgress, ci.Blocks, ci.Headers)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// DecredNodeInfo merges the getblockchaininfo and getnetworkinfo responses of dcrd
+type DecredNodeInfo struct {
+	Chain                string        `json:"chain"`
+	Blocks               int64         `json:"blocks"`
+	Headers              int64         `json:"headers"`
+	SyncHeight           int64         `json:"syncHeight"`
+	BestBlockHash        string        `json:"bestBlockHash"`
+	Difficulty           uint32        `json:"difficulty"`
+	VerificationProgress float64       `json:"verificationProgress"`
+	InitialBlockDownload bool          `json:"initialBlockDownload"`
+	Version              int32         `json:"version"`
+	ProtocolVersion      int32         `json:"protocolVersion"`
+	TimeOffset           int64         `json:"timeOffset"`
+	Connections          int32         `json:"connections"`
+	RelayFee             float64       `json:"relayFee"`
+	Agendas              DecredAgendas `json:"agendas,omitempty"`
+}
+
+// GetNodeInfo returns the blockchain and network info of dcrd, both calls are made in parallel
+func (d *DecredRPC) GetNodeInfo() (*DecredNodeInfo, error) {
+	var (
+		wg                sync.WaitGroup
+		blockchainInfo    *GetBlockChainInfoResult
+		networkInfo       *GetNetworkInfoResult
+		blockchainInfoErr error
+		networkInfoErr    error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		blockchainInfo, blockchainInfoErr = d.GetDecredBlockchainInfo()
+	}()
+	go func() {
+		defer wg.Done()
+		networkInfo, networkInfoErr = d.getNetworkInfo()
+	}()
+	wg.Wait()
+	if blockchainInfoErr != nil {
+		return nil, blockchainInfoErr
+	}
+	if networkInfoErr != nil {
+		return nil, networkInfoErr
+	}
+
+	return &DecredNodeInfo{
+		Chain:                blockchainInfo.Result.Chain,
+		Blocks:               blockchainInfo.Result.Blocks,
+		Headers:              blockchainInfo.Result.Headers,
+		SyncHeight:           blockchainInfo.Result.SyncHeight,
+		BestBlockHash:        blockchainInfo.Result.BestBlockHash,
+		Difficulty:           blockchainInfo.Result.Difficulty,
+		VerificationProgress: blockchainInfo.Result.VerificationProgress,
+		InitialBlockDownload: blockchainInfo.Result.InitialBlockDownload,
+		Version:              networkInfo.Result.Version,
+		ProtocolVersion:      networkInfo.Result.ProtocolVersion,
+		TimeOffset:           networkInfo.Result.TimeOffset,
+		Connections:          networkInfo.Result.Connections,
+		RelayFee:             networkInfo.Result.RelayFee,
+		Agendas:              blockchainInfo.Result.Agendas,
+	}, nil
+}
+
+func (d *DecredRPC) getNetworkInfo() (*GetNetworkInfoResult, error) {
+	networkInfoRequest := GenericCmd{
+		ID:     1,
+		Method: "getnetworkinfo",
+	}
+	networkInfoResult := GetNetworkInfoResult{}
+	err := d.Call(networkInfoRequest, &networkInfoResult)
+	if err != nil {
+		return nil, err
+	}
+	if networkInfoResult.Error.Message != "" {
+		return nil, errors.Annotate(newRPCError(networkInfoRequest.Method, networkInfoResult.Error), "Error fetching network info")
+	}
+	return &networkInfoResult, nil
+}
+
+// GetDecredBlockchainInfo returns the getblockchaininfo response with all the Decred specific fields
+func (d *DecredRPC) GetDecredBlockchainInfo() (*GetBlockChainInfoResult, error) {
 	blockchainInfoRequest := GenericCmd{
 		ID:     1,
 		Method: "getblockchaininfo",
@@ -315,36 +691,123 @@ func (d *DecredRPC) GetChainInfo() (*bchain.ChainInfo, error) {
 		return nil, err
 	}
 	if blockchainInfoResult.Error.Message != "" {
-		return nil, fmt.Errorf("Error fetching blockchain info: %s", blockchainInfoResult.Error.Message)
+		return nil, errors.Annotate(newRPCError(blockchainInfoRequest.Method, blockchainInfoResult.Error), "Error fetching blockchain info")
 	}
+	return &blockchainInfoResult, nil
+}
 
-	infoChainRequest := GenericCmd{
-		ID:     2,
-		Method: "getinfo",
+// GetPeerInfo returns the list of peers connected to dcrd
+func (d *DecredRPC) GetPeerInfo() ([]DecredPeerInfo, error) {
+	peerInfoRequest := GenericCmd{
+		ID:     1,
+		Method: "getpeerinfo",
 	}
-	infoChainResult := &GetInfoChainResult{}
-	err = d.Call(infoChainRequest, infoChainResult)
+	peerInfoResult := GetPeerInfoResult{}
+	err := d.Call(peerInfoRequest, &peerInfoResult)
 	if err != nil {
 		return nil, err
 	}
-	if infoChainResult.Error.Message != "" {
-		return nil, fmt.Errorf("Error fetching network info: %s", infoChainResult.Error.Message)
+	if peerInfoResult.Error.Message != "" {
+		return nil, errors.Annotate(newRPCError(peerInfoRequest.Method, peerInfoResult.Error), "Error fetching peer info")
 	}
 
-	chainInfo := &bchain.ChainInfo{
-		Chain:           blockchainInfoResult.Result.Chain,
-		Blocks:          int(blockchainInfoResult.Result.Blocks),
-		Headers:         int(blockchainInfoResult.Result.Headers),
-		Bestblockhash:   blockchainInfoResult.Result.BestBlockHash,
-		Difficulty:      strconv.Itoa(int(blockchainInfoResult.Result.Difficulty)),
-		SizeOnDisk:      blockchainInfoResult.Result.SyncHeight,
-		Version:         strconv.Itoa(int(infoChainResult.Result.Version)),
-		Subversion:      "",
-		ProtocolVersion: strconv.Itoa(int(infoChainResult.Result.ProtocolVersion)),
-		Timeoffset:      float64(infoChainResult.Result.TimeOffset),
-		Warnings:        "",
+	return peerInfoResult.Result, nil
+}
+
+// ErrAdminCallsDisabled is returned by the admin rpc calls if allow_admin_calls is not set
+var ErrAdminCallsDisabled = errors.New("Admin calls are disabled, set allow_admin_calls to enable them")
+
+type AdminCallResult struct {
+	Error  Error       `json:"error"`
+	Result interface{} `json:"result"`
+}
+
+func (d *DecredRPC) adminCall(method string, params ...interface{}) error {
+	if !d.config.AllowAdminCalls {
+		return ErrAdminCallsDisabled
 	}
-	return chainInfo, nil
+	adminRequest := GenericCmd{
+		ID:     1,
+		Method: method,
+		Params: params,
+	}
+	adminResult := AdminCallResult{}
+	err := d.Call(adminRequest, &adminResult)
+	if err != nil {
+		return err
+	}
+	if adminResult.Error.Message != "" {
+		return errors.Annotatef(newRPCError(adminRequest.Method, adminResult.Error), "Error calling %v", method)
+	}
+	return nil
+}
+
+// InvalidateBlock marks the block as invalid, dcrd reorganizes the chain to the best valid block
+func (d *DecredRPC) InvalidateBlock(hash string) error {
+	return d.adminCall("invalidateblock", hash)
+}
+
+// ReconsiderBlock removes the invalid status of the block and its ancestors set by InvalidateBlock
+func (d *DecredRPC) ReconsiderBlock(hash string) error {
+	return d.adminCall("reconsiderblock", hash)
+}
+
+// DecredAddedNodeInfo is the connection status of the address of the node added to dcrd by the addpeer option
+type DecredAddedNodeInfo struct {
+	AddedNode string `json:"addedNode"`
+	IP        string `json:"ip,omitempty"`
+	Port      string `json:"port,omitempty"`
+	Connected bool   `json:"connected"`
+}
+
+type GetAddedNodeInfoResult struct {
+	Error  Error `json:"error"`
+	Result []struct {
+		AddedNode string `json:"addednode"`
+		Connected *bool  `json:"connected,omitempty"`
+		Addresses []struct {
+			Address   string `json:"address"`
+			Connected string `json:"connected"`
+		} `json:"addresses,omitempty"`
+	} `json:"result"`
+}
+
+// GetAddedNodeInfo returns the resolved addresses of the added nodes and their connection status
+func (d *DecredRPC) GetAddedNodeInfo() ([]DecredAddedNodeInfo, error) {
+	addedNodeInfoRequest := GenericCmd{
+		ID:     1,
+		Method: "getaddednodeinfo",
+		Params: []interface{}{true},
+	}
+	addedNodeInfoResult := GetAddedNodeInfoResult{}
+	err := d.Call(addedNodeInfoRequest, &addedNodeInfoResult)
+	if err != nil {
+		return nil, err
+	}
+	if addedNodeInfoResult.Error.Message != "" {
+		return nil, errors.Annotate(newRPCError(addedNodeInfoRequest.Method, addedNodeInfoResult.Error), "Error fetching added node info")
+	}
+
+	r := make([]DecredAddedNodeInfo, 0, len(addedNodeInfoResult.Result))
+	for _, n := range addedNodeInfoResult.Result {
+		if len(n.Addresses) == 0 {
+			r = append(r, DecredAddedNodeInfo{AddedNode: n.AddedNode, Connected: n.Connected != nil && *n.Connected})
+			continue
+		}
+		for _, a := range n.Addresses {
+			ni := DecredAddedNodeInfo{
+				AddedNode: n.AddedNode,
+				IP:        a.Address,
+				// the connection direction (inbound/outbound) or "false"
+				Connected: a.Connected != "" && a.Connected != "false",
+			}
+			if host, port, err := net.SplitHostPort(a.Address); err == nil {
+				ni.IP, ni.Port = host, port
+			}
+			r = append(r, ni)
+		}
+	}
+	return r, nil
 }
 
 func (d *DecredRPC) getBestBlock() (*GetBestBlockResult, error) {
@@ -358,28 +821,231 @@ func (d *DecredRPC) getBestBlock() (*GetBestBlockResult, error) {
 		return nil, err
 	}
 	if bestBlockResult.Error.Message != "" {
-		return nil, fmt.Errorf("Error fetching best block: %s", bestBlockResult.Error.Message)
+		return nil, errors.Annotate(newRPCError(bestBlockRequest.Method, bestBlockResult.Error), "Error fetching best block")
 	}
 
 	return bestBlockResult, err
 }
 
+// GetBestBlockHash returns the hash of the best block using getbestblockhash,
+// getBestBlock is used by the callers which need the height as well
 func (d *DecredRPC) GetBestBlockHash() (string, error) {
-	bestBlock, err := d.getBestBlock()
+	bestBlockHashRequest := GenericCmd{
+		ID:     1,
+		Method: "getbestblockhash",
+	}
+	bestBlockHashResult := GetBestBlockHashResult{}
+	err := d.Call(bestBlockHashRequest, &bestBlockHashResult)
 	if err != nil {
 		return "", err
 	}
-
-	return bestBlock.Result.Hash, nil
+	if bestBlockHashResult.Error.Message != "" {
+		return "", errors.Annotate(newRPCError(bestBlockHashRequest.Method, bestBlockHashResult.Error), "Error fetching best block hash")
+	}
+	return bestBlockHashResult.Result, nil
 }
 
 func (d *DecredRPC) GetBestBlockHeight() (uint32, error) {
-	bestBlock, err := d.getBestBlock()
+	return d.GetBlockCount()
+}
+
+// GetBlockCount returns the height of the best block, it is cheaper than getbestblock
+// and suitable for polling of new blocks
+func (d *DecredRPC) GetBlockCount() (uint32, error) {
+	blockCountRequest := GenericCmd{
+		ID:     1,
+		Method: "getblockcount",
+	}
+	blockCountResult := GetBlockCountResult{}
+	err := d.Call(blockCountRequest, &blockCountResult)
 	if err != nil {
 		return 0, err
 	}
+	if blockCountResult.Error.Message != "" {
+		return 0, errors.Annotate(newRPCError(blockCountRequest.Method, blockCountResult.Error), "Error fetching block count")
+	}
+	return uint32(blockCountResult.Result), nil
+}
+
+// maxHealthyLatency is the response time of dcrd above which the backend is reported as unhealthy
+const maxHealthyLatency = 2 * time.Second
+
+// DecredNodeHealth is the status of the connection to dcrd
+type DecredNodeHealth struct {
+	Connected bool `json:"connected"`
+	// Latency is the response time of the ping, in nanoseconds in json
+	Latency    time.Duration `json:"latency"`
+	BestHeight uint32        `json:"bestHeight"`
+	Error      string        `json:"error,omitempty"`
+}
+
+type GetPingResult struct {
+	Error  Error       `json:"error"`
+	Result interface{} `json:"result"`
+}
+
+// CheckDecredNodeHealth pings dcrd and returns the status of the connection, the error is returned
+// if dcrd is not reachable or if it responds slower than maxHealthyLatency.
+// If ping is not supported, the response time of getblockcount is measured instead.
+func (d *DecredRPC) CheckDecredNodeHealth() (*DecredNodeHealth, error) {
+	h := &DecredNodeHealth{}
+	start := time.Now()
+	err := d.ping()
+	if err == bchain.ErrMethodNotSupported {
+		start = time.Now()
+		h.BestHeight, err = d.GetBlockCount()
+		h.Latency = time.Since(start)
+	} else {
+		h.Latency = time.Since(start)
+		if err == nil {
+			h.BestHeight, err = d.GetBlockCount()
+		}
+	}
+	if err != nil {
+		h.Error = err.Error()
+		return h, err
+	}
+	h.Connected = true
+	if h.Latency > maxHealthyLatency {
+		err = errors.Errorf("dcrd response time %v exceeds %v", h.Latency, maxHealthyLatency)
+		h.Error = err.Error()
+		return h, err
+	}
+	return h, nil
+}
+
+func (d *DecredRPC) ping() error {
+	pingRequest := GenericCmd{
+		ID:     1,
+		Method: "ping",
+	}
+	pingResult := GetPingResult{}
+	err := d.Call(pingRequest, &pingResult)
+	if err != nil {
+		return err
+	}
+	if pingResult.Error.Message != "" {
+		return errors.Annotate(newRPCError(pingRequest.Method, pingResult.Error), "Error pinging dcrd")
+	}
+	return nil
+}
+
+// maxReorgDepth is the maximum number of blocks walked back by CheckForReorg
+const maxReorgDepth = 1000
+
+// FetchWorkers returns the number of the blocks fetched concurrently in the regular sync
+func (d *DecredRPC) FetchWorkers() int {
+	if d.config == nil {
+		return 0
+	}
+	return d.config.FetchWorkers
+}
+
+// BlockPrefetchDepth returns the number of the block hashes resolved ahead of the initial sync
+func (d *DecredRPC) BlockPrefetchDepth() int {
+	if d.config == nil {
+		return 0
+	}
+	return d.config.BlockPrefetchDepth
+}
+
+// CheckForReorg finds the common ancestor of the block lastKnownHash at the height lastKnownHeight
+// and the current main chain. It returns true and the height of the common ancestor if the block
+// is not in the main chain anymore, false and lastKnownHeight otherwise.
+// dcrd keeps the headers of the orphaned blocks, the chain of the block is walked back using them.
+func (d *DecredRPC) CheckForReorg(lastKnownHash string, lastKnownHeight uint32) (bool, uint32, error) {
+	bestHeight, err := d.GetBlockCount()
+	if err != nil {
+		return false, 0, err
+	}
+	hash, height := lastKnownHash, lastKnownHeight
+	for depth := 0; ; depth++ {
+		if height <= bestHeight {
+			mainHash, err := d.GetBlockHash(height)
+			if err != nil {
+				return false, 0, err
+			}
+			if mainHash == hash {
+				return height != lastKnownHeight, height, nil
+			}
+		}
+		if height == 0 || depth >= maxReorgDepth {
+			return false, 0, errors.Errorf("Common ancestor of block %v not found", lastKnownHash)
+		}
+		header, err := d.getBlockHeader(hash)
+		if err != nil {
+			return false, 0, err
+		}
+		hash = header.Result.PreviousHash
+		height--
+	}
+}
+
+// GetVotingAddress returns the addresses receiving the reward when the ticket votes, one for each commitment of the ticket
+func (d *DecredRPC) GetVotingAddress(ticketHash string) ([]string, error) {
+	ticket, err := d.GetTransaction(ticketHash)
+	if err != nil {
+		return nil, err
+	}
+	return d.Parser.(*DecredParser).DeriveVotingAddress(ticket)
+}
 
-	return uint32(bestBlock.Result.Height), err
+// IndexedChain provides the main chain blocks of the index of blockbook
+type IndexedChain interface {
+	GetBestBlock() (uint32, string, error)
+	GetBlockHash(height uint32) (string, error)
+}
+
+// VerifyChain compares depth blocks from the best indexed block down with the main chain of dcrd, ok is false
+// and mismatch is the height of the highest indexed block not in the main chain if any block differs.
+// The blocks above the tip of dcrd are not compared.
+func (d *DecredRPC) VerifyChain(index IndexedChain, depth int) (mismatch uint32, ok bool, err error) {
+	height, _, err := index.GetBestBlock()
+	if err != nil {
+		return 0, false, err
+	}
+	bestHeight, err := d.GetBlockCount()
+	if err != nil {
+		return 0, false, err
+	}
+	if height > bestHeight {
+		height = bestHeight
+	}
+	for i := 0; i < depth; i++ {
+		indexed, err := index.GetBlockHash(height)
+		if err != nil {
+			return 0, false, err
+		}
+		hash, err := d.GetBlockHash(height)
+		if err != nil {
+			return 0, false, err
+		}
+		if indexed != hash {
+			return height, false, nil
+		}
+		if height == 0 {
+			break
+		}
+		height--
+	}
+	return 0, true, nil
+}
+
+// VerifyChainOnStartup compares the indexed blocks with dcrd if verify_chain_depth is configured,
+// the mismatch is only logged, the sync handles it as a reorg
+func (d *DecredRPC) VerifyChainOnStartup(index IndexedChain) {
+	depth := d.config.VerifyChainDepth
+	if depth <= 0 {
+		return
+	}
+	mismatch, ok, err := d.VerifyChain(index, depth)
+	if err != nil {
+		glog.Warning("rpc: VerifyChain error ", err)
+	} else if !ok {
+		glog.Warning("rpc: indexed block at height ", mismatch, " is not in the main chain of dcrd")
+	} else {
+		glog.Info("rpc: verified ", depth, " indexed blocks")
+	}
 }
 
 func (d *DecredRPC) GetBlockHash(height uint32) (string, error) {
@@ -394,13 +1060,52 @@ func (d *DecredRPC) GetBlockHash(height uint32) (string, error) {
 		return "", err
 	}
 	if blockHashResult.Error.Message != "" {
-		return "", fmt.Errorf("Error fetching block hash: %s", blockHashResult.Error.Message)
+		return "", errors.Annotate(newRPCError(blockHashRequest.Method, blockHashResult.Error), "Error fetching block hash")
 	}
 
 	return blockHashResult.Result, err
 }
 
 func (d *DecredRPC) GetBlockHeader(hash string) (*bchain.BlockHeader, error) {
+	blockHeader, err := d.getBlockHeader(hash)
+	if err != nil {
+		return nil, err
+	}
+	header := blockHeader.blockHeader()
+	return &header, nil
+}
+
+// DecredBlockHeader is the block header with the stake specific fields
+type DecredBlockHeader struct {
+	bchain.BlockHeader
+	Voters       uint16  `json:"voters"`
+	FreshStake   uint8   `json:"freshstake"`
+	Revocations  uint8   `json:"revocations"`
+	PoolSize     uint32  `json:"poolsize"`
+	SBits        float64 `json:"sbits"`
+	VoteBits     uint16  `json:"votebits"`
+	StakeVersion uint32  `json:"stakeversion"`
+}
+
+// GetDecredBlockHeader returns the block header including the stake specific fields
+func (d *DecredRPC) GetDecredBlockHeader(hash string) (*DecredBlockHeader, error) {
+	blockHeader, err := d.getBlockHeader(hash)
+	if err != nil {
+		return nil, err
+	}
+	return &DecredBlockHeader{
+		BlockHeader:  blockHeader.blockHeader(),
+		Voters:       blockHeader.Result.Voters,
+		FreshStake:   blockHeader.Result.FreshStake,
+		Revocations:  blockHeader.Result.Revocations,
+		PoolSize:     blockHeader.Result.PoolSize,
+		SBits:        blockHeader.Result.SBits,
+		VoteBits:     blockHeader.Result.VoteBits,
+		StakeVersion: blockHeader.Result.StakeVersion,
+	}, nil
+}
+
+func (d *DecredRPC) getBlockHeader(hash string) (*GetBlockHeaderResult, error) {
 	blockHeaderRequest := GenericCmd{
 		ID:     1,
 		Method: "getblockheader",
@@ -413,20 +1118,22 @@ func (d *DecredRPC) GetBlockHeader(hash string) (*bchain.BlockHeader, error) {
 		return nil, err
 	}
 	if blockHeader.Error.Message != "" {
-		return nil, fmt.Errorf("Error fetching block info: %s", blockHeader.Error.Message)
+		return nil, errors.Annotate(newRPCError(blockHeaderRequest.Method, blockHeader.Error), "Error fetching block info")
 	}
+	return blockHeader, nil
+}
 
-	header := &bchain.BlockHeader{
-		Hash:          blockHeader.Result.Hash,
-		Prev:          blockHeader.Result.PreviousHash,
-		Next:          blockHeader.Result.NextHash,
-		Height:        blockHeader.Result.Height,
-		Confirmations: int(blockHeader.Result.Confirmations),
-		Size:          int(blockHeader.Result.Size),
-		Time:          blockHeader.Result.Time / 1000,
+func (r *GetBlockHeaderResult) blockHeader() bchain.BlockHeader {
+	return bchain.BlockHeader{
+		Hash:          r.Result.Hash,
+		Prev:          r.Result.PreviousHash,
+		Next:          r.Result.NextHash,
+		Height:        r.Result.Height,
+		Confirmations: int(r.Result.Confirmations),
+		Size:          int(r.Result.Size),
+		Time:          r.Result.Time / 1000,
+		ChainWork:     r.Result.ChainWork,
 	}
-
-	return header, nil
 }
 
 func (d *DecredRPC) GetBlockHeaderByHeight(height uint32) (*bchain.BlockHeader, error) {
@@ -447,15 +1154,22 @@ func (d *DecredRPC) GetBlock(hash string, height uint32) (*bchain.Block, error)
 			return nil, err
 		}
 		if getHashResult.Error.Message != "" {
-			return nil, fmt.Errorf("Error fetching block hash: %s", getHashResult.Error.Message)
+			return nil, errors.Annotate(newRPCError(getHashRequest.Method, getHashResult.Error), "Error fetching block hash")
 		}
 		requestHash = getHashResult.Result
 	}
 
-	block, err := d.getBlock(requestHash)
+	block, err := d.getBlockVerboseTx(requestHash)
 	if err != nil {
 		return nil, err
 	}
+	if err = d.validateVoters(block.Result.Height, block.Result.Voters); err != nil {
+		return nil, errors.Annotatef(err, "block %v", block.Result.Hash)
+	}
+	// the clock of dcrd or blockbook may be wrong, the block is accepted by dcrd and is indexed anyway
+	if err = d.ValidateBlockTime(block); err != nil {
+		glog.Error("rpc: block ", block.Result.Hash, " time ", time.Unix(block.Result.Time, 0), ": ", err, ", check the clock of dcrd and blockbook")
+	}
 
 	header := bchain.BlockHeader{
 		Hash:          block.Result.Hash,
@@ -466,26 +1180,144 @@ func (d *DecredRPC) GetBlock(hash string, height uint32) (*bchain.Block, error)
 		Size:          int(block.Result.Size),
 		Time:          block.Result.Time,
 	}
+	// the serialized header carries the vote bits read by the block approval, the block is usable without it
+	if header.Raw, err = serializeBlockHeader(block); err != nil {
+		glog.Warning("rpc: block ", block.Result.Hash, " header: ", err)
+	}
 
 	bchainBlock := &bchain.Block{
 		BlockHeader: header,
 	}
 
-	for _, txId := range block.Result.Tx {
-		if block.Result.Height == 0 {
-			continue
-		}
+	// the transactions of the genesis block are not available from getrawtransaction
+	if block.Result.Height == 0 && len(block.Result.RawTx) == 0 {
+		d.observeBlockFetched()
+		return bchainBlock, nil
+	}
 
-		tx, err := d.GetTransaction(txId)
+	if bchainBlock.Txs, err = d.parseBlockTxs(block.Result.RawTx, block.Result.Tx); err != nil {
+		return nil, err
+	}
+	// the stake tree is kept apart, its transactions are not indexed as the regular transactions
+	if bchainBlock.StakeTxs, err = d.parseBlockTxs(block.Result.RawSTx, block.Result.STx); err != nil {
+		return nil, err
+	}
+	if err = validateBlockTxs(block, bchainBlock.Txs); err != nil {
+		return nil, errors.Annotatef(err, "block %v", block.Result.Hash)
+	}
+
+	d.observeBlockFetched()
+	return bchainBlock, nil
+}
+
+// parseBlockTxs parses the transactions of one tree of the block, the transactions are fetched
+// by their txids if the block was returned without the decoded transactions
+func (d *DecredRPC) parseBlockTxs(rawTxs []RawTx, txids []string) ([]bchain.Tx, error) {
+	if len(rawTxs) == 0 {
+		txs, err := d.getTransactionsBatch(txids)
 		if err != nil {
 			return nil, err
 		}
+		r := make([]bchain.Tx, len(txs))
+		for i := range txs {
+			r[i] = *txs[i]
+		}
+		return r, nil
+	}
+	r := make([]bchain.Tx, len(rawTxs))
+	for i := range rawTxs {
+		b, err := json.Marshal(&rawTxs[i])
+		if err != nil {
+			return nil, err
+		}
+		tx, err := d.Parser.(*DecredParser).ParseTxFromJsonDecodeOnly(b)
+		if err != nil {
+			return nil, errors.Annotatef(err, "txid %v", rawTxs[i].Txid)
+		}
+		r[i] = *tx
+	}
+	return r, nil
+}
 
-		bchainBlock.Txs = append(bchainBlock.Txs, *tx)
+// validateBlockTxs checks the transactions of the block returned by dcrd to catch corrupted responses.
+// The number of the regular transactions must match the txids listed by the block, the stake tree must contain
+// at least the votes, tickets and revocations counted by the header, the txids of both trees must be unique
+// and the first regular transaction must be the coinbase.
+func validateBlockTxs(block *GetBlockResult, txs []bchain.Tx) error {
+	if len(block.Result.Tx) > 0 && len(txs) != len(block.Result.Tx) {
+		return errors.Errorf("Transaction count: %d regular transactions fetched, the block lists %d", len(txs), len(block.Result.Tx))
+	}
+	stxids := block.Result.STx
+	if len(stxids) == 0 {
+		for i := range block.Result.RawSTx {
+			stxids = append(stxids, block.Result.RawSTx[i].Txid)
+		}
+	}
+	if len(stxids) > 0 {
+		if counted := int(block.Result.Voters) + int(block.Result.FreshStake) + int(block.Result.Revocations); len(stxids) < counted {
+			return errors.Errorf("Transaction count: %d stake transactions, the header counts %d", len(stxids), counted)
+		}
+	}
+	unique := make(map[string]struct{}, len(txs)+len(stxids))
+	for i := range txs {
+		if _, found := unique[txs[i].Txid]; found {
+			return errors.Errorf("Duplicate txid: %v", txs[i].Txid)
+		}
+		unique[txs[i].Txid] = struct{}{}
+	}
+	for _, txid := range stxids {
+		if _, found := unique[txid]; found {
+			return errors.Errorf("Duplicate txid: %v", txid)
+		}
+		unique[txid] = struct{}{}
+	}
+	if len(txs) == 0 || len(txs[0].Vin) == 0 || txs[0].Vin[0].Coinbase == "" {
+		return errors.New("Coinbase: the first transaction is not a coinbase")
+	}
+	return nil
+}
 
+// validateVoters checks the number of votes, the blocks after the stake validation height
+// must contain votes of the majority of the tickets selected to vote
+func (d *DecredRPC) validateVoters(height int64, voters uint16) error {
+	params := d.Parser.(*DecredParser).chainParams()
+	if height < params.StakeValidationHeight {
+		return nil
+	}
+	minVoters := params.TicketsPerBlock/2 + 1
+	if voters < minVoters {
+		return errors.Errorf("Invalid block at height %d: %d votes, at least %d required", height, voters, minVoters)
 	}
+	return nil
+}
 
-	return bchainBlock, nil
+// maxBlockTimeOffset is the maximum time a block can be ahead of the current time, the same as in dcrd consensus rules
+const maxBlockTimeOffset = 2 * time.Hour
+
+// lastBlockTime is the time of the last block validated by ValidateBlockTime
+type lastBlockTime struct {
+	lock sync.Mutex
+	hash string
+	time int64
+}
+
+// ValidateBlockTime checks that the block is not too far in the future and that its timestamp is not before
+// the timestamp of the previous block. The previous block is compared only if it was the last validated block,
+// which is the case in the sync, no block is fetched for the check. The consensus rules allow a block before
+// the previous block if it is after the median time of the previous blocks, therefore it is only reported.
+func (d *DecredRPC) ValidateBlockTime(block *GetBlockResult) error {
+	blockTime := time.Unix(block.Result.Time, 0)
+	d.lastBlock.lock.Lock()
+	if block.Result.PreviousHash == d.lastBlock.hash && block.Result.Time < d.lastBlock.time {
+		glog.Warning("rpc: block ", block.Result.Hash, " time ", blockTime, " is before the time of the previous block ", time.Unix(d.lastBlock.time, 0))
+	}
+	d.lastBlock.hash = block.Result.Hash
+	d.lastBlock.time = block.Result.Time
+	d.lastBlock.lock.Unlock()
+	if blockTime.After(time.Now().Add(maxBlockTimeOffset)) {
+		return bchain.ErrBlockFutureDated
+	}
+	return nil
 }
 
 func (d *DecredRPC) getBlock(hash string) (*GetBlockResult, error) {
@@ -500,12 +1332,125 @@ func (d *DecredRPC) getBlock(hash string) (*GetBlockResult, error) {
 		return nil, err
 	}
 	if block.Error.Message != "" {
-		return nil, fmt.Errorf("Error fetching block info: %s", block.Error.Message)
+		return nil, errors.Annotate(newRPCError(blockRequest.Method, block.Error), "Error fetching block info")
 	}
 
 	return block, err
 }
 
+// getBlockVerboseTx returns the block with the decoded transactions of both trees in RawTx and RawSTx
+func (d *DecredRPC) getBlockVerboseTx(hash string) (*GetBlockResult, error) {
+	verbose, verboseTx := true, true
+	blockRequest := GenericCmd{
+		ID:     1,
+		Method: "getblock",
+		Params: []interface{}{hash, &verbose, &verboseTx},
+	}
+	block := &GetBlockResult{}
+	err := d.Call(blockRequest, block)
+	if err != nil {
+		return nil, err
+	}
+	if block.Error.Message != "" {
+		return nil, errors.Annotate(newRPCError(blockRequest.Method, block.Error), "Error fetching block info")
+	}
+	return block, nil
+}
+
+// DecredBlockRewards is the breakdown of the block subsidy
+type DecredBlockRewards struct {
+	PoW      big.Int
+	Stake    big.Int
+	Treasury big.Int
+}
+
+// GetBlockInfoRewards returns the block info together with the subsidy paid by the block, both are taken
+// from a single getblock call. The rewards are nil if they cannot be computed, the block info is usable anyway.
+func (d *DecredRPC) GetBlockInfoRewards(hash string) (*bchain.BlockInfo, *DecredBlockRewards, error) {
+	block, err := d.getBlockVerboseTx(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := blockRewards(block)
+	if err != nil {
+		glog.Warning("rpc: block ", hash, " rewards: ", err)
+		r = nil
+	}
+	return blockInfo(block), r, nil
+}
+
+// blockRewards returns the subsidy paid by the block to the miner, to the voters and to the treasury,
+// the treasury portion is credited by the treasurybase transaction in the stake tree (DCP-0006)
+func blockRewards(block *GetBlockResult) (*DecredBlockRewards, error) {
+	hash := block.Result.Hash
+	var err error
+	var r DecredBlockRewards
+	add := func(sum *big.Int, value float64) error {
+		a, err := dcrutil.NewAmount(value)
+		if err != nil {
+			return errors.Annotatef(err, "block %v", hash)
+		}
+		sum.Add(sum, big.NewInt(int64(a)))
+		return nil
+	}
+	if len(block.Result.RawTx) > 0 {
+		for _, vout := range block.Result.RawTx[0].Vout {
+			if err = add(&r.PoW, vout.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for i := range block.Result.RawSTx {
+		tx := &block.Result.RawSTx[i]
+		if len(tx.Vin) == 0 {
+			continue
+		}
+		if tx.Vin[0].Stakebase != "" {
+			if err = add(&r.Stake, tx.Vin[0].AmountIn); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for j := range tx.Vout {
+			if isTreasuryCredit(tx.Vout[j].ScriptPubKey.Hex) && tx.Vin[0].Txid == "" {
+				if err = add(&r.Treasury, tx.Vout[j].Value); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return &r, nil
+}
+
+type GetBlockRawResult struct {
+	Error  Error  `json:"error"`
+	Result string `json:"result"`
+}
+
+// GetBlockRaw returns the serialized block
+func (d *DecredRPC) GetBlockRaw(hash string) ([]byte, error) {
+	verbose := false
+	blockRequest := GenericCmd{
+		ID:     1,
+		Method: "getblock",
+		Params: []interface{}{hash, verbose},
+	}
+	block := &GetBlockRawResult{}
+	err := d.Call(blockRequest, block)
+	if err != nil {
+		return nil, err
+	}
+	if block.Error.Message != "" {
+		return nil, errors.Annotate(newRPCError(blockRequest.Method, block.Error), "Error fetching raw block")
+	}
+
+	blockBytes, err := hex.DecodeString(block.Result)
+	if err != nil {
+		return nil, errors.Annotatef(err, "hash %v", hash)
+	}
+	return blockBytes, nil
+}
+
 func (d *DecredRPC) decodeRawTransaction(txHex string) (*bchain.Tx, error) {
 	decodeRawTxRequest := GenericCmd{
 		ID:     1,
@@ -518,7 +1463,7 @@ func (d *DecredRPC) decodeRawTransaction(txHex string) (*bchain.Tx, error) {
 		return nil, err
 	}
 	if decodeRawTxResult.Error.Message != "" {
-		return nil, fmt.Errorf("Error decoding raw tx: %s", decodeRawTxResult.Error.Message)
+		return nil, errors.Annotate(newRPCError(decodeRawTxRequest.Method, decodeRawTxResult.Error), "Error decoding raw tx")
 	}
 
 	tx := &bchain.Tx{
@@ -536,6 +1481,19 @@ func (d *DecredRPC) GetBlockInfo(hash string) (*bchain.BlockInfo, error) {
 	if err != nil {
 		return nil, err
 	}
+	return blockInfo(block), nil
+}
+
+// blockInfo converts the getblock result, the txids of the regular tree are taken from the decoded
+// transactions if the block was requested with them
+func blockInfo(block *GetBlockResult) *bchain.BlockInfo {
+	txids := block.Result.Tx
+	if len(txids) == 0 && len(block.Result.RawTx) > 0 {
+		txids = make([]string, len(block.Result.RawTx))
+		for i := range block.Result.RawTx {
+			txids[i] = block.Result.RawTx[i].Txid
+		}
+	}
 
 	header := bchain.BlockHeader{
 		Hash:          block.Result.Hash,
@@ -554,10 +1512,13 @@ func (d *DecredRPC) GetBlockInfo(hash string) (*bchain.BlockInfo, error) {
 		Nonce:       block.Result.Nonce,
 		Bits:        block.Result.Bits,
 		Difficulty:  json.Number(strconv.FormatFloat(block.Result.Difficulty, 'e', -1, 64)),
-		Txids:       block.Result.Tx,
+		Txids:       txids,
+		// ticket price in DCR
+		StakeDifficulty: json.Number(strconv.FormatFloat(block.Result.SBits, 'f', -1, 64)),
+		ExtraData:       block.Result.ExtraData,
 	}
 
-	return bInfo, nil
+	return bInfo
 }
 
 func (d *DecredRPC) GetMempoolTransactions() ([]string, error) {
@@ -578,6 +1539,62 @@ func (d *DecredRPC) GetTransaction(txid string) (*bchain.Tx, error) {
 	return tx, nil
 }
 
+// GetRawTransactionBatchResult is a single response of the batched getrawtransaction request
+type GetRawTransactionBatchResult struct {
+	ID     int             `json:"id"`
+	Error  *Error          `json:"error"`
+	Result json.RawMessage `json:"result"`
+}
+
+// getTransactionsBatch returns the block transactions fetched by a single batched JSON-RPC request,
+// in the same order as the txids
+func (d *DecredRPC) getTransactionsBatch(txids []string) ([]*bchain.Tx, error) {
+	if len(txids) == 0 {
+		return []*bchain.Tx{}, nil
+	}
+	verbose := 1
+	batch := make([]GenericCmd, len(txids))
+	for i, txid := range txids {
+		if txid == "" {
+			return nil, bchain.ErrTxidMissing
+		}
+		batch[i] = GenericCmd{
+			ID:     i,
+			Method: "getrawtransaction",
+			Params: []interface{}{txid, &verbose},
+		}
+	}
+	var batchResult []GetRawTransactionBatchResult
+	err := d.Call(batch, &batchResult)
+	if err != nil {
+		return nil, err
+	}
+	if len(batchResult) != len(txids) {
+		return nil, errors.Annotate(newResponseError("getrawtransaction", "%d results for %d txids", len(batchResult), len(txids)), "Error fetching transactions")
+	}
+
+	txs := make([]*bchain.Tx, len(txids))
+	// the responses of a batch are not required to be in the order of the requests
+	for i := range batchResult {
+		r := &batchResult[i]
+		if r.ID < 0 || r.ID >= len(txids) || txs[r.ID] != nil {
+			return nil, errors.Annotate(newResponseError("getrawtransaction", "unexpected response id %d", r.ID), "Error fetching transactions")
+		}
+		if r.Error != nil && r.Error.Message != "" {
+			if r.Error.Code == rpcErrTxNotFound {
+				return nil, bchain.ErrTxNotFound
+			}
+			return nil, errors.Annotatef(newRPCError(batch[r.ID].Method, *r.Error), "Error fetching transaction %v", txids[r.ID])
+		}
+		tx, err := d.Parser.(*DecredParser).ParseTxFromJsonDecodeOnly(r.Result)
+		if err != nil {
+			return nil, errors.Annotatef(err, "txid %v", txids[r.ID])
+		}
+		txs[r.ID] = tx
+	}
+	return txs, nil
+}
+
 func (d *DecredRPC) getRawTransaction(txid string) (json.RawMessage, error) {
 	if txid == "" {
 		return nil, bchain.ErrTxidMissing
@@ -595,7 +1612,10 @@ func (d *DecredRPC) getRawTransaction(txid string) (json.RawMessage, error) {
 		return nil, err
 	}
 	if getTxResult.Error.Message != "" {
-		return nil, fmt.Errorf("Error fetching transaction: %s", getTxResult.Error.Message)
+		if getTxResult.Error.Code == rpcErrTxNotFound {
+			return nil, bchain.ErrTxNotFound
+		}
+		return nil, errors.Annotate(newRPCError(getTxRequest.Method, getTxResult.Error), "Error fetching transaction")
 	}
 
 	bytes, err := json.Marshal(getTxResult.Result)
@@ -627,10 +1647,11 @@ func (d *DecredRPC) EstimateSmartFee(blocks int, conservative bool) (big.Int, er
 		return *big.NewInt(0), nil
 	}
 	if estimateSmartFeeResult.Error.Message != "" {
-		return *big.NewInt(0), fmt.Errorf("Error fetching smart fee estimate: %s", estimateSmartFeeResult.Error.Message)
+		return *big.NewInt(0), errors.Annotate(newRPCError(estimateSmartFeeRequest.Method, estimateSmartFeeResult.Error), "Error fetching smart fee estimate")
 	}
 
-	return *big.NewInt(int64(estimateSmartFeeResult.Result.FeeRate)), nil
+	// the fee rate is in DCR per 1000 bytes of the serialized transaction, Decred has no witness discount
+	return d.Parser.AmountToBigInt(estimateSmartFeeResult.Result.FeeRate)
 }
 
 func (d *DecredRPC) EstimateFee(blocks int) (big.Int, error) {
@@ -670,8 +1691,357 @@ func (d *DecredRPC) SendRawTransaction(tx string) (string, error) {
 	return res, nil
 }
 
+// DecredUTXO is an unspent output returned by getaddressunspent
+type DecredUTXO struct {
+	Address       string      `json:"address"`
+	Txid          string      `json:"txid"`
+	Vout          uint32      `json:"vout"`
+	Tree          int8        `json:"tree"`
+	ScriptPubKey  string      `json:"scriptpubkey"`
+	Amount        json.Number `json:"amount"`
+	Height        int64       `json:"height"`
+	Confirmations int64       `json:"confirmations"`
+}
+
+type GetAddressUnspentResult struct {
+	Error  Error        `json:"error"`
+	Result []DecredUTXO `json:"result"`
+}
+
+// GetAddressUnspent returns the unspent outputs of the addresses from the dcrd address index,
+// the confirmations are computed as bestHeight - height + 1 so that all outputs count from the same tip,
+// the unconfirmed outputs have no confirmations
+func (d *DecredRPC) GetAddressUnspent(addrs []string) ([]DecredUTXO, error) {
+	bestHeight, err := d.GetBlockCount()
+	if err != nil {
+		return nil, err
+	}
+	addressUnspentRequest := GenericCmd{
+		ID:     1,
+		Method: "getaddressunspent",
+		Params: []interface{}{addrs},
+	}
+	addressUnspentResult := GetAddressUnspentResult{}
+	err = d.Call(addressUnspentRequest, &addressUnspentResult)
+	if err != nil {
+		return nil, err
+	}
+	if addressUnspentResult.Error.Message != "" {
+		return nil, errors.Annotate(newRPCError(addressUnspentRequest.Method, addressUnspentResult.Error), "Error fetching address unspent outputs")
+	}
+	for i := range addressUnspentResult.Result {
+		u := &addressUnspentResult.Result[i]
+		switch {
+		case u.Height <= 0:
+			u.Confirmations = 0
+		case u.Height > int64(bestHeight):
+			// the block was connected after getblockcount
+			u.Confirmations = 1
+		default:
+			u.Confirmations = int64(bestHeight) - u.Height + 1
+		}
+	}
+	return addressUnspentResult.Result, nil
+}
+
+type SearchRawTransactionsResult struct {
+	Error  Error             `json:"error"`
+	Result []json.RawMessage `json:"result"`
+}
+
+// SearchRawTransactions returns the transactions of the address from the dcrd address index (dcrd must run with --addrindex),
+// the transactions are hex strings if verbose is 0, otherwise getrawtransaction like objects.
+// The address without any transaction returns an empty list.
+func (d *DecredRPC) SearchRawTransactions(addr string, verbose int, skip int, count int, vinExtra int, reverse bool, filterAddrs []string) ([]json.RawMessage, error) {
+	searchRequest := GenericCmd{
+		ID:     1,
+		Method: "searchrawtransactions",
+		Params: []interface{}{addr, verbose, skip, count, vinExtra, reverse, filterAddrs},
+	}
+	searchResult := SearchRawTransactionsResult{}
+	err := d.Call(searchRequest, &searchResult)
+	if err != nil {
+		return nil, err
+	}
+	if searchResult.Error.Message != "" {
+		// dcrd returns the no information error for the addresses without transactions
+		if searchResult.Error.Code == rpcErrTxNotFound {
+			return []json.RawMessage{}, nil
+		}
+		return nil, errors.Annotate(newRPCError(searchRequest.Method, searchResult.Error), "Error searching raw transactions")
+	}
+	return searchResult.Result, nil
+}
+
+// GetAddressTransactionsPaged returns count transactions of the address after skipping skip transactions, newest first,
+// only the requested page is fetched from the dcrd address index. The unconfirmed transactions are returned by dcrd
+// before the confirmed ones and are included in the offset.
+func (d *DecredRPC) GetAddressTransactionsPaged(addr string, skip int, count int) ([]*bchain.Tx, error) {
+	if skip < 0 || count <= 0 {
+		return nil, errors.Errorf("Invalid page, skip %d, count %d", skip, count)
+	}
+	raws, err := d.SearchRawTransactions(addr, 1, skip, count, 0, true, nil)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]*bchain.Tx, 0, len(raws))
+	for _, raw := range raws {
+		tx, err := d.Parser.ParseTxFromJson(raw)
+		if err != nil {
+			return nil, errors.Annotatef(err, "address %v", addr)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+func (d *DecredRPC) observeBlockFetched() {
+	if d.metrics != nil {
+		d.metrics.BackendBlocksFetched.Inc()
+	}
+}
+
+// trees of the transactions of the Decred block
+const (
+	txTreeRegular int8 = 0
+	txTreeStake   int8 = 1
+)
+
+// DecredTxOut is the unspent transaction output returned by gettxout
+type DecredTxOut struct {
+	BestBlock     string             `json:"bestblock"`
+	Confirmations int64              `json:"confirmations"`
+	Value         json.Number        `json:"value"`
+	ScriptPubKey  ScriptPubKeyResult `json:"scriptPubKey"`
+	Coinbase      bool               `json:"coinbase"`
+}
+
+type GetTxOutResult struct {
+	Error  Error        `json:"error"`
+	Result *DecredTxOut `json:"result"`
+}
+
+// GetTxOut returns the unspent output of the transaction in the tree or nil if the output is spent or does not exist,
+// dcrd does not find the output if the tree does not match the transaction
+func (d *DecredRPC) GetTxOut(txid string, vout uint32, tree int8, includeMempool bool) (*DecredTxOut, error) {
+	txOutRequest := GenericCmd{
+		ID:     1,
+		Method: "gettxout",
+		Params: []interface{}{txid, vout, tree, includeMempool},
+	}
+	txOutResult := GetTxOutResult{}
+	err := d.Call(txOutRequest, &txOutResult)
+	if err != nil {
+		return nil, err
+	}
+	if txOutResult.Error.Message != "" {
+		return nil, errors.Annotate(newRPCError(txOutRequest.Method, txOutResult.Error), "Error fetching tx out")
+	}
+	return txOutResult.Result, nil
+}
+
+// DecredMempoolTx is the verbose getrawmempool entry
+type DecredMempoolTx struct {
+	Size             int32       `json:"size"`
+	Fee              json.Number `json:"fee"`
+	Time             int64       `json:"time"`
+	Height           int64       `json:"height"`
+	StartingPriority float64     `json:"startingpriority"`
+	CurrentPriority  float64     `json:"currentpriority"`
+	Depends          []string    `json:"depends"`
+}
+
+type GetRawMempoolVerboseResult struct {
+	Error  Error                      `json:"error"`
+	Result map[string]DecredMempoolTx `json:"result"`
+}
+
+// getRawMempoolVerbose returns the mempool transactions of the txType ("all", "regular", "tickets", "votes", "revocations")
+func (d *DecredRPC) getRawMempoolVerbose(txType string) (map[string]DecredMempoolTx, error) {
+	mempoolRequest := GenericCmd{
+		ID:     1,
+		Method: "getrawmempool",
+		Params: []interface{}{true, txType},
+	}
+	mempoolResult := GetRawMempoolVerboseResult{}
+	err := d.Call(mempoolRequest, &mempoolResult)
+	if err != nil {
+		return nil, err
+	}
+	if mempoolResult.Error.Message != "" {
+		return nil, errors.Annotate(newRPCError(mempoolRequest.Method, mempoolResult.Error), "Error fetching mempool")
+	}
+	return mempoolResult.Result, nil
+}
+
+// DecredMempoolTxInfo contains the fee and size information of the mempool transaction
+type DecredMempoolTxInfo struct {
+	Fee     float64  `json:"fee"`
+	FeeSat  big.Int  `json:"-"`
+	Size    int32    `json:"size"`
+	Depends []string `json:"depends"`
+	Time    int64    `json:"time"`
+}
+
+// GetRawMempoolVerbose returns the fee and size information of all mempool transactions keyed by txid
+func (d *DecredRPC) GetRawMempoolVerbose() (map[string]*DecredMempoolTxInfo, error) {
+	mempool, err := d.getRawMempoolVerbose("all")
+	if err != nil {
+		return nil, err
+	}
+	r := make(map[string]*DecredMempoolTxInfo, len(mempool))
+	for txid, tx := range mempool {
+		fee, err := tx.Fee.Float64()
+		if err != nil {
+			return nil, errors.Annotatef(err, "txid %v", txid)
+		}
+		feeSat, err := d.Parser.AmountToBigInt(tx.Fee)
+		if err != nil {
+			return nil, errors.Annotatef(err, "txid %v", txid)
+		}
+		r[txid] = &DecredMempoolTxInfo{
+			Fee:     fee,
+			FeeSat:  feeSat,
+			Size:    tx.Size,
+			Depends: tx.Depends,
+			Time:    tx.Time,
+		}
+	}
+	return r, nil
+}
+
+// DecredMempoolTypeInfo contains the statistics of one type of the mempool transactions
+type DecredMempoolTypeInfo struct {
+	Count int     `json:"count"`
+	Size  int64   `json:"size"`
+	Fees  big.Int `json:"fees"`
+}
+
+// DecredMempoolInfo is the breakdown of the mempool by transaction type
+type DecredMempoolInfo struct {
+	Regular     DecredMempoolTypeInfo `json:"regular"`
+	Tickets     DecredMempoolTypeInfo `json:"tickets"`
+	Votes       DecredMempoolTypeInfo `json:"votes"`
+	Revocations DecredMempoolTypeInfo `json:"revocations"`
+}
+
+// GetDecredMempoolInfo returns the counts, sizes and fees (in atoms) of the mempool transactions by type
+func (d *DecredRPC) GetDecredMempoolInfo() (*DecredMempoolInfo, error) {
+	var r DecredMempoolInfo
+	for _, t := range []struct {
+		txType string
+		info   *DecredMempoolTypeInfo
+	}{
+		{"regular", &r.Regular},
+		{"tickets", &r.Tickets},
+		{"votes", &r.Votes},
+		{"revocations", &r.Revocations},
+	} {
+		mempool, err := d.getRawMempoolVerbose(t.txType)
+		if err != nil {
+			return nil, err
+		}
+		for txid, tx := range mempool {
+			fee, err := d.Parser.AmountToBigInt(tx.Fee)
+			if err != nil {
+				return nil, errors.Annotatef(err, "txid %v", txid)
+			}
+			t.info.Count++
+			t.info.Size += int64(tx.Size)
+			t.info.Fees.Add(&t.info.Fees, &fee)
+		}
+	}
+	return &r, nil
+}
+
+// maxFeeHistogramBucket is the highest bucket of the fee histogram, 2^20 atoms per byte
+const maxFeeHistogramBucket = 20
+
+// GetMempoolFeeHistogram returns the histogram of mempool fee rates in logarithmic buckets
+// (0, 1, 2, 4, 8... atoms per byte), the highest fee rates first. The size reported by dcrd
+// is the whole serialized transaction, the same as CalcTxSize of the parser.
+func (d *DecredRPC) GetMempoolFeeHistogram() ([]bchain.FeeHistogramItem, error) {
+	mempool, err := d.getRawMempoolVerbose("all")
+	if err != nil {
+		return nil, err
+	}
+	var buckets [maxFeeHistogramBucket + 2]bchain.FeeHistogramItem
+	for txid, tx := range mempool {
+		if tx.Size <= 0 {
+			continue
+		}
+		fee, err := d.Parser.AmountToBigInt(tx.Fee)
+		if err != nil {
+			return nil, errors.Annotatef(err, "txid %v", txid)
+		}
+		rate := fee.Int64() / int64(tx.Size)
+		b := 0
+		for r := rate; r > 0 && b <= maxFeeHistogramBucket; r >>= 1 {
+			b++
+		}
+		buckets[b].Count++
+		buckets[b].Size += int64(tx.Size)
+	}
+	histogram := make([]bchain.FeeHistogramItem, 0, len(buckets))
+	for b := len(buckets) - 1; b >= 0; b-- {
+		if buckets[b].Count == 0 {
+			continue
+		}
+		if b > 0 {
+			buckets[b].FeeRate = 1 << uint(b-1)
+		}
+		histogram = append(histogram, buckets[b])
+	}
+	return histogram, nil
+}
+
 // Call calls Backend RPC interface, using RPCMarshaler interface to marshall the request
 func (d *DecredRPC) Call(req interface{}, res interface{}) error {
+	method := requestMethod(req)
+	start := time.Now()
+	err := d.call(method, req, res)
+	e := responseError(res)
+	if d.metrics != nil {
+		d.metrics.BackendRPCLatency.With(common.Labels{"method": method}).Observe(float64(time.Since(start)) / 1e6) // in milliseconds
+		if err != nil || e != nil {
+			d.metrics.BackendRPCErrors.With(common.Labels{"method": method}).Inc()
+		}
+	}
+	if err == nil && e != nil {
+		d.conns.callFinished(newRPCError(method, *e))
+	} else {
+		d.conns.callFinished(err)
+	}
+	if err == nil && e != nil && e.Code == rpcErrMethodNotFound {
+		glog.Warning("rpc: method ", method, " is not supported by dcrd, upgrade dcrd to a newer version")
+		return bchain.ErrMethodNotSupported
+	}
+	return err
+}
+
+// responseError returns the dcrd error contained in the decoded response struct or nil
+func responseError(res interface{}) *Error {
+	v := reflect.ValueOf(res)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	f := v.FieldByName("Error")
+	if !f.IsValid() {
+		return nil
+	}
+	if e, ok := f.Interface().(Error); ok && e.Message != "" {
+		return &e
+	}
+	return nil
+}
+
+func (d *DecredRPC) call(method string, req interface{}, res interface{}) error {
 	httpData, err := json.Marshal(req)
 	if err != nil {
 		return err
@@ -681,47 +2051,86 @@ func (d *DecredRPC) Call(req interface{}, res interface{}) error {
 	if err != nil {
 		return err
 	}
+	if timeout := d.methodTimeout(method); timeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		httpReq = httpReq.WithContext(ctx)
+	}
 	httpReq.SetBasicAuth(d.rpcUser, d.rpcPassword)
-	httpRes, err := d.client.Do(httpReq)
+	// the request uses its connection until the response is read
+	d.conns.requestStarted()
+	defer d.conns.requestFinished()
+	httpRes, err := d.httpClient().Do(httpReq)
 	// in some cases the httpRes can contain data even if it returns error
 	// see http://devs.cloudimmunity.com/gotchas-and-common-mistakes-in-go-golang/
 	if httpRes != nil {
 		defer httpRes.Body.Close()
 	}
 	if err != nil {
-		return err
+		return newNetworkError(method, err)
 	}
 
 	// if server returns HTTP error code it might not return json with response
 	// handle both cases
 	if httpRes.StatusCode != 200 {
-		err = safeDecodeResponse(httpRes.Body, &res)
+		err = safeDecodeResponse(httpRes.Body, &res, method, d.config.MaxResponseSize)
 		if err != nil {
-			return errors.Errorf("%v %v", httpRes.Status, err)
+			return &DecredRPCError{Code: ErrCodeHTTP, Method: method, Message: httpRes.Status}
 		}
 		return nil
 	}
-	return safeDecodeResponse(httpRes.Body, &res)
+	return safeDecodeResponse(httpRes.Body, &res, method, d.config.MaxResponseSize)
 }
 
-func safeDecodeResponse(body io.ReadCloser, res *interface{}) (err error) {
+// methodTimeout returns the timeout of the rpc method, falling back to the global rpc_timeout
+func (d *DecredRPC) methodTimeout(method string) time.Duration {
+	if t, ok := d.config.RPCTimeouts[method]; ok {
+		return time.Duration(t) * time.Second
+	}
+	return time.Duration(d.config.RPCTimeout) * time.Second
+}
+
+// requestMethod returns the name of the rpc method of the request, used in error messages
+func requestMethod(req interface{}) string {
+	switch r := req.(type) {
+	case GenericCmd:
+		return r.Method
+	case *GenericCmd:
+		return r.Method
+	case []GenericCmd:
+		// batched requests contain the same method
+		if len(r) > 0 {
+			return r[0].Method
+		}
+	}
+	return fmt.Sprintf("%T", req)
+}
+
+func safeDecodeResponse(body io.ReadCloser, res *interface{}, method string, maxSize int64) (err error) {
 	var data []byte
 	defer func() {
 		if r := recover(); r != nil {
-			glog.Error("unmarshal json recovered from panic: ", r, "; data: ", string(data))
+			glog.Error("unmarshal json of ", method, " recovered from panic: ", r, "; data: ", string(data))
 			debug.PrintStack()
 			if len(data) > 0 && len(data) < 2048 {
-				err = errors.Errorf("Error: %v", string(data))
+				err = newResponseError(method, "Error: %v", string(data))
 			} else {
-				err = errors.New("Internal error")
+				err = newResponseError(method, "Internal error")
 			}
 		}
 	}()
-	data, err = ioutil.ReadAll(body)
+	// read one byte over the limit to detect too large responses
+	data, err = ioutil.ReadAll(io.LimitReader(body, maxSize+1))
 	if err != nil {
-		return err
+		return newNetworkError(method, err)
+	}
+	if int64(len(data)) > maxSize {
+		return newResponseError(method, "response exceeds the maximum size of %d bytes", maxSize)
 	}
 
 	error := json.Unmarshal(data, res)
-	return error
+	if error != nil {
+		return newResponseError(method, "%v", error)
+	}
+	return nil
 }
@@ -0,0 +1,1586 @@
+// +build unittest
+
+package dcr
+
+import (
+	"blockbook/bchain"
+	"blockbook/bchain/coins/btc"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/juju/errors"
+	"github.com/martinboehm/btcutil/chaincfg"
+
+	dch "github.com/decred/dcrd/chaincfg"
+)
+
+const (
+	testGenesisHash = "298e5cc3d985bfe7f81dc135f360abe089edd4396b86d2de66b0cef42b21d980"
+	testGenesisTime = 1454954400
+	testZeroHash    = "0000000000000000000000000000000000000000000000000000000000000000"
+)
+
+// testRPCBackend emulates dcrd, the result of the rpc method is returned by the handler
+func testRPCBackend(t testing.TB, handler func(method string, params []json.RawMessage) interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int               `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": req.ID, "result": handler(req.Method, req.Params)})
+	}))
+}
+
+// testGenesisBackend emulates the responses of dcrd for the genesis block
+func testGenesisBackend(t *testing.T) *httptest.Server {
+	return testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		switch method {
+		case "getblockhash":
+			return testGenesisHash
+		case "getblock":
+			return map[string]interface{}{
+				"hash":              testGenesisHash,
+				"confirmations":     100,
+				"size":              300,
+				"height":            0,
+				"tx":                []string{"e7dfbceac9fccd6025c70a1dfa9302b3e7b5aa22fa51c98a69164ad403d60a2c"},
+				"time":              testGenesisTime,
+				"voters":            0,
+				"previousblockhash": testZeroHash,
+				"nextblockhash":     "000000000000437482b6d47f82f374cde539440ddb108b0a76886f0d87d126b9",
+			}
+		}
+		t.Errorf("Unexpected rpc method %v", method)
+		return nil
+	})
+}
+
+func newTestDecredRPC(url string) *DecredRPC {
+	return &DecredRPC{
+		BitcoinRPC: &btc.BitcoinRPC{BaseChain: &bchain.BaseChain{Parser: testParser}},
+		rpcURL:     url,
+		config:     &Configuration{MaxResponseSize: defaultMaxResponseSize},
+	}
+}
+
+func TestDecredRPC_GetBlock_Genesis(t *testing.T) {
+	s := testGenesisBackend(t)
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+
+	tests := []struct {
+		name   string
+		hash   string
+		height uint32
+	}{
+		{name: "by height", height: 0},
+		{name: "by hash", hash: testGenesisHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.GetBlock(tt.hash, tt.height)
+			if err != nil {
+				t.Fatalf("GetBlock() error = %v", err)
+			}
+			if got.Hash != testGenesisHash || got.Height != 0 || got.Time != testGenesisTime || got.Prev != testZeroHash {
+				t.Errorf("GetBlock() header = %+v", got.BlockHeader)
+			}
+			if len(got.Txs) != 0 {
+				t.Errorf("GetBlock() returned %d transactions, want 0", len(got.Txs))
+			}
+		})
+	}
+}
+
+func TestDecredRPC_GetBlock_StakeTree(t *testing.T) {
+	const coinbaseTxid = "e7dfbceac9fccd6025c70a1dfa9302b3e7b5aa22fa51c98a69164ad403d60a2c"
+	const ticketTxid = "a56e8a1c1a0ccb5b5d28a59a5f21a8e4f1b1d0e0b2a4d6c8e0f1a3b5c7d9e1f3"
+	block := testGenesisBlockResult()
+	block.Result.RawTx = []RawTx{{Txid: coinbaseTxid, Vin: []Vin{{Coinbase: "0000"}}, Vout: []Vout{
+		{Value: 0, N: 0, ScriptPubKey: ScriptPubKeyResult{Hex: "801679e98561ada96caec2949a5d41c4cab3851eb740d951c10ecbcf265c1fd9"}},
+	}}}
+	block.Result.RawSTx = []RawTx{{Txid: ticketTxid, Version: 1, Vin: []Vin{{Txid: coinbaseTxid, Vout: 0}}, Vout: []Vout{
+		{Value: 150, N: 0, ScriptPubKey: ScriptPubKeyResult{Hex: "ba76a914f5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d488ac"}},
+		{Value: 0, N: 1, ScriptPubKey: ScriptPubKeyResult{Hex: "6a1ef5eba6730a4052ba7ec5a8d0fef8e7a8d5e7a9d400e1f505000000000058"}},
+		{Value: 0, N: 2, ScriptPubKey: ScriptPubKeyResult{Hex: "bd76a914000000000000000000000000000000000000000088ac"}},
+	}}}
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		// both trees are returned by one verbose getblock call
+		if method != "getblock" || len(params) != 3 || string(params[2]) != "true" {
+			t.Errorf("Unexpected rpc method %v %s", method, params)
+		}
+		return block.Result
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+
+	got, err := d.GetBlock(testGenesisHash, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Txs) != 1 || got.Txs[0].Txid != coinbaseTxid {
+		t.Fatalf("GetBlock() regular transactions = %+v, want the coinbase", got.Txs)
+	}
+	if len(got.StakeTxs) != 1 || got.StakeTxs[0].Txid != ticketTxid {
+		t.Fatalf("GetBlock() stake transactions = %+v, want the ticket", got.StakeTxs)
+	}
+	if _, ok := testParser.GetTicketCommitment(&got.StakeTxs[0]); !ok {
+		t.Error("GetTicketCommitment() of the stake transaction, want the ticket commitment")
+	}
+}
+
+func TestGetBlockChainInfoResult_Agendas(t *testing.T) {
+	data := `{"result":{"chain":"mainnet","blocks":600000,"deployments":{
+		"treasury":{"status":"active","since":552448,"starttime":1596240000,"expiretime":1627776000},
+		"headercommitments":{"status":"active","since":431488,"starttime":1567641600,"expiretime":1599264000},
+		"maxblocksize":{"status":"failed","starttime":1493164800,"expiretime":1524700800}}},"error":null,"id":1}`
+	var r GetBlockChainInfoResult
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		t.Fatal(err)
+	}
+	want := DecredAgendas{
+		{ID: "headercommitments", Status: "active", Since: 431488, StartTime: 1567641600, ExpireTime: 1599264000},
+		{ID: "maxblocksize", Status: "failed", StartTime: 1493164800, ExpireTime: 1524700800},
+		{ID: "treasury", Status: "active", Since: 552448, StartTime: 1596240000, ExpireTime: 1627776000},
+	}
+	if !reflect.DeepEqual(r.Result.Agendas, want) {
+		t.Errorf("Agendas = %+v, want %+v", r.Result.Agendas, want)
+	}
+}
+
+func Test_validateBlockTxs(t *testing.T) {
+	coinbase := bchain.Tx{Txid: "cb", Vin: []bchain.Vin{{Coinbase: "00"}}}
+	transfer := bchain.Tx{Txid: "tx1", Vin: []bchain.Vin{{Txid: "prev"}}}
+	block := func(tx, stx []string, voters uint16) *GetBlockResult {
+		b := &GetBlockResult{}
+		b.Result.Hash = "block"
+		b.Result.Tx = tx
+		b.Result.STx = stx
+		b.Result.Voters = voters
+		return b
+	}
+	tests := []struct {
+		name    string
+		block   *GetBlockResult
+		txs     []bchain.Tx
+		wantErr string
+	}{
+		{"valid", block([]string{"cb", "tx1"}, []string{"v1", "v2", "v3"}, 3), []bchain.Tx{coinbase, transfer}, ""},
+		{"missing transaction", block([]string{"cb", "tx1"}, nil, 0), []bchain.Tx{coinbase}, "Transaction count"},
+		{"missing votes", block([]string{"cb"}, []string{"v1", "v2"}, 3), []bchain.Tx{coinbase}, "Transaction count"},
+		{"duplicate regular txid", block([]string{"cb", "tx1", "tx1"}, nil, 0), []bchain.Tx{coinbase, transfer, transfer}, "Duplicate txid"},
+		{"duplicate txid in both trees", block([]string{"cb", "tx1"}, []string{"tx1"}, 1), []bchain.Tx{coinbase, transfer}, "Duplicate txid"},
+		{"no coinbase", block([]string{"tx1", "cb"}, nil, 0), []bchain.Tx{transfer, coinbase}, "Coinbase"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBlockTxs(tt.block, tt.txs)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateBlockTxs() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("validateBlockTxs() error = %v, want %v error", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_merklePath(t *testing.T) {
+	for n := 1; n <= 7; n++ {
+		leaves := make([]chainhash.Hash, n)
+		for i := range leaves {
+			leaves[i] = chainhash.HashH([]byte{byte(i)})
+		}
+		_, root := merklePath(leaves, -1)
+		if n == 1 && root != leaves[0] {
+			t.Errorf("merklePath() root of single leaf = %v, want %v", root, leaves[0])
+		}
+		for index := 0; index < n; index++ {
+			path, r := merklePath(leaves, index)
+			if r != root {
+				t.Fatalf("merklePath(%d, %d) root = %v, want %v", n, index, r, root)
+			}
+			// fold the path from the leaf to the root
+			h, i := leaves[index], index
+			for j := range path {
+				if i%2 == 0 {
+					h = hashMerkleBranches(&h, &path[j])
+				} else {
+					h = hashMerkleBranches(&path[j], &h)
+				}
+				i /= 2
+			}
+			if h != root {
+				t.Errorf("merklePath(%d, %d) path does not lead to the root", n, index)
+			}
+		}
+	}
+	if _, root := merklePath(nil, -1); root != (chainhash.Hash{}) {
+		t.Errorf("merklePath() root of empty tree = %v, want zero hash", root)
+	}
+}
+
+func TestDecredRPC_GetTxMerkleProof_Genesis(t *testing.T) {
+	genesis := dch.MainNetParams.GenesisBlock
+	var coinbase bytes.Buffer
+	if err := genesis.Transactions[0].Serialize(&coinbase); err != nil {
+		t.Fatal(err)
+	}
+	const txid = "e7dfbceac9fccd6025c70a1dfa9302b3e7b5aa22fa51c98a69164ad403d60a2c"
+	block := testGenesisBlockResult()
+	block.Result.RawTx = []RawTx{{Hex: hex.EncodeToString(coinbase.Bytes()), Txid: txid}}
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		if method != "getblock" || len(params) != 3 || string(params[2]) != "true" {
+			t.Errorf("Unexpected rpc method %v %s", method, params)
+		}
+		return block.Result
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+
+	proof, err := d.GetTxMerkleProof(txid, testGenesisHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the leaf is the full hash of the coinbase, the txid is only the hash of its prefix
+	if proof.RegularRoot != genesis.Header.MerkleRoot.String() || proof.RegularRoot == txid {
+		t.Errorf("GetTxMerkleProof() regular root = %v, want %v", proof.RegularRoot, genesis.Header.MerkleRoot)
+	}
+	if proof.Tree != TreeRegular || proof.Index != 0 || len(proof.Path) != 0 || proof.StakeRoot != testZeroHash {
+		t.Errorf("GetTxMerkleProof() = %+v", proof)
+	}
+	var header bytes.Buffer
+	if err := genesis.Header.Serialize(&header); err != nil {
+		t.Fatal(err)
+	}
+	if proof.Header != hex.EncodeToString(header.Bytes()) {
+		t.Errorf("GetTxMerkleProof() header = %v, want %x", proof.Header, header.Bytes())
+	}
+
+	block.Result.RawTx[0].Txid = testZeroHash
+	if _, err := d.GetTxMerkleProof(testZeroHash, testGenesisHash); err == nil {
+		t.Error("GetTxMerkleProof() with mismatched txid, expected error")
+	}
+}
+
+// testReorgBackend emulates dcrd after the reorganization of the blocks 10-12,
+// the main chain has blocks main-0...main-13, the orphaned blocks are orphan-10...orphan-12
+func testReorgBackend(t *testing.T) *httptest.Server {
+	return testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		switch method {
+		case "getblockcount":
+			return 13
+		case "getblockhash":
+			var height int
+			if err := json.Unmarshal(params[0], &height); err != nil {
+				t.Fatal(err)
+			}
+			return fmt.Sprintf("main-%d", height)
+		case "getblockheader":
+			var hash string
+			if err := json.Unmarshal(params[0], &hash); err != nil {
+				t.Fatal(err)
+			}
+			var height int
+			prev := "main-9"
+			if _, err := fmt.Sscanf(hash, "orphan-%d", &height); err != nil {
+				if _, err = fmt.Sscanf(hash, "main-%d", &height); err != nil {
+					t.Fatal(err)
+				}
+				prev = fmt.Sprintf("main-%d", height-1)
+			} else if height > 10 {
+				prev = fmt.Sprintf("orphan-%d", height-1)
+			}
+			return map[string]interface{}{"hash": hash, "height": height, "previousblockhash": prev}
+		}
+		t.Errorf("Unexpected rpc method %v", method)
+		return nil
+	})
+}
+
+func TestDecredRPC_CheckForReorg(t *testing.T) {
+	s := testReorgBackend(t)
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+
+	tests := []struct {
+		name       string
+		hash       string
+		height     uint32
+		wantReorg  bool
+		wantHeight uint32
+	}{
+		{name: "orphaned tip", hash: "orphan-12", height: 12, wantReorg: true, wantHeight: 9},
+		{name: "orphaned first block", hash: "orphan-10", height: 10, wantReorg: true, wantHeight: 9},
+		{name: "main chain", hash: "main-12", height: 12, wantReorg: false, wantHeight: 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reorg, height, err := d.CheckForReorg(tt.hash, tt.height)
+			if err != nil {
+				t.Fatalf("CheckForReorg() error = %v", err)
+			}
+			if reorg != tt.wantReorg || height != tt.wantHeight {
+				t.Errorf("CheckForReorg() = %v, %v, want %v, %v", reorg, height, tt.wantReorg, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestDecredWalletRPC_GetMempoolTransactions(t *testing.T) {
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		if method != "listtransactions" {
+			t.Errorf("Unexpected rpc method %v", method)
+			return nil
+		}
+		return []map[string]interface{}{
+			{"txid": "tx1", "confirmations": 0},
+			{"txid": "tx2", "confirmations": 3},
+			{"txid": "tx1", "confirmations": 0},
+			{"txid": "tx3", "confirmations": 0},
+		}
+	})
+	defer s.Close()
+	w := &DecredWalletRPC{DecredRPC: newTestDecredRPC(s.URL)}
+	got, err := w.GetMempoolTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"tx1", "tx3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetMempoolTransactions() = %v, want %v", got, want)
+	}
+	if GetDecredRPC(w) != w.DecredRPC {
+		t.Error("GetDecredRPC() does not return the embedded DecredRPC")
+	}
+}
+
+func TestDecredRPC_SendMany(t *testing.T) {
+	hash := bytes.Repeat([]byte{0x11}, 20)
+	mainnet, err := dcrutil.NewAddressPubKeyHash(hash, &dch.MainNetParams, dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testnet, err := dcrutil.NewAddressPubKeyHash(hash, &dch.TestNet3Params, dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		if method != "sendmany" {
+			t.Errorf("Unexpected rpc method %v", method)
+			return nil
+		}
+		calls++
+		var amounts map[string]float64
+		if err := json.Unmarshal(params[1], &amounts); err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]float64{mainnet.String(): 1.5}; !reflect.DeepEqual(amounts, want) {
+			t.Errorf("sendmany amounts = %v, want %v", amounts, want)
+		}
+		return "txid"
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	if _, err := d.SendMany("default", map[string]float64{mainnet.String(): 1.5}, 1, ""); err == nil {
+		t.Error("SendMany() expected error without the wallet backend")
+	}
+	d.config.BackendType = BackendTypeWallet
+	got, err := d.SendMany("default", map[string]float64{mainnet.String(): 1.5}, 1, "payout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "txid" {
+		t.Errorf("SendMany() = %v, want txid", got)
+	}
+	for _, address := range []string{testnet.String(), "DsInvalid"} {
+		_, err = d.SendMany("default", map[string]float64{mainnet.String(): 1, address: 1}, 1, "")
+		if ae, ok := err.(*InvalidAddressError); !ok || ae.Address != address {
+			t.Errorf("SendMany() with %v error = %v, want InvalidAddressError", address, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("sendmany called %d times, want 1", calls)
+	}
+}
+
+func TestDecredRPC_GetDecredNetworkStats(t *testing.T) {
+	calls := 0
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		calls++
+		switch method {
+		case "getdifficulty":
+			return 1
+		case "getstakedifficulty":
+			return map[string]interface{}{"current": 150.5, "next": 152.25}
+		case "getticketpoolvalue":
+			return 6150000.75
+		case "getrawmempool":
+			return []string{"tx1", "tx2"}
+		case "getbestblock":
+			return map[string]interface{}{"hash": testGenesisHash, "height": 0}
+		case "getblockheader":
+			return map[string]interface{}{"hash": testGenesisHash, "height": 0, "poolsize": 40960}
+		}
+		t.Errorf("Unexpected rpc method %v", method)
+		return nil
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	got, err := d.GetDecredNetworkStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TicketPrice != 150.5 || got.NextTicketPrice != 152.25 || got.TicketPoolSize != 40960 || got.TicketPoolValue != 6150000.75 || got.MempoolSize != 2 {
+		t.Errorf("Unexpected network stats %+v", got)
+	}
+	// mainnet target time per block is 5 minutes
+	if got.HashRate != float64(1<<32)/300 {
+		t.Errorf("HashRate = %v, want %v", got.HashRate, float64(1<<32)/300)
+	}
+	n := calls
+	if _, err = d.GetDecredNetworkStats(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != n {
+		t.Errorf("The cached network stats were not used, %d rpc calls", calls-n)
+	}
+}
+
+func TestDecredRPC_GetMiningInfo(t *testing.T) {
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		if method != "getmininginfo" {
+			t.Errorf("Unexpected rpc method %v", method)
+			return nil
+		}
+		return map[string]interface{}{
+			"blocks":           450000,
+			"currentblocksize": 4120,
+			"currentblocktx":   3,
+			"difficulty":       2.5e10,
+			"stakedifficulty":  16030000000,
+			"errors":           "",
+			"generate":         false,
+			"genproclimit":     -1,
+			"hashespersec":     0,
+			"networkhashps":    3.1e17,
+			"pooledtx":         12,
+			"testnet":          false,
+		}
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	got, err := d.GetMiningInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &DecredMiningInfo{Blocks: 450000, CurrentBlockSize: 4120, CurrentBlockTx: 3, Difficulty: 2.5e10, NetworkHashPS: 3.1e17, PooledTx: 12}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetMiningInfo() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecredRPC_GetParticipationRate(t *testing.T) {
+	headers := 0
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		switch method {
+		case "getbestblock":
+			return map[string]interface{}{"hash": "h-5000", "height": 5000}
+		case "getblockheader":
+			headers++
+			var hash string
+			if err := json.Unmarshal(params[0], &hash); err != nil {
+				t.Fatal(err)
+			}
+			var height int
+			fmt.Sscanf(hash, "h-%d", &height)
+			// every other block misses a vote
+			voters := 5 - height%2
+			return map[string]interface{}{
+				"hash":              hash,
+				"height":            height,
+				"voters":            voters,
+				"freshstake":        2,
+				"previousblockhash": fmt.Sprintf("h-%d", height-1),
+			}
+		}
+		t.Errorf("Unexpected rpc method %v", method)
+		return nil
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	got, err := d.GetParticipationRate(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &DecredParticipationRate{Height: 5000, WindowSize: 4, AvgVoters: 4.5, TicketsPerBlock: 2, ParticipationPct: 90}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetParticipationRate() = %+v, want %+v", got, want)
+	}
+	// the rate of the same tip is served from the cache
+	if got, err = d.GetParticipationRate(4); err != nil || !reflect.DeepEqual(got, want) || headers != 4 {
+		t.Errorf("GetParticipationRate() cached = %+v, %v, %d headers fetched, want 4", got, err, headers)
+	}
+	if _, err = d.GetParticipationRate(0); err == nil {
+		t.Error("GetParticipationRate(0) expected error")
+	}
+}
+
+func TestDecredRPC_GetBestBlockHash(t *testing.T) {
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		if method != "getbestblockhash" {
+			t.Errorf("Unexpected rpc method %v", method)
+			return nil
+		}
+		return testGenesisHash
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	got, err := d.GetBestBlockHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != testGenesisHash {
+		t.Errorf("GetBestBlockHash() = %v, want %v", got, testGenesisHash)
+	}
+}
+
+// testIndexedChain is the index of blockbook with the blocks h-{height}, the orphans replace the blocks at their heights
+type testIndexedChain struct {
+	best    uint32
+	orphans map[uint32]string
+}
+
+func (c *testIndexedChain) GetBestBlock() (uint32, string, error) {
+	hash, _ := c.GetBlockHash(c.best)
+	return c.best, hash, nil
+}
+
+func (c *testIndexedChain) GetBlockHash(height uint32) (string, error) {
+	if h, found := c.orphans[height]; found {
+		return h, nil
+	}
+	return fmt.Sprintf("h-%d", height), nil
+}
+
+func TestDecredRPC_VerifyChain(t *testing.T) {
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		switch method {
+		case "getblockcount":
+			return 5000
+		case "getblockhash":
+			var height int
+			if err := json.Unmarshal(params[0], &height); err != nil {
+				t.Fatal(err)
+			}
+			return fmt.Sprintf("h-%d", height)
+		}
+		t.Errorf("Unexpected rpc method %v", method)
+		return nil
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	tests := []struct {
+		name         string
+		index        *testIndexedChain
+		depth        int
+		wantMismatch uint32
+		wantOk       bool
+	}{
+		{name: "match", index: &testIndexedChain{best: 5000, orphans: map[uint32]string{4996: "orphan"}}, depth: 4, wantOk: true},
+		{name: "orphan", index: &testIndexedChain{best: 5000, orphans: map[uint32]string{4997: "orphan", 4996: "orphan"}}, depth: 4, wantMismatch: 4997},
+		{name: "index above dcrd", index: &testIndexedChain{best: 5002, orphans: map[uint32]string{5001: "orphan"}}, depth: 2, wantOk: true},
+		{name: "short chain", index: &testIndexedChain{best: 1}, depth: 10, wantOk: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mismatch, ok, err := d.VerifyChain(tt.index, tt.depth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mismatch != tt.wantMismatch || ok != tt.wantOk {
+				t.Errorf("VerifyChain() = %v, %v, want %v, %v", mismatch, ok, tt.wantMismatch, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestDecredRPC_GetBlockSizeStats(t *testing.T) {
+	sizes := map[int]int{5000: 3000, 4999: 1000, 4998: 8000, 4997: 2000}
+	calls := 0
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		switch method {
+		case "getbestblock":
+			calls++
+			return map[string]interface{}{"hash": "h-5000", "height": 5000}
+		case "getblockheader":
+			var hash string
+			if err := json.Unmarshal(params[0], &hash); err != nil {
+				t.Fatal(err)
+			}
+			var height int
+			fmt.Sscanf(hash, "h-%d", &height)
+			return map[string]interface{}{
+				"hash":              hash,
+				"height":            height,
+				"size":              sizes[height],
+				"previousblockhash": fmt.Sprintf("h-%d", height-1),
+			}
+		}
+		t.Errorf("Unexpected rpc method %v", method)
+		return nil
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	tests := []struct {
+		count int
+		want  DecredBlockSizeStats
+	}{
+		{3, DecredBlockSizeStats{Height: 5000, Count: 3, Min: 1000, Max: 8000, Avg: 4000, Median: 3000}},
+		{4, DecredBlockSizeStats{Height: 5000, Count: 4, Min: 1000, Max: 8000, Avg: 3500, Median: 2500}},
+	}
+	for _, tt := range tests {
+		got, err := d.GetBlockSizeStats(tt.count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt.want.Updated = got.Updated
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("GetBlockSizeStats(%d) = %+v, want %+v", tt.count, *got, tt.want)
+		}
+	}
+	// the cached statistics are returned
+	if _, err := d.GetBlockSizeStats(3); err != nil {
+		t.Fatal(err)
+	}
+	// the sizes of the largest window are fetched once for all the counts
+	if calls != 1 {
+		t.Errorf("getbestblock called %d times, want 1", calls)
+	}
+	if _, err := d.GetBlockSizeStats(0); err == nil {
+		t.Error("GetBlockSizeStats(0) expected error")
+	}
+}
+
+func TestDecredRPC_GetAddressUnspent(t *testing.T) {
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		switch method {
+		case "getblockcount":
+			return 1000
+		case "getaddressunspent":
+			return []map[string]interface{}{
+				{"txid": "tip", "vout": 0, "height": 1000},
+				{"txid": "old", "vout": 1, "height": 1, "confirmations": 5},
+				{"txid": "new", "vout": 0, "height": 1001},
+				{"txid": "mempool", "vout": 0, "height": 0},
+			}
+		}
+		t.Errorf("Unexpected rpc method %v", method)
+		return nil
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	got, err := d.GetAddressUnspent([]string{"DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"tip": 1, "old": 1000, "new": 1, "mempool": 0}
+	if len(got) != len(want) {
+		t.Fatalf("GetAddressUnspent() returned %d outputs, want %d", len(got), len(want))
+	}
+	for _, u := range got {
+		if u.Confirmations != want[u.Txid] {
+			t.Errorf("GetAddressUnspent() %v confirmations = %d, want %d", u.Txid, u.Confirmations, want[u.Txid])
+		}
+	}
+}
+
+func TestDecredRPC_GetPrevOut(t *testing.T) {
+	calls := 0
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		if method != "getrawtransaction" {
+			t.Errorf("Unexpected rpc method %v", method)
+			return nil
+		}
+		calls++
+		var txid string
+		if err := json.Unmarshal(params[0], &txid); err != nil {
+			t.Fatal(err)
+		}
+		return RawTx{
+			Txid: txid,
+			Vin:  []Vin{{Coinbase: "00"}},
+			Vout: []Vout{
+				{Value: 1, N: 0, ScriptPubKey: ScriptPubKeyResult{Hex: "76a914f5916158e3e2c4551c1796708db8367207ed13bb88ac"}},
+				{Value: 2, N: 1, ScriptPubKey: ScriptPubKeyResult{Hex: "a914f0b4e85100aee1a996f22915eb3c3f764d53779a87"}},
+			},
+		}
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	d.prevOuts = newPrevOutCache(2)
+
+	tx := &bchain.Tx{Vin: []bchain.Vin{
+		{Txid: "tx1", Vout: 1},
+		{Txid: "tx1", Vout: 0},
+		{Coinbase: "00"},
+	}}
+	got, err := d.ResolveInputs(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("ResolveInputs() made %d rpc calls, want 1", calls)
+	}
+	if len(got) != 3 || got[0].ValueSat.Int64() != 2e8 || got[1].ValueSat.Int64() != 1e8 || got[2] != nil {
+		t.Fatalf("ResolveInputs() = %+v", got)
+	}
+	if hex.EncodeToString(got[0].ScriptPubKey) != "a914f0b4e85100aee1a996f22915eb3c3f764d53779a87" {
+		t.Errorf("ResolveInputs() script = %x", got[0].ScriptPubKey)
+	}
+	// the outputs of tx1 are cached
+	got1, err := d.GetPrevOut("tx1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got1.ValueSat.Int64() != 1e8 || calls != 1 {
+		t.Errorf("GetPrevOut() = %+v, %d rpc calls, want 1", got1, calls)
+	}
+	// the outputs of tx2 evict the outputs of tx1 from the cache of size 2
+	if _, err = d.GetPrevOut("tx2", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = d.GetPrevOut("tx1", 0); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("GetPrevOut() made %d rpc calls, want 3", calls)
+	}
+	// the outputs of the disconnected transactions are fetched again
+	d.RemoveDisconnectedTxs([]string{"tx1"})
+	if _, err = d.GetPrevOut("tx1", 1); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 4 || len(d.prevOuts.entries) != 2 || len(d.prevOuts.txKeys) != 1 {
+		t.Errorf("GetPrevOut() after RemoveDisconnectedTxs made %d rpc calls, %d cached outputs, want 4 calls, 2 outputs", calls, len(d.prevOuts.entries))
+	}
+	if _, err = d.GetPrevOut("tx1", 5); err == nil {
+		t.Error("GetPrevOut() of the missing output expected error")
+	}
+}
+
+func TestDecredRPC_httpClient(t *testing.T) {
+	d := newTestDecredRPC("")
+	if c := d.httpClient(); c != http.DefaultClient {
+		t.Errorf("httpClient() without the pool = %v, want http.DefaultClient", c)
+	}
+	d.clients = newHTTPClients(3, nil)
+	used := make(map[*http.Client]int)
+	for i := 0; i < 9; i++ {
+		used[d.httpClient()]++
+	}
+	if len(used) != 3 {
+		t.Fatalf("httpClient() used %d clients, want 3", len(used))
+	}
+	for c, n := range used {
+		if n != 3 {
+			t.Errorf("httpClient() returned client %p %d times, want 3", c, n)
+		}
+	}
+	if n := len(newHTTPClients(0, nil)); n != 1 {
+		t.Errorf("newHTTPClients(0, nil) returned %d clients, want 1", n)
+	}
+}
+
+func TestDecredRPC_errors(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenericCmd
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		switch req.Method {
+		case "getblockhash":
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": req.ID, "error": Error{Code: -1, Message: "Block number out of range"}})
+		case "getrawtransaction":
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": req.ID, "error": Error{Code: rpcErrTxNotFound, Message: "No information available about transaction"}})
+		default:
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		}
+	}))
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+
+	_, err := d.GetBlockHash(1000000)
+	if e, ok := errors.Cause(err).(*DecredRPCError); !ok || e.Code != -1 || e.Method != "getblockhash" {
+		t.Errorf("GetBlockHash() error = %#v, want DecredRPCError of getblockhash", errors.Cause(err))
+	}
+	if IsNotFound(err) || IsNetworkError(err) {
+		t.Errorf("GetBlockHash() error %v is classified as not found or network error", err)
+	}
+
+	_, err = d.GetTransaction("ab")
+	if !IsNotFound(err) || IsNetworkError(err) {
+		t.Errorf("GetTransaction() error %v is not classified as not found", err)
+	}
+
+	_, err = d.GetBestBlockHash()
+	if rpcErrorCode(err) != ErrCodeHTTP || !IsNetworkError(err) {
+		t.Errorf("GetBestBlockHash() error %v is not an http error", err)
+	}
+
+	s.Close()
+	_, err = d.GetBestBlockHash()
+	if rpcErrorCode(err) != ErrCodeNetwork || !IsNetworkError(err) || IsNotFound(err) {
+		t.Errorf("GetBestBlockHash() error %v is not a network error", err)
+	}
+}
+
+func TestDecredRPC_FundTestAddress(t *testing.T) {
+	testAddress := func(t *testing.T, np *dch.Params) string {
+		a, err := dcrutil.NewAddressPubKeyHash(make([]byte, 20), np, dcrec.STEcdsaSecp256k1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a.Address()
+	}
+	faucet := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("amount") != "5" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid amount"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"txid": "faucettx"})
+	}))
+	defer faucet.Close()
+	tests := []struct {
+		name      string
+		np        *dch.Params
+		amount    string
+		want      DecredFaucetResult
+		wantError bool
+	}{
+		{name: "simnet", np: &dch.SimNetParams, want: DecredFaucetResult{Network: "simnet", Blocks: []string{"minedblock"}}},
+		{name: "testnet", np: &dch.TestNet3Params, amount: "5", want: DecredFaucetResult{Network: "testnet3", Txid: "faucettx"}},
+		{name: "testnet faucet error", np: &dch.TestNet3Params, amount: "1000", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+				switch method {
+				case "generatetoaddress":
+					return []string{"minedblock"}
+				}
+				t.Errorf("Unexpected rpc method %v", method)
+				return nil
+			})
+			defer s.Close()
+			d := newTestDecredRPC(s.URL)
+			d.Parser = NewDecredParser(GetChainParams(tt.np.Name), &btc.Configuration{})
+			address := testAddress(t, tt.np)
+			if _, err = d.FundTestAddress(address, tt.amount); err != ErrFaucetDisabled {
+				t.Errorf("FundTestAddress() of disabled faucet error = %v", err)
+			}
+			d.config.EnableTestnetFaucet = true
+			d.config.TestnetFaucetURL = faucet.URL
+			if !d.TestnetFaucetEnabled() {
+				t.Error("TestnetFaucetEnabled() = false")
+			}
+			got, err := d.FundTestAddress(address, tt.amount)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("FundTestAddress() = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.want.Address = address
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("FundTestAddress() = %+v, want %+v", *got, tt.want)
+			}
+			if _, err = d.FundTestAddress(testAddress(t, &dch.MainNetParams), tt.amount); err == nil {
+				t.Error("FundTestAddress() of mainnet address succeeded")
+			}
+		})
+	}
+
+	d := newTestDecredRPC("")
+	d.config.EnableTestnetFaucet = true
+	if d.TestnetFaucetEnabled() {
+		t.Error("TestnetFaucetEnabled() on mainnet = true")
+	}
+	if _, err := d.FundTestAddress(testAddress(t, &dch.MainNetParams), ""); err != bchain.ErrFeatureUnsupported {
+		t.Errorf("FundTestAddress() on mainnet error = %v, want %v", err, bchain.ErrFeatureUnsupported)
+	}
+}
+
+func TestDecredRPC_EstimateSmartFee(t *testing.T) {
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		if method != "estimatesmartfee" {
+			t.Errorf("Unexpected rpc method %v", method)
+			return nil
+		}
+		return map[string]interface{}{"feerate": 0.0001, "blocks": 2}
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	got, err := d.EstimateSmartFee(2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 0.0001 DCR per 1000 bytes
+	if got.Int64() != 10000 {
+		t.Errorf("EstimateSmartFee() = %v, want 10000", got.String())
+	}
+}
+
+func TestDecredRPC_GetBlockInfo(t *testing.T) {
+	extraData := "0d3e5a61" + strings.Repeat("00", 28)
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		if method != "getblock" {
+			t.Errorf("Unexpected rpc method %v", method)
+			return nil
+		}
+		return map[string]interface{}{
+			"hash":      testGenesisHash,
+			"height":    0,
+			"tx":        []string{"e7dfbceac9fccd6025c70a1dfa9302b3e7b5aa22fa51c98a69164ad403d60a2c"},
+			"sbits":     0.0002,
+			"extradata": extraData,
+		}
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	got, err := d.GetBlockInfo(testGenesisHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ExtraData != extraData || got.StakeDifficulty != "0.0002" || len(got.Txids) != 1 {
+		t.Errorf("GetBlockInfo() = %+v", got)
+	}
+}
+
+func TestDecredRPC_GetBlockInfoRewards(t *testing.T) {
+	calls := 0
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		if method != "getblock" {
+			t.Errorf("Unexpected rpc method %v", method)
+			return nil
+		}
+		calls++
+		return map[string]interface{}{
+			"hash":   "b1",
+			"height": 500000,
+			"rawtx": []RawTx{
+				{Txid: "cb", Vin: []Vin{{Coinbase: "00"}}, Vout: []Vout{{Value: 1.5, N: 0}, {Value: 0.25, N: 1}}},
+				{Txid: "spend", Vin: []Vin{{Txid: "prev"}}, Vout: []Vout{{Value: 9, N: 0}}},
+			},
+			"rawstx": []RawTx{
+				{Txid: "tb", Vin: []Vin{{AmountIn: 0.5}}, Vout: []Vout{{Value: 0.5, N: 0, ScriptPubKey: ScriptPubKeyResult{Hex: "c1"}}}},
+				{Txid: "vote1", Vin: []Vin{{Stakebase: "00", AmountIn: 0.1}, {Txid: "ticket1"}}},
+				{Txid: "vote2", Vin: []Vin{{Stakebase: "00", AmountIn: 0.1}, {Txid: "ticket2"}}},
+			},
+		}
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	bi, r, err := d.GetBlockInfoRewards("b1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("getblock called %d times, want 1", calls)
+	}
+	if bi.Hash != "b1" || bi.Height != 500000 || !reflect.DeepEqual(bi.Txids, []string{"cb", "spend"}) {
+		t.Errorf("GetBlockInfoRewards() block info = %+v", bi)
+	}
+	if r == nil || r.PoW.Int64() != 175000000 || r.Stake.Int64() != 20000000 || r.Treasury.Int64() != 50000000 {
+		t.Errorf("GetBlockInfoRewards() rewards = %+v", r)
+	}
+}
+
+func TestDecredRPC_GetHardForkStatus(t *testing.T) {
+	var last uint32
+	for v := range dch.MainNetParams.Deployments {
+		if v > last {
+			last = v
+		}
+	}
+	calls := 0
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		switch method {
+		case "getblockchaininfo":
+			return map[string]interface{}{"chain": "mainnet", "deployments": map[string]interface{}{
+				"active":   map[string]interface{}{"status": "active", "since": 1000},
+				"lockedin": map[string]interface{}{"status": "lockedin", "since": 2000},
+			}}
+		case "getvoteinfo":
+			calls++
+			var version uint32
+			if err := json.Unmarshal(params[0], &version); err != nil {
+				t.Fatal(err)
+			}
+			if version != last {
+				return map[string]interface{}{"voteversion": version, "agendas": []interface{}{}}
+			}
+			choices := []map[string]interface{}{
+				{"id": "abstain", "isabstain": true, "count": 500},
+				{"id": "no", "isno": true, "count": 100},
+				{"id": "yes", "count": 300},
+			}
+			return map[string]interface{}{"voteversion": version, "agendas": []map[string]interface{}{
+				{"id": "active", "description": "Active agenda", "status": "active", "choices": choices},
+				{"id": "lockedin", "description": "Locked in agenda", "status": "lockedin", "choices": choices},
+				{"id": "started", "description": "Started agenda", "status": "started", "choices": choices[:1]},
+			}}
+		}
+		t.Errorf("Unexpected rpc method %v", method)
+		return nil
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	got, err := d.GetHardForkStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != len(dch.MainNetParams.Deployments) {
+		t.Errorf("GetHardForkStatus() made %d getvoteinfo calls, want %d", calls, len(dch.MainNetParams.Deployments))
+	}
+	want := []DecredHardFork{
+		{AgendaID: "active", Description: "Active agenda", VoteVersion: last, Status: "active", YesPercent: 75, NoPercent: 25, ActivationHeight: 1000},
+		{AgendaID: "lockedin", Description: "Locked in agenda", VoteVersion: last, Status: "lockedin", YesPercent: 75, NoPercent: 25,
+			ActivationHeight: 2000 + int64(dch.MainNetParams.RuleChangeActivationInterval)},
+		{AgendaID: "started", Description: "Started agenda", VoteVersion: last, Status: "started"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetHardForkStatus() = %+v, want %+v", got, want)
+	}
+	// the agendas fetched for the status are cached for the decoding of the votes
+	agendas, err := d.GetVoteAgendas(last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(agendas) != 3 || calls != len(dch.MainNetParams.Deployments) {
+		t.Errorf("GetVoteAgendas() = %+v, %d getvoteinfo calls, want 3 cached agendas", agendas, calls)
+	}
+}
+
+func TestDecredRPC_WaitForNodeSync(t *testing.T) {
+	defer func(interval time.Duration) { nodeSyncPollInterval = interval }(nodeSyncPollInterval)
+	nodeSyncPollInterval = time.Millisecond
+	var polls int32
+	progress := []float64{0.5, 0.9, 0.9999, 1}
+	backend := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		switch method {
+		case "getblockchaininfo":
+			i := int(atomic.AddInt32(&polls, 1)) - 1
+			if i >= len(progress) {
+				i = len(progress) - 1
+			}
+			return map[string]interface{}{"chain": "mainnet", "blocks": 100, "headers": 200, "verificationprogress": progress[i]}
+		case "getnetworkinfo":
+			return map[string]interface{}{"version": 1060000}
+		}
+		return nil
+	})
+	defer backend.Close()
+	d := newTestDecredRPC(backend.URL)
+
+	if err := d.WaitForNodeSync(context.Background(), 0.9999); err != nil {
+		t.Fatalf("WaitForNodeSync() error = %v", err)
+	}
+	if n := atomic.LoadInt32(&polls); n != 3 {
+		t.Errorf("WaitForNodeSync() polled %d times, want 3", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	atomic.StoreInt32(&polls, 0)
+	if err := d.WaitForNodeSync(ctx, 2); err != context.DeadlineExceeded {
+		t.Errorf("WaitForNodeSync() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestDecredRPC_DetectCPFP(t *testing.T) {
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		if method != "getrawmempool" {
+			t.Errorf("Unexpected rpc method %v", method)
+			return nil
+		}
+		return map[string]interface{}{
+			"parent":     map[string]interface{}{"fee": json.Number("0.00001"), "size": 1000, "depends": []string{}},
+			"child":      map[string]interface{}{"fee": json.Number("0.0001"), "size": 250, "depends": []string{"parent"}},
+			"grandchild": map[string]interface{}{"fee": json.Number("0.000001"), "size": 250, "depends": []string{"child"}},
+			"unrelated":  map[string]interface{}{"fee": json.Number("0.01"), "size": 250, "depends": []string{}},
+		}
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+
+	got, err := d.DetectCPFP("parent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &bchain.CPFPInfo{
+		Txid:            "parent",
+		AncestorFeeRate: 1000,
+		PackageFeeRate:  8800,
+		Descendants:     []string{"child", "grandchild"},
+		BumpedBy:        "child",
+		Bumped:          true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DetectCPFP(parent) = %+v, want %+v", got, want)
+	}
+
+	got, err = d.DetectCPFP("child")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = &bchain.CPFPInfo{
+		Txid:            "child",
+		AncestorFeeRate: 8800,
+		PackageFeeRate:  8800,
+		Descendants:     []string{"grandchild"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DetectCPFP(child) = %+v, want %+v", got, want)
+	}
+
+	if _, err = d.DetectCPFP("confirmed"); !IsNotFound(err) {
+		t.Errorf("DetectCPFP(confirmed) error = %v, want not found", err)
+	}
+}
+
+func TestDecredRPC_GetAccounts(t *testing.T) {
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		switch method {
+		case "listaccounts":
+			return map[string]float64{"default": 12.5, "imported": 0, "savings": 0.00000001}
+		case "getaccountaddress":
+			if len(params) != 1 || string(params[0]) != `"default"` {
+				t.Errorf("Unexpected getaccountaddress params %s", params)
+			}
+			return "DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu"
+		}
+		t.Errorf("Unexpected rpc method %v", method)
+		return nil
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	if _, err := d.GetAccounts(); err != ErrWalletBackendRequired {
+		t.Errorf("GetAccounts() error = %v, want %v", err, ErrWalletBackendRequired)
+	}
+	d.config.BackendType = BackendTypeWallet
+	got, err := d.GetAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DecredAccount{
+		{Name: "default", Balance: big.NewInt(1250000000)},
+		{Name: "imported", Balance: big.NewInt(0)},
+		{Name: "savings", Balance: big.NewInt(1)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAccounts() = %+v, want %+v", got, want)
+	}
+	address, err := d.GetAccountAddress("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address != "DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu" {
+		t.Errorf("GetAccountAddress() = %v", address)
+	}
+}
+
+func TestDecredRPC_ExplorerTxLink(t *testing.T) {
+	d := newTestDecredRPC("")
+	if got, want := d.ExplorerTxLink("txid"), "https://explorer.dcrdata.org/tx/txid"; got != want {
+		t.Errorf("ExplorerTxLink() = %v, want %v", got, want)
+	}
+	d.config.ExplorerTxURL = "https://explorer.example.com/{txid}?net=main"
+	if got, want := d.ExplorerTxLink("txid"), "https://explorer.example.com/txid?net=main"; got != want {
+		t.Errorf("ExplorerTxLink() = %v, want %v", got, want)
+	}
+}
+
+func TestDecredRPC_GetAddressTransactionsPaged(t *testing.T) {
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		if method != "searchrawtransactions" {
+			t.Errorf("Unexpected rpc method %v", method)
+			return nil
+		}
+		if len(params) != 7 || string(params[2]) != "20" || string(params[3]) != "2" || string(params[5]) != "true" {
+			t.Errorf("Unexpected searchrawtransactions params %s", params)
+		}
+		return []map[string]interface{}{
+			{"txid": "2c5e9d2f56c8b1a3d2a1e3cb7d1e65bb7e0f5b1c5f8e3a0d9c4b2a1f0e9d8c7b", "confirmations": 3, "blockheight": 100},
+			{"txid": "8d1e4a3f2b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e", "confirmations": 10, "blockheight": 93},
+		}
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	if _, err := d.GetAddressTransactionsPaged("DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu", 0, 0); err == nil {
+		t.Error("GetAddressTransactionsPaged() with zero count, expected error")
+	}
+	got, err := d.GetAddressTransactionsPaged("DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu", 20, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("GetAddressTransactionsPaged() returned %d transactions, want 2", len(got))
+	}
+	if got[0].Txid != "2c5e9d2f56c8b1a3d2a1e3cb7d1e65bb7e0f5b1c5f8e3a0d9c4b2a1f0e9d8c7b" || got[0].Confirmations != 3 || got[1].Confirmations != 10 {
+		t.Errorf("GetAddressTransactionsPaged() = %+v, %+v", got[0], got[1])
+	}
+}
+
+func TestDecredRPC_GetConnectionStatus(t *testing.T) {
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		if method == "getbestblockhash" {
+			return testGenesisHash
+		}
+		return nil
+	})
+	defer s.Close()
+	u := strings.Replace(s.URL, "http://", "http://user:secret@", 1)
+	d := newTestDecredRPC(u)
+	d.conns = &connStats{}
+	d.clients = newHTTPClients(1, d.conns)
+	if _, err := d.GetBestBlockHash(); err != nil {
+		t.Fatal(err)
+	}
+	d.conns.callFinished(newNetworkError("getblock", errors.New("connection refused")))
+	d.conns.callFinished(newRPCError("getblock", Error{Code: rpcErrTxNotFound, Message: "not found"}))
+	got, err := d.GetConnectionStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got.RPCURL, "secret") || !strings.Contains(got.RPCURL, "user:xxxxx@") {
+		t.Errorf("GetConnectionStatus() RPCURL = %v, the password is not redacted", got.RPCURL)
+	}
+	if got.Transport != "http" || got.TotalConnections != 1 || got.ActiveConnections != 0 || got.IdleConnections != 1 {
+		t.Errorf("GetConnectionStatus() = %+v, want 1 idle http connection", got)
+	}
+	if got.LastSuccess == nil {
+		t.Error("GetConnectionStatus() LastSuccess is not set")
+	}
+	if got.Errors["network"] != 1 || got.Errors["rpc"] != 1 || got.Errors["http"] != 0 {
+		t.Errorf("GetConnectionStatus() Errors = %v", got.Errors)
+	}
+}
+
+// testGenesisBlockResult returns the verbose getblock result of the mainnet genesis block built from the chain params
+func testGenesisBlockResult() *GetBlockResult {
+	h := &dch.MainNetParams.GenesisBlock.Header
+	var block GetBlockResult
+	r := &block.Result
+	r.Hash = testGenesisHash
+	r.Version = json.Number(strconv.Itoa(int(h.Version)))
+	r.PreviousHash = h.PrevBlock.String()
+	r.MerkleRoot = h.MerkleRoot.String()
+	r.StakeRoot = h.StakeRoot.String()
+	r.VoteBits = h.VoteBits
+	r.FinalState = hex.EncodeToString(h.FinalState[:])
+	r.Voters = h.Voters
+	r.FreshStake = h.FreshStake
+	r.Revocations = h.Revocations
+	r.PoolSize = h.PoolSize
+	r.Bits = strconv.FormatUint(uint64(h.Bits), 16)
+	r.SBits = dcrutil.Amount(h.SBits).ToCoin()
+	r.Height = int64(h.Height)
+	r.Size = int32(h.Size)
+	r.Time = h.Timestamp.Unix()
+	r.Nonce = json.Number(strconv.FormatUint(uint64(h.Nonce), 10))
+	r.ExtraData = hex.EncodeToString(h.ExtraData[:])
+	r.StakeVersion = h.StakeVersion
+	return &block
+}
+
+func Test_serializeBlockHeader(t *testing.T) {
+	h := &dch.MainNetParams.GenesisBlock.Header
+	var want bytes.Buffer
+	if err := h.Serialize(&want); err != nil {
+		t.Fatal(err)
+	}
+	block := testGenesisBlockResult()
+	r := &block.Result
+	got, err := serializeBlockHeader(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want.Bytes()) {
+		t.Errorf("serializeBlockHeader() = %x, want %x", got, want.Bytes())
+	}
+	r.Nonce = json.Number("1")
+	if _, err := serializeBlockHeader(block); err == nil {
+		t.Error("serializeBlockHeader() of the modified header, expected hash mismatch error")
+	}
+}
+
+func TestParentApproval(t *testing.T) {
+	h := dch.MainNetParams.GenesisBlock.Header
+	serialize := func(voteBits uint16) []byte {
+		h.VoteBits = voteBits
+		var b bytes.Buffer
+		if err := h.Serialize(&b); err != nil {
+			t.Fatal(err)
+		}
+		return b.Bytes()
+	}
+	tests := []struct {
+		name         string
+		header       []byte
+		parent       string
+		wantApproved bool
+		wantOk       bool
+	}{
+		{name: "approved", header: serialize(0x0001), parent: testZeroHash, wantApproved: true, wantOk: true},
+		{name: "disapproved", header: serialize(0x0000), parent: testZeroHash, wantApproved: false, wantOk: true},
+		{name: "approved with other vote bits", header: serialize(0x8005), parent: testZeroHash, wantApproved: true, wantOk: true},
+		{name: "other parent", header: serialize(0x0001), parent: testGenesisHash, wantOk: false},
+		{name: "not indexed", header: nil, parent: testZeroHash, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			approved, ok := ParentApproval(tt.header, tt.parent)
+			if approved != tt.wantApproved || ok != tt.wantOk {
+				t.Errorf("ParentApproval() = %v, %v, want %v, %v", approved, ok, tt.wantApproved, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestDecredRPC_IsParentDisapproved(t *testing.T) {
+	h := dch.MainNetParams.GenesisBlock.Header
+	var b bytes.Buffer
+	if err := h.Serialize(&b); err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		if method == "getblockheader" {
+			calls++
+			return map[string]interface{}{"hash": "h", "votebits": 0}
+		}
+		t.Errorf("Unexpected rpc method %v", method)
+		return nil
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	svh := uint32(d.Parser.(*DecredParser).chainParams().StakeValidationHeight)
+
+	// the genesis header has VoteBits 1 and the zero previous hash
+	block := &bchain.Block{BlockHeader: bchain.BlockHeader{Hash: "h", Height: svh, Prev: testZeroHash, Raw: b.Bytes()}}
+	if got, err := d.IsParentDisapproved(block); err != nil || got {
+		t.Errorf("IsParentDisapproved() = %v, %v, want false", got, err)
+	}
+	block.Raw[100] = 0
+	if got, err := d.IsParentDisapproved(block); err != nil || !got {
+		t.Errorf("IsParentDisapproved() = %v, %v, want true", got, err)
+	}
+	if calls != 0 {
+		t.Errorf("getblockheader called %d times for the block with the header", calls)
+	}
+	// the blocks without votes approve the parent
+	block.Height = svh - 1
+	if got, err := d.IsParentDisapproved(block); err != nil || got {
+		t.Errorf("IsParentDisapproved() below stake validation height = %v, %v, want false", got, err)
+	}
+	// without the serialized header the vote bits are fetched
+	block = &bchain.Block{BlockHeader: bchain.BlockHeader{Hash: "h", Height: svh, Prev: testZeroHash}}
+	if got, err := d.IsParentDisapproved(block); err != nil || !got || calls != 1 {
+		t.Errorf("IsParentDisapproved() without header = %v, %v, %d calls, want true, 1 call", got, err, calls)
+	}
+}
+
+func TestDecredRPC_GetWork(t *testing.T) {
+	s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+		if method != "getwork" {
+			t.Errorf("Unexpected rpc method %v", method)
+			return nil
+		}
+		return map[string]interface{}{"data": "0600000001", "target": "ffff0000"}
+	})
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	w, err := d.GetWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Data != "0600000001" || w.Target != "ffff0000" || w.Reason != "" {
+		t.Errorf("Unexpected work %+v", w)
+	}
+}
+
+func TestDecredRPC_WorkNotification(t *testing.T) {
+	d := newTestDecredRPC("")
+	var work []DecredWork
+	d.SubscribeWork(func(w *DecredWork) { work = append(work, *w) })
+	var notifications []bchain.NotificationType
+	n, err := newWSNotifier(&Configuration{NotifyWork: true}, func(nt bchain.NotificationType) { notifications = append(notifications, nt) }, d.onWork)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.handleNotification(&wsNotification{Method: "work", Params: []json.RawMessage{
+		json.RawMessage(`"0600000002"`), json.RawMessage(`"ffff0000"`), json.RawMessage(`"blockconnected"`),
+	}})
+	// malformed notification is skipped
+	n.handleNotification(&wsNotification{Method: "work", Params: []json.RawMessage{json.RawMessage(`"0600000003"`)}})
+	n.handleNotification(&wsNotification{Method: "blockconnected"})
+	want := []DecredWork{{Data: "0600000002", Target: "ffff0000", Reason: "blockconnected"}}
+	if !reflect.DeepEqual(work, want) {
+		t.Errorf("work = %+v, want %+v", work, want)
+	}
+	if !reflect.DeepEqual(notifications, []bchain.NotificationType{bchain.NotificationNewBlock}) {
+		t.Errorf("Unexpected notifications %v", notifications)
+	}
+}
+
+func TestDownloadChainParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		chain   string
+		genesis string
+		want    *chaincfg.Params
+		wantErr bool
+	}{
+		{name: "testnet", chain: "testnet3", genesis: dch.TestNet3Params.GenesisHash.String(), want: &TestNetParams},
+		{name: "mainnet", chain: "mainnet", genesis: testGenesisHash, want: &MainNetParams},
+		{name: "genesis mismatch", chain: "testnet3", genesis: testGenesisHash, wantErr: true},
+		{name: "unknown network", chain: "customnet", genesis: testGenesisHash, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testRPCBackend(t, func(method string, params []json.RawMessage) interface{} {
+				switch method {
+				case "getblockchaininfo":
+					return map[string]interface{}{"chain": tt.chain}
+				case "getblockhash":
+					return tt.genesis
+				}
+				t.Errorf("Unexpected rpc method %v", method)
+				return nil
+			})
+			defer s.Close()
+			got, err := DownloadChainParams(newTestDecredRPC(s.URL))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("DownloadChainParams() = %v, want error", got.Name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("DownloadChainParams() = %v, want %v", got.Name, tt.want.Name)
+			}
+			if np := netParamsByName(tt.chain); uint32(got.Net) != uint32(np.Net) {
+				t.Errorf("DownloadChainParams() magic %x, want %x", uint32(got.Net), uint32(np.Net))
+			}
+		})
+	}
+}
+
+func TestDecredRPC_ValidateBlockTime(t *testing.T) {
+	// no rpc call is expected, the previous block is compared only if it was validated before
+	d := newTestDecredRPC("")
+	newBlock := func(hash, prev string, tm int64) *GetBlockResult {
+		b := &GetBlockResult{}
+		b.Result.Hash = hash
+		b.Result.PreviousHash = prev
+		b.Result.Time = tm
+		return b
+	}
+	now := time.Now().Unix()
+	if err := d.ValidateBlockTime(newBlock("b1", "b0", now-600)); err != nil {
+		t.Errorf("ValidateBlockTime() error = %v", err)
+	}
+	// the time before the previous block is only logged
+	if err := d.ValidateBlockTime(newBlock("b2", "b1", now-1200)); err != nil {
+		t.Errorf("ValidateBlockTime() error = %v", err)
+	}
+	if d.lastBlock.hash != "b2" || d.lastBlock.time != now-1200 {
+		t.Errorf("lastBlock = %v %v, want b2 %v", d.lastBlock.hash, d.lastBlock.time, now-1200)
+	}
+	if err := d.ValidateBlockTime(newBlock("b3", "b2", now+int64(3*time.Hour/time.Second))); err != bchain.ErrBlockFutureDated {
+		t.Errorf("ValidateBlockTime() error = %v, want %v", err, bchain.ErrBlockFutureDated)
+	}
+}
+
+func TestWSTLSConfig(t *testing.T) {
+	s := httptest.NewTLSServer(http.NotFoundHandler())
+	defer s.Close()
+	f, err := ioutil.TempFile("", "rpc.cert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if err = pem.Encode(f, &pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	c, err := wsTLSConfig(&Configuration{WSCert: f.Name()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c == nil || c.InsecureSkipVerify || c.RootCAs == nil {
+		t.Fatalf("wsTLSConfig() = %+v, want verification with ws_cert", c)
+	}
+	// the server is verified with the certificate
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: c}}
+	res, err := client.Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if c, err = wsTLSConfig(&Configuration{}); err != nil || c != nil {
+		t.Errorf("wsTLSConfig() without ws_cert = %+v, %v, want the default verification", c, err)
+	}
+	if c, err = wsTLSConfig(&Configuration{WSInsecureSkipVerify: true}); err != nil || !c.InsecureSkipVerify {
+		t.Errorf("wsTLSConfig() with ws_insecure_skip_verify = %+v, %v", c, err)
+	}
+	if _, err = wsTLSConfig(&Configuration{WSCert: f.Name() + ".missing"}); err == nil {
+		t.Error("wsTLSConfig() with missing ws_cert expected error")
+	}
+}
+
+func TestDecredRPC_Call_MethodNotFound(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 1, "result": nil, "error": map[string]interface{}{"code": rpcErrMethodNotFound, "message": "Method not found"}})
+	}))
+	defer s.Close()
+	d := newTestDecredRPC(s.URL)
+	var res GetBlockCountResult
+	if err := d.Call(GenericCmd{ID: 1, Method: "getcfilterv2"}, &res); err != bchain.ErrMethodNotSupported {
+		t.Errorf("Call() error = %v, want %v", err, bchain.ErrMethodNotSupported)
+	}
+}
@@ -0,0 +1,342 @@
+package dcr
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// networkStatsTTL is the time the network statistics are cached
+const networkStatsTTL = 60 * time.Second
+
+// DecredNetworkStats contains the mining and staking metrics of the network
+type DecredNetworkStats struct {
+	Height          uint32    `json:"height"`
+	Difficulty      float64   `json:"difficulty"`
+	HashRate        float64   `json:"hashRate"`
+	TicketPrice     float64   `json:"ticketPrice"`
+	NextTicketPrice float64   `json:"nextTicketPrice"`
+	TicketPoolSize  uint32    `json:"ticketPoolSize"`
+	TicketPoolValue float64   `json:"ticketPoolValue"`
+	MempoolSize     int       `json:"mempoolSize"`
+	Updated         time.Time `json:"updated"`
+}
+
+// networkStatsCache holds the last network statistics
+type networkStatsCache struct {
+	lock  sync.Mutex
+	stats *DecredNetworkStats
+}
+
+type GetDifficultyResult struct {
+	Error  Error   `json:"error"`
+	Result float64 `json:"result"`
+}
+
+type GetStakeDifficultyResult struct {
+	Error  Error `json:"error"`
+	Result struct {
+		Current float64 `json:"current"`
+		Next    float64 `json:"next"`
+	} `json:"result"`
+}
+
+type GetTicketPoolValueResult struct {
+	Error  Error   `json:"error"`
+	Result float64 `json:"result"`
+}
+
+type GetRawMempoolResult struct {
+	Error  Error    `json:"error"`
+	Result []string `json:"result"`
+}
+
+// GetDecredNetworkStats returns the network statistics, the statistics are cached for networkStatsTTL
+func (d *DecredRPC) GetDecredNetworkStats() (*DecredNetworkStats, error) {
+	d.networkStats.lock.Lock()
+	defer d.networkStats.lock.Unlock()
+	if s := d.networkStats.stats; s != nil && time.Since(s.Updated) < networkStatsTTL {
+		return s, nil
+	}
+	s, err := d.getNetworkStats()
+	if err != nil {
+		return nil, err
+	}
+	d.networkStats.stats = s
+	return s, nil
+}
+
+func (d *DecredRPC) getNetworkStats() (*DecredNetworkStats, error) {
+	var difficulty GetDifficultyResult
+	if err := d.Call(GenericCmd{ID: 1, Method: "getdifficulty"}, &difficulty); err != nil {
+		return nil, err
+	}
+	if difficulty.Error.Message != "" {
+		return nil, errors.Annotate(newRPCError("getdifficulty", difficulty.Error), "Error fetching difficulty")
+	}
+
+	var stakeDifficulty GetStakeDifficultyResult
+	if err := d.Call(GenericCmd{ID: 2, Method: "getstakedifficulty"}, &stakeDifficulty); err != nil {
+		return nil, err
+	}
+	if stakeDifficulty.Error.Message != "" {
+		return nil, errors.Annotate(newRPCError("getstakedifficulty", stakeDifficulty.Error), "Error fetching stake difficulty")
+	}
+
+	var poolValue GetTicketPoolValueResult
+	if err := d.Call(GenericCmd{ID: 3, Method: "getticketpoolvalue"}, &poolValue); err != nil {
+		return nil, err
+	}
+	if poolValue.Error.Message != "" {
+		return nil, errors.Annotate(newRPCError("getticketpoolvalue", poolValue.Error), "Error fetching ticket pool value")
+	}
+
+	var mempool GetRawMempoolResult
+	if err := d.Call(GenericCmd{ID: 4, Method: "getrawmempool", Params: []interface{}{false, "all"}}, &mempool); err != nil {
+		return nil, err
+	}
+	if mempool.Error.Message != "" {
+		return nil, errors.Annotate(newRPCError("getrawmempool", mempool.Error), "Error fetching mempool")
+	}
+
+	best, err := d.getBestBlock()
+	if err != nil {
+		return nil, err
+	}
+	header, err := d.GetDecredBlockHeader(best.Result.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DecredNetworkStats{
+		Height:          header.Height,
+		Difficulty:      difficulty.Result,
+		HashRate:        d.hashRate(difficulty.Result),
+		TicketPrice:     stakeDifficulty.Result.Current,
+		NextTicketPrice: stakeDifficulty.Result.Next,
+		TicketPoolSize:  header.PoolSize,
+		TicketPoolValue: poolValue.Result,
+		MempoolSize:     len(mempool.Result),
+		Updated:         time.Now(),
+	}, nil
+}
+
+// hashRate estimates the network hash rate in hashes per second, on average the difficulty
+// times 2^32 hashes are needed to find a block in the target time per block
+func (d *DecredRPC) hashRate(difficulty float64) float64 {
+	target := d.Parser.(*DecredParser).chainParams().TargetTimePerBlock.Seconds()
+	return difficulty * (1 << 32) / target
+}
+
+// MaxParticipationWindow is the maximum number of blocks of the participation rate window,
+// one week of the mainnet blocks
+const MaxParticipationWindow = 2016
+
+// DecredParticipationRate contains the staking participation over the window of the last blocks
+type DecredParticipationRate struct {
+	Height           uint32  `json:"height"`
+	WindowSize       int     `json:"windowSize"`
+	AvgVoters        float64 `json:"avgVoters"`
+	TicketsPerBlock  float64 `json:"ticketsPerBlock"`
+	ParticipationPct float64 `json:"participationPct"`
+}
+
+// participationCache holds the participation rates of the best block by the window size,
+// the rates are dropped when the best block changes
+type participationCache struct {
+	lock  sync.Mutex
+	hash  string
+	rates map[int]*DecredParticipationRate
+}
+
+func (c *participationCache) get(hash string, windowSize int) *DecredParticipationRate {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.hash != hash {
+		return nil
+	}
+	return c.rates[windowSize]
+}
+
+func (c *participationCache) set(hash string, windowSize int, r *DecredParticipationRate) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.hash != hash {
+		c.hash = hash
+		c.rates = make(map[int]*DecredParticipationRate)
+	}
+	c.rates[windowSize] = r
+}
+
+// GetParticipationRate computes the average number of votes and fresh tickets per block and the percentage
+// of the possible votes cast over the last windowSize blocks, the blocks before the stake validation height
+// do not count to the possible votes. The rates are cached until the best block changes.
+func (d *DecredRPC) GetParticipationRate(windowSize int) (*DecredParticipationRate, error) {
+	if windowSize <= 0 || windowSize > MaxParticipationWindow {
+		return nil, errors.Errorf("Invalid window size %d, must be between 1 and %d", windowSize, MaxParticipationWindow)
+	}
+	best, err := d.getBestBlock()
+	if err != nil {
+		return nil, err
+	}
+	if r := d.participation.get(best.Result.Hash, windowSize); r != nil {
+		return r, nil
+	}
+	params := d.Parser.(*DecredParser).chainParams()
+	r := &DecredParticipationRate{Height: uint32(best.Result.Height)}
+	var voters, freshStake, possibleVotes int
+	hash := best.Result.Hash
+	for r.WindowSize < windowSize && hash != "" {
+		header, err := d.getBlockHeader(hash)
+		if err != nil {
+			return nil, err
+		}
+		r.WindowSize++
+		voters += int(header.Result.Voters)
+		freshStake += int(header.Result.FreshStake)
+		if int64(header.Result.Height) >= params.StakeValidationHeight {
+			possibleVotes += int(params.TicketsPerBlock)
+		}
+		if header.Result.Height == 0 {
+			break
+		}
+		hash = header.Result.PreviousHash
+	}
+	r.AvgVoters = float64(voters) / float64(r.WindowSize)
+	r.TicketsPerBlock = float64(freshStake) / float64(r.WindowSize)
+	if possibleVotes > 0 {
+		r.ParticipationPct = float64(voters) * 100 / float64(possibleVotes)
+	}
+	d.participation.set(best.Result.Hash, windowSize, r)
+	return r, nil
+}
+
+// DecredMiningInfo is the mining state of dcrd returned by getmininginfo
+type DecredMiningInfo struct {
+	Blocks           int64   `json:"blocks"`
+	CurrentBlockSize int64   `json:"currentblocksize"`
+	CurrentBlockTx   int64   `json:"currentblocktx"`
+	Difficulty       float64 `json:"difficulty"`
+	Errors           string  `json:"errors"`
+	Generate         bool    `json:"generate"`
+	NetworkHashPS    float64 `json:"networkhashps"`
+	PooledTx         int     `json:"pooledtx"`
+}
+
+type GetMiningInfoResult struct {
+	Error  Error            `json:"error"`
+	Result DecredMiningInfo `json:"result"`
+}
+
+// GetMiningInfo returns the mining state of dcrd including the network hash rate estimated by dcrd
+func (d *DecredRPC) GetMiningInfo() (*DecredMiningInfo, error) {
+	var miningInfo GetMiningInfoResult
+	if err := d.Call(GenericCmd{ID: 1, Method: "getmininginfo"}, &miningInfo); err != nil {
+		return nil, err
+	}
+	if miningInfo.Error.Message != "" {
+		return nil, errors.Annotate(newRPCError("getmininginfo", miningInfo.Error), "Error fetching mining info")
+	}
+	return &miningInfo.Result, nil
+}
+
+// maxBlockSizeStatsCount is the maximum number of the blocks of the block size statistics,
+// one week of the mainnet blocks
+const maxBlockSizeStatsCount = 2016
+
+// DecredBlockSizeStats contains the statistics of the sizes of the last blocks
+type DecredBlockSizeStats struct {
+	Height  uint32    `json:"height"`
+	Count   int       `json:"count"`
+	Min     uint32    `json:"min"`
+	Max     uint32    `json:"max"`
+	Avg     float64   `json:"avg"`
+	Median  float64   `json:"median"`
+	Updated time.Time `json:"updated"`
+}
+
+// blockSizeStatsCache holds the sizes of the last maxBlockSizeStatsCount blocks, newest first,
+// the statistics of the smaller counts are computed from the beginning of the sizes
+type blockSizeStatsCache struct {
+	lock    sync.Mutex
+	height  uint32
+	sizes   []uint32
+	updated time.Time
+}
+
+// GetBlockSizeStats returns the statistics of the sizes of the last count blocks,
+// the sizes of the blocks are cached for networkStatsTTL
+func (d *DecredRPC) GetBlockSizeStats(count int) (*DecredBlockSizeStats, error) {
+	if count <= 0 || count > maxBlockSizeStatsCount {
+		return nil, errors.Errorf("Invalid count %d, must be between 1 and %d", count, maxBlockSizeStatsCount)
+	}
+	c := &d.blockSizeStats
+	c.lock.Lock()
+	height, sizes, updated := c.height, c.sizes, c.updated
+	c.lock.Unlock()
+	// the sizes are fetched outside of the lock, the concurrent requests may fetch them at the same time
+	if sizes == nil || time.Since(updated) >= networkStatsTTL {
+		var err error
+		if height, sizes, err = d.getBlockSizes(maxBlockSizeStatsCount); err != nil {
+			return nil, err
+		}
+		updated = time.Now()
+		c.lock.Lock()
+		c.height, c.sizes, c.updated = height, sizes, updated
+		c.lock.Unlock()
+	}
+	if count > len(sizes) {
+		count = len(sizes)
+	}
+	s := blockSizeStats(sizes[:count])
+	s.Height = height
+	s.Updated = updated
+	return s, nil
+}
+
+// getBlockSizes returns the height of the best block and the sizes of the last count blocks, newest first
+func (d *DecredRPC) getBlockSizes(count int) (uint32, []uint32, error) {
+	best, err := d.getBestBlock()
+	if err != nil {
+		return 0, nil, err
+	}
+	sizes := make([]uint32, 0, count)
+	hash := best.Result.Hash
+	for len(sizes) < count && hash != "" {
+		header, err := d.getBlockHeader(hash)
+		if err != nil {
+			return 0, nil, err
+		}
+		sizes = append(sizes, header.Result.Size)
+		if header.Result.Height == 0 {
+			break
+		}
+		hash = header.Result.PreviousHash
+	}
+	return uint32(best.Result.Height), sizes, nil
+}
+
+// blockSizeStats computes the statistics of the sizes, the sizes are not modified
+func blockSizeStats(sizes []uint32) *DecredBlockSizeStats {
+	s := &DecredBlockSizeStats{Count: len(sizes)}
+	if len(sizes) == 0 {
+		return s
+	}
+	sorted := append([]uint32(nil), sizes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total uint64
+	for _, size := range sorted {
+		total += uint64(size)
+	}
+	s.Min = sorted[0]
+	s.Max = sorted[len(sorted)-1]
+	s.Avg = float64(total) / float64(len(sorted))
+	if m := len(sorted) / 2; len(sorted)%2 == 0 {
+		s.Median = float64(sorted[m-1]+sorted[m]) / 2
+	} else {
+		s.Median = float64(sorted[m])
+	}
+	return s
+}
@@ -0,0 +1,74 @@
+package dcr
+
+import (
+	"math"
+
+	"github.com/juju/errors"
+)
+
+// maxSubsidyPoints limits the number of the points of the subsidy schedule
+const maxSubsidyPoints = 1000
+
+// MaxSubsidyHeight is the highest start height of the subsidy schedule, the block heights are uint32
+const MaxSubsidyHeight = math.MaxUint32
+
+// DecredSubsidyPoint is the subsidy of the block at Height in atoms, PoSSubsidy is the sum paid to all the votes
+type DecredSubsidyPoint struct {
+	Height          int64 `json:"height"`
+	TotalSubsidy    int64 `json:"totalSubsidy"`
+	PoWSubsidy      int64 `json:"powSubsidy"`
+	PoSSubsidy      int64 `json:"posSubsidy"`
+	TreasurySubsidy int64 `json:"treasurySubsidy"`
+}
+
+// GetSubsidySchedule computes the block subsidy at count heights spaced by the subsidy reduction interval
+// starting at startHeight, that is one point per reduction of the subsidy by MulSubsidy/DivSubsidy.
+// The schedule follows the subsidy rules of the consensus parameters of the network and assumes
+// that all the votes of the blocks are cast, dcrd is not called.
+// The schedule ends with the first point with no subsidy, the subsidy is not reduced further.
+func (p *DecredParser) GetSubsidySchedule(startHeight int64, count int) ([]DecredSubsidyPoint, error) {
+	if startHeight < 0 || startHeight > MaxSubsidyHeight {
+		return nil, errors.Errorf("Start height must be between 0 and %d", int64(MaxSubsidyHeight))
+	}
+	if count <= 0 || count > maxSubsidyPoints {
+		return nil, errors.Errorf("Count must be between 1 and %d", maxSubsidyPoints)
+	}
+	params := p.chainParams()
+	r := make([]DecredSubsidyPoint, 0, count)
+	// the full subsidy is reduced once per interval, the reductions up to startHeight are applied once
+	subsidy := params.BaseSubsidy
+	reductions := startHeight / params.SubsidyReductionInterval
+	for i := int64(0); i < reductions && subsidy > 0; i++ {
+		subsidy = subsidy * params.MulSubsidy / params.DivSubsidy
+	}
+	total := int64(params.TotalSubsidyProportions())
+	for i := 0; i < count; i++ {
+		height := startHeight + int64(i)*params.SubsidyReductionInterval
+		if i > 0 {
+			subsidy = subsidy * params.MulSubsidy / params.DivSubsidy
+		}
+		var sp DecredSubsidyPoint
+		sp.Height = height
+		switch {
+		case height == 0:
+			// the genesis block has no subsidy
+		case height == 1:
+			// the subsidy of the first block is the initial distribution of the coins
+			sp.PoWSubsidy = params.BlockOneSubsidy()
+		default:
+			sp.PoWSubsidy = subsidy * int64(params.WorkRewardProportion) / total
+			sp.TreasurySubsidy = subsidy * int64(params.BlockTaxProportion) / total
+			if height >= params.StakeValidationHeight {
+				// each vote receives its share of the stake subsidy rounded down
+				votes := int64(params.TicketsPerBlock)
+				sp.PoSSubsidy = subsidy * int64(params.StakeRewardProportion) / total / votes * votes
+			}
+		}
+		sp.TotalSubsidy = sp.PoWSubsidy + sp.PoSSubsidy + sp.TreasurySubsidy
+		r = append(r, sp)
+		if subsidy == 0 {
+			break
+		}
+	}
+	return r, nil
+}
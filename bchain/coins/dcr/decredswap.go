@@ -0,0 +1,221 @@
+package dcr
+
+import (
+	"blockbook/bchain"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/decred/dcrd/txscript"
+)
+
+// atomicSwapSecretSize is the size of the secret required by the atomic swap contracts
+const atomicSwapSecretSize = 32
+
+// DecredAtomicSwap describes the hash time locked contract of an atomic swap, used by the Decred DEX.
+// The recipient redeems the contract by revealing the secret of SecretHash, the refund address takes
+// the funds back after LockTime. LockTime is a block height or a unix time for OP_CHECKLOCKTIMEVERIFY
+// contracts, the encoded relative lock time for OP_CHECKSEQUENCEVERIFY contracts.
+type DecredAtomicSwap struct {
+	SecretHash       []byte `json:"secretHash"`
+	RecipientAddress string `json:"recipientAddress"`
+	RefundAddress    string `json:"refundAddress"`
+	LockTime         int64  `json:"lockTime"`
+	RelativeLockTime bool   `json:"relativeLockTime,omitempty"`
+}
+
+// ParseAtomicSwapScript recognizes the atomic swap contract
+//
+//	OP_IF
+//	  OP_SIZE 32 OP_EQUALVERIFY OP_SHA256 <secret hash> OP_EQUALVERIFY OP_DUP OP_HASH160 <recipient pkh>
+//	OP_ELSE
+//	  <locktime> OP_CHECKLOCKTIMEVERIFY|OP_CHECKSEQUENCEVERIFY OP_DROP OP_DUP OP_HASH160 <refund pkh>
+//	OP_ENDIF
+//	OP_EQUALVERIFY OP_CHECKSIG
+//
+// and returns the swap details, false if the script is not an atomic swap contract.
+func (p *DecredParser) ParseAtomicSwapScript(script []byte) (*DecredAtomicSwap, bool) {
+	s := script
+	match := func(ops ...byte) bool {
+		if !bytes.HasPrefix(s, ops) {
+			return false
+		}
+		s = s[len(ops):]
+		return true
+	}
+	data := func(n int) []byte {
+		if len(s) < n+1 || int(s[0]) != n {
+			return nil
+		}
+		d := s[1 : n+1]
+		s = s[n+1:]
+		return d
+	}
+	if !match(txscript.OP_IF, txscript.OP_SIZE, txscript.OP_DATA_1, atomicSwapSecretSize, txscript.OP_EQUALVERIFY, txscript.OP_SHA256) {
+		return nil, false
+	}
+	secretHash := data(32)
+	if secretHash == nil || !match(txscript.OP_EQUALVERIFY, txscript.OP_DUP, txscript.OP_HASH160) {
+		return nil, false
+	}
+	recipient := data(20)
+	if recipient == nil || !match(txscript.OP_ELSE) {
+		return nil, false
+	}
+	lockTime, ok := atomicSwapLockTime(&s)
+	if !ok || len(s) == 0 {
+		return nil, false
+	}
+	relative := false
+	switch s[0] {
+	case txscript.OP_CHECKLOCKTIMEVERIFY:
+	case txscript.OP_CHECKSEQUENCEVERIFY:
+		relative = true
+	default:
+		return nil, false
+	}
+	s = s[1:]
+	if !match(txscript.OP_DROP, txscript.OP_DUP, txscript.OP_HASH160) {
+		return nil, false
+	}
+	refund := data(20)
+	if refund == nil || !match(txscript.OP_ENDIF, txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG) || len(s) != 0 {
+		return nil, false
+	}
+	recipientAddress, err := p.pubKeyHashAddress(recipient)
+	if err != nil {
+		return nil, false
+	}
+	refundAddress, err := p.pubKeyHashAddress(refund)
+	if err != nil {
+		return nil, false
+	}
+	return &DecredAtomicSwap{
+		SecretHash:       append([]byte(nil), secretHash...),
+		RecipientAddress: recipientAddress,
+		RefundAddress:    refundAddress,
+		LockTime:         lockTime,
+		RelativeLockTime: relative,
+	}, true
+}
+
+// atomicSwapLockTime decodes the lock time pushed as a small integer or as a script number of up to 5 bytes,
+// the limit of the numbers accepted by OP_CHECKLOCKTIMEVERIFY, and advances the script past the push
+func atomicSwapLockTime(s *[]byte) (int64, bool) {
+	script := *s
+	if len(script) == 0 {
+		return 0, false
+	}
+	op := script[0]
+	if op >= txscript.OP_1 && op <= txscript.OP_16 {
+		*s = script[1:]
+		return int64(op - txscript.OP_1 + 1), true
+	}
+	if op < txscript.OP_DATA_1 || op > txscript.OP_DATA_5 || len(script) < int(op)+1 {
+		return 0, false
+	}
+	num := script[1 : op+1]
+	// the negative lock times make the script fail
+	if num[len(num)-1]&0x80 != 0 {
+		return 0, false
+	}
+	var v int64
+	for i := len(num) - 1; i >= 0; i-- {
+		v = v<<8 | int64(num[i])
+	}
+	*s = script[op+1:]
+	return v, true
+}
+
+// pubKeyHashAddress returns the P2PKH address of the hash in the same encoding as the index uses
+func (p *DecredParser) pubKeyHashAddress(hash []byte) (string, error) {
+	script := make([]byte, 0, 25)
+	script = append(script, txscript.OP_DUP, txscript.OP_HASH160, txscript.OP_DATA_20)
+	script = append(script, hash...)
+	script = append(script, txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)
+	addrDesc, err := p.GetAddrDescFromVout(&bchain.Vout{ScriptPubKey: bchain.ScriptPubKey{Hex: hex.EncodeToString(script)}})
+	if err != nil {
+		return "", err
+	}
+	return string(addrDesc), nil
+}
+
+// atomicSwapSpend splits the signature script of the input spending the P2SH atomic swap contract,
+// the redemption pushes <sig> <pubkey> <secret> OP_TRUE <contract>, the refund <sig> <pubkey> OP_FALSE <contract>.
+// The secret is nil for the refunds.
+func (p *DecredParser) atomicSwapSpend(sigScript []byte) (contract []byte, secret []byte, swap *DecredAtomicSwap, ok bool) {
+	ops, pushes, ok := splitPushes(sigScript)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	n := len(ops)
+	if n != 4 && n != 5 {
+		return nil, nil, nil, false
+	}
+	contract = pushes[n-1]
+	swap, ok = p.ParseAtomicSwapScript(contract)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	switch {
+	case n == 5 && ops[3] == txscript.OP_TRUE:
+		secret = pushes[2]
+		if h := sha256.Sum256(secret); len(secret) != atomicSwapSecretSize || !bytes.Equal(h[:], swap.SecretHash) {
+			return nil, nil, nil, false
+		}
+	case n == 4 && ops[2] == txscript.OP_FALSE:
+	default:
+		return nil, nil, nil, false
+	}
+	return contract, secret, swap, true
+}
+
+// splitPushes returns the opcodes and the pushed data of the push only script,
+// false if the script contains other opcodes or is truncated
+func splitPushes(script []byte) ([]byte, [][]byte, bool) {
+	var ops []byte
+	var pushes [][]byte
+	for len(script) > 0 {
+		op := script[0]
+		script = script[1:]
+		var l int
+		switch {
+		case op == txscript.OP_0 || (op >= txscript.OP_1 && op <= txscript.OP_16) || op == txscript.OP_1NEGATE:
+		case op <= txscript.OP_DATA_75:
+			l = int(op)
+		case op == txscript.OP_PUSHDATA1 && len(script) >= 1:
+			l = int(script[0])
+			script = script[1:]
+		case op == txscript.OP_PUSHDATA2 && len(script) >= 2:
+			l = int(binary.LittleEndian.Uint16(script))
+			script = script[2:]
+		case op == txscript.OP_PUSHDATA4 && len(script) >= 4:
+			l = int(binary.LittleEndian.Uint32(script))
+			script = script[4:]
+		default:
+			return nil, nil, false
+		}
+		if len(script) < l {
+			return nil, nil, false
+		}
+		ops = append(ops, op)
+		pushes = append(pushes, script[:l])
+		script = script[l:]
+	}
+	return ops, pushes, true
+}
+
+// GetAtomicSwapSpend returns the contract and the secret revealed by the input spending an atomic swap contract,
+// the secret is nil for the refunds. It returns false if the input does not spend an atomic swap contract.
+func (p *DecredParser) GetAtomicSwapSpend(tx *bchain.Tx, input int) ([]byte, []byte, bool) {
+	if input < 0 || input >= len(tx.Vin) || tx.Vin[input].ScriptSig.Hex == "" {
+		return nil, nil, false
+	}
+	sigScript, err := hex.DecodeString(tx.Vin[input].ScriptSig.Hex)
+	if err != nil {
+		return nil, nil, false
+	}
+	contract, secret, _, ok := p.atomicSwapSpend(sigScript)
+	return contract, secret, ok
+}
@@ -0,0 +1,148 @@
+package dcr
+
+import (
+	"blockbook/bchain"
+	"sort"
+	"sync"
+
+	"github.com/juju/errors"
+)
+
+type GetVoteInfoResult struct {
+	Error  Error `json:"error"`
+	Result struct {
+		VoteVersion uint32         `json:"voteversion"`
+		Agendas     []DecredAgenda `json:"agendas"`
+	} `json:"result"`
+}
+
+// voteAgendasCache holds the agendas of the vote versions, the definition of the agendas does not change,
+// their status and the vote counts are refreshed by each getvoteinfo call
+type voteAgendasCache struct {
+	lock    sync.Mutex
+	agendas map[uint32][]DecredAgenda
+}
+
+// GetVoteAgendas returns the agendas voted on by the votes of the given vote version
+func (d *DecredRPC) GetVoteAgendas(version uint32) ([]DecredAgenda, error) {
+	d.voteAgendas.lock.Lock()
+	a, found := d.voteAgendas.agendas[version]
+	d.voteAgendas.lock.Unlock()
+	if found {
+		return a, nil
+	}
+	return d.getVoteInfo(version)
+}
+
+// getVoteInfo fetches the agendas of the vote version with their current status and vote counts and stores them in the cache
+func (d *DecredRPC) getVoteInfo(version uint32) ([]DecredAgenda, error) {
+	voteInfoRequest := GenericCmd{
+		ID:     1,
+		Method: "getvoteinfo",
+		Params: []interface{}{version},
+	}
+	var voteInfoResult GetVoteInfoResult
+	if err := d.Call(voteInfoRequest, &voteInfoResult); err != nil {
+		return nil, err
+	}
+	if voteInfoResult.Error.Message != "" {
+		return nil, errors.Annotatef(newRPCError(voteInfoRequest.Method, voteInfoResult.Error), "Error fetching vote info of version %d", version)
+	}
+	d.voteAgendas.lock.Lock()
+	defer d.voteAgendas.lock.Unlock()
+	if d.voteAgendas.agendas == nil {
+		d.voteAgendas.agendas = make(map[uint32][]DecredAgenda)
+	}
+	d.voteAgendas.agendas[version] = voteInfoResult.Result.Agendas
+	return voteInfoResult.Result.Agendas, nil
+}
+
+// IsParentDisapproved returns true if the votes in the block disapproved the regular transactions of the previous block,
+// the vote bits are read from the serialized header of the fetched block. The blocks below the stake validation height
+// have no votes and approve the previous block.
+func (d *DecredRPC) IsParentDisapproved(block *bchain.Block) (bool, error) {
+	if int64(block.Height) < d.Parser.(*DecredParser).chainParams().StakeValidationHeight {
+		return false, nil
+	}
+	if approved, ok := ParentApproval(block.Raw, block.Prev); ok {
+		return !approved, nil
+	}
+	// the header of the block could not be serialized
+	header, err := d.getBlockHeader(block.Hash)
+	if err != nil {
+		return false, err
+	}
+	return !IsBlockApproved(header.Result.VoteBits), nil
+}
+
+// DecredHardFork is the state of the consensus change voted by the stakeholders, the percentages
+// are of the yes and no votes of the current rule change interval, the abstaining votes are not counted.
+// ActivationHeight is the height at which the active or locked in agenda is enforced, 0 for the other agendas.
+type DecredHardFork struct {
+	AgendaID         string  `json:"agendaId"`
+	Description      string  `json:"description"`
+	VoteVersion      uint32  `json:"voteVersion"`
+	Status           string  `json:"status"`
+	YesPercent       float64 `json:"yesPercent"`
+	NoPercent        float64 `json:"noPercent"`
+	ActivationHeight int64   `json:"activationHeight,omitempty"`
+}
+
+// GetHardForkStatus returns the state and the vote tallies of the agendas of all vote versions
+// of the network, the tallies change with every block and are always fetched, the fetched agendas refresh the cache
+// of GetVoteAgendas
+func (d *DecredRPC) GetHardForkStatus() ([]DecredHardFork, error) {
+	params := d.Parser.(*DecredParser).chainParams()
+	info, err := d.GetDecredBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	since := make(map[string]int64, len(info.Result.Agendas))
+	for _, a := range info.Result.Agendas {
+		since[a.ID] = a.Since
+	}
+	versions := make([]uint32, 0, len(params.Deployments))
+	for v := range params.Deployments {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	var r []DecredHardFork
+	for _, version := range versions {
+		agendas, err := d.getVoteInfo(version)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range agendas {
+			hf := DecredHardFork{
+				AgendaID:    a.ID,
+				Description: a.Description,
+				VoteVersion: version,
+				Status:      a.Status,
+			}
+			var yes, no uint32
+			for _, c := range a.Choices {
+				switch {
+				case c.IsAbstain:
+				case c.IsNo:
+					no += c.Count
+				default:
+					yes += c.Count
+				}
+			}
+			if yes+no > 0 {
+				hf.YesPercent = 100 * float64(yes) / float64(yes+no)
+				hf.NoPercent = 100 * float64(no) / float64(yes+no)
+			}
+			switch a.Status {
+			case "active":
+				hf.ActivationHeight = since[a.ID]
+			case "lockedin":
+				if s := since[a.ID]; s > 0 {
+					hf.ActivationHeight = s + int64(params.RuleChangeActivationInterval)
+				}
+			}
+			r = append(r, hf)
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,226 @@
+package dcr
+
+import (
+	"blockbook/bchain"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/juju/errors"
+)
+
+// BackendTypeWallet is the backend_type of the configuration selecting dcrwallet as the backend
+const BackendTypeWallet = "dcrwallet"
+
+// walletListTransactionsCount is the number of the newest wallet transactions searched for the unconfirmed ones
+const walletListTransactionsCount = 1000
+
+// DecredWalletRPC is an interface to JSON-RPC dcrwallet service. The wallet forwards the chain
+// related calls to its dcrd, only the calls with a different API surface are overridden.
+// The mempool contains only the unconfirmed transactions of the wallet (watch-only) addresses.
+type DecredWalletRPC struct {
+	*DecredRPC
+}
+
+// GetDecredRPC returns the DecredRPC of the dcrd or dcrwallet backend or nil for other backends
+func GetDecredRPC(chain bchain.BlockChain) *DecredRPC {
+	switch b := chain.(type) {
+	case *DecredRPC:
+		return b
+	case *DecredWalletRPC:
+		return b.DecredRPC
+	}
+	return nil
+}
+
+type WalletSendRawTransactionResult struct {
+	Error  Error  `json:"error"`
+	Result string `json:"result"`
+}
+
+// SendRawTransaction sends the transaction through dcrwallet, returns the txid
+func (w *DecredWalletRPC) SendRawTransaction(tx string) (string, error) {
+	sendRawTxRequest := GenericCmd{
+		ID:     1,
+		Method: "sendrawtransaction",
+		Params: []interface{}{tx, false},
+	}
+	sendRawTxResult := WalletSendRawTransactionResult{}
+	err := w.Call(sendRawTxRequest, &sendRawTxResult)
+	if err != nil {
+		return "", err
+	}
+	if sendRawTxResult.Error.Message != "" {
+		return "", errors.Annotate(newRPCError(sendRawTxRequest.Method, sendRawTxResult.Error), "Error sending transaction")
+	}
+	return sendRawTxResult.Result, nil
+}
+
+type WalletListTransactionsResult struct {
+	Error  Error `json:"error"`
+	Result []struct {
+		TxID          string `json:"txid"`
+		Confirmations int64  `json:"confirmations"`
+	} `json:"result"`
+}
+
+// GetMempoolTransactions returns the unconfirmed transactions of the wallet,
+// dcrwallet does not have access to the mempool of dcrd
+func (w *DecredWalletRPC) GetMempoolTransactions() ([]string, error) {
+	listRequest := GenericCmd{
+		ID:     1,
+		Method: "listtransactions",
+		Params: []interface{}{"*", walletListTransactionsCount, 0, true},
+	}
+	listResult := WalletListTransactionsResult{}
+	err := w.Call(listRequest, &listResult)
+	if err != nil {
+		return nil, err
+	}
+	if listResult.Error.Message != "" {
+		return nil, errors.Annotate(newRPCError(listRequest.Method, listResult.Error), "Error listing wallet transactions")
+	}
+	// the transaction is listed once for each wallet output or input
+	unique := make(map[string]struct{})
+	txids := make([]string, 0)
+	for _, t := range listResult.Result {
+		if t.Confirmations != 0 {
+			continue
+		}
+		if _, found := unique[t.TxID]; !found {
+			unique[t.TxID] = struct{}{}
+			txids = append(txids, t.TxID)
+		}
+	}
+	return txids, nil
+}
+
+// InvalidAddressError is returned by SendMany if a destination address cannot be paid, nothing is sent then
+type InvalidAddressError struct {
+	Address string
+	Reason  string
+}
+
+func (e *InvalidAddressError) Error() string {
+	return fmt.Sprintf("Invalid address %v, %v", e.Address, e.Reason)
+}
+
+type SendManyResult struct {
+	Error  Error  `json:"error"`
+	Result string `json:"result"`
+}
+
+// SendMany pays the amounts in DCR to the addresses from the account of dcrwallet by one transaction,
+// the outputs spent must have minConf confirmations. It returns the txid of the transaction. The addresses
+// are checked before the call, *InvalidAddressError is returned for the first address which is not valid.
+func (d *DecredRPC) SendMany(fromAccount string, amounts map[string]float64, minConf int, comment string) (string, error) {
+	if d.config.BackendType != BackendTypeWallet {
+		return "", errors.New("sendmany requires the dcrwallet backend")
+	}
+	if len(amounts) == 0 {
+		return "", errors.New("No destination addresses")
+	}
+	params := d.Parser.(*DecredParser).chainParams()
+	for address, amount := range amounts {
+		a, err := dcrutil.DecodeAddress(address)
+		if err != nil {
+			return "", &InvalidAddressError{Address: address, Reason: err.Error()}
+		}
+		if !a.IsForNet(params) {
+			return "", &InvalidAddressError{Address: address, Reason: "address is not for network " + params.Name}
+		}
+		if amount <= 0 {
+			return "", &InvalidAddressError{Address: address, Reason: fmt.Sprintf("amount %v is not positive", amount)}
+		}
+	}
+	sendManyRequest := GenericCmd{
+		ID:     1,
+		Method: "sendmany",
+		Params: []interface{}{fromAccount, amounts, minConf, comment},
+	}
+	sendManyResult := SendManyResult{}
+	err := d.Call(sendManyRequest, &sendManyResult)
+	if err != nil {
+		return "", err
+	}
+	if sendManyResult.Error.Message != "" {
+		return "", errors.Annotate(newRPCError(sendManyRequest.Method, sendManyResult.Error), "Error sending payments")
+	}
+	return sendManyResult.Result, nil
+}
+
+// ErrWalletBackendRequired is returned by the wallet calls if the backend is not dcrwallet
+var ErrWalletBackendRequired = errors.New("The call requires the dcrwallet backend")
+
+// WalletAPIEnabled returns true if the backend is dcrwallet and the wallet api is enabled in the configuration
+func (d *DecredRPC) WalletAPIEnabled() bool {
+	return d.config.BackendType == BackendTypeWallet && d.config.EnableWalletAPI
+}
+
+// DecredAccount is the account of dcrwallet with its balance in atoms
+type DecredAccount struct {
+	Name    string   `json:"name"`
+	Balance *big.Int `json:"balance"`
+}
+
+type ListAccountsResult struct {
+	Error  Error              `json:"error"`
+	Result map[string]float64 `json:"result"`
+}
+
+// GetAccounts returns the accounts of dcrwallet sorted by the name, the balances include the confirmed outputs only
+func (d *DecredRPC) GetAccounts() ([]DecredAccount, error) {
+	if d.config.BackendType != BackendTypeWallet {
+		return nil, ErrWalletBackendRequired
+	}
+	listRequest := GenericCmd{
+		ID:     1,
+		Method: "listaccounts",
+	}
+	listResult := ListAccountsResult{}
+	err := d.Call(listRequest, &listResult)
+	if err != nil {
+		return nil, err
+	}
+	if listResult.Error.Message != "" {
+		return nil, errors.Annotate(newRPCError(listRequest.Method, listResult.Error), "Error listing wallet accounts")
+	}
+	accounts := make([]DecredAccount, 0, len(listResult.Result))
+	for name, balance := range listResult.Result {
+		a, err := dcrutil.NewAmount(balance)
+		if err != nil {
+			return nil, errors.Annotatef(err, "account %v", name)
+		}
+		accounts = append(accounts, DecredAccount{Name: name, Balance: big.NewInt(int64(a))})
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Name < accounts[j].Name })
+	return accounts, nil
+}
+
+type GetAccountAddressResult struct {
+	Error  Error  `json:"error"`
+	Result string `json:"result"`
+}
+
+// GetAccountAddress returns the current receiving address of the account of dcrwallet,
+// dcrwallet returns a new address once the current one is used
+func (d *DecredRPC) GetAccountAddress(account string) (string, error) {
+	if d.config.BackendType != BackendTypeWallet {
+		return "", ErrWalletBackendRequired
+	}
+	addressRequest := GenericCmd{
+		ID:     1,
+		Method: "getaccountaddress",
+		Params: []interface{}{account},
+	}
+	addressResult := GetAccountAddressResult{}
+	err := d.Call(addressRequest, &addressResult)
+	if err != nil {
+		return "", err
+	}
+	if addressResult.Error.Message != "" {
+		return "", errors.Annotate(newRPCError(addressRequest.Method, addressResult.Error), "Error fetching account address")
+	}
+	return addressResult.Result, nil
+}
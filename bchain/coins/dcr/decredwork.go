@@ -0,0 +1,86 @@
+package dcr
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/juju/errors"
+)
+
+// DecredWork is the work of the next block returned by dcrd getwork or pushed by the work notification
+type DecredWork struct {
+	// Data is the hex encoded serialized block header padded for the blake256 hashing
+	Data string `json:"data"`
+	// Target is the hex encoded little endian hash target
+	Target string `json:"target"`
+	// Reason of the work notification, empty for getwork
+	Reason string `json:"reason,omitempty"`
+}
+
+type GetWorkResult struct {
+	Error  Error      `json:"error"`
+	Result DecredWork `json:"result"`
+}
+
+// WorkHandler is called with the new work each time dcrd notifies it
+type WorkHandler func(*DecredWork)
+
+// workSubscriptions holds the handlers of the work subscribers
+type workSubscriptions struct {
+	lock     sync.Mutex
+	handlers []WorkHandler
+}
+
+// GetWork returns the current work of the next block, dcrd must be configured with the mining addresses
+func (d *DecredRPC) GetWork() (*DecredWork, error) {
+	workRequest := GenericCmd{
+		ID:     1,
+		Method: "getwork",
+	}
+	var workResult GetWorkResult
+	if err := d.Call(workRequest, &workResult); err != nil {
+		return nil, err
+	}
+	if workResult.Error.Message != "" {
+		return nil, errors.Annotate(newRPCError(workRequest.Method, workResult.Error), "Error fetching work")
+	}
+	return &workResult.Result, nil
+}
+
+// SubscribeWork registers the handler receiving the work pushed by dcrd over the websocket,
+// the notifications are requested only if notify_work is configured
+func (d *DecredRPC) SubscribeWork(handler WorkHandler) {
+	d.work.lock.Lock()
+	defer d.work.lock.Unlock()
+	d.work.handlers = append(d.work.handlers, handler)
+}
+
+// onWork passes the work notification to the subscribers
+func (d *DecredRPC) onWork(w *DecredWork) {
+	d.work.lock.Lock()
+	handlers := append([]WorkHandler{}, d.work.handlers...)
+	d.work.lock.Unlock()
+	for _, h := range handlers {
+		h(w)
+	}
+}
+
+// parseWorkNotification decodes the params of the dcrd work notification: data, target and reason
+func parseWorkNotification(params []json.RawMessage) (*DecredWork, error) {
+	if len(params) < 2 {
+		return nil, errors.Errorf("work notification has %d params", len(params))
+	}
+	var w DecredWork
+	if err := json.Unmarshal(params[0], &w.Data); err != nil {
+		return nil, errors.Annotate(err, "data")
+	}
+	if err := json.Unmarshal(params[1], &w.Target); err != nil {
+		return nil, errors.Annotate(err, "target")
+	}
+	if len(params) > 2 {
+		if err := json.Unmarshal(params[2], &w.Reason); err != nil {
+			return nil, errors.Annotate(err, "reason")
+		}
+	}
+	return &w, nil
+}
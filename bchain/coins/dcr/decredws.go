@@ -0,0 +1,226 @@
+package dcr
+
+import (
+	"blockbook/bchain"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/gorilla/websocket"
+	"github.com/juju/errors"
+)
+
+const (
+	wsReconnectDelay = 5 * time.Second
+	// wsHandshakeTimeout is used if ws_handshake_timeout is not configured
+	wsHandshakeTimeout = 10 * time.Second
+)
+
+// wsNotification is a JSON-RPC notification or a response sent by dcrd over the websocket
+type wsNotification struct {
+	ID     *int              `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	Error  *Error            `json:"error"`
+}
+
+// wsNotifier listens to dcrd websocket notifications and converts them to blockbook notifications
+type wsNotifier struct {
+	url              string
+	header           http.Header
+	handshakeTimeout time.Duration
+	tlsConfig        *tls.Config
+	notifyNewTxs     bool
+	notifyWork       bool
+	pushHandler      func(bchain.NotificationType)
+	workHandler      WorkHandler
+	conn             *websocket.Conn
+	connLock         sync.Mutex
+	done             chan struct{}
+}
+
+// wsURL returns the configured websocket url or derives it from the rpc url
+func wsURL(c *Configuration) string {
+	if c.WSURL != "" {
+		return c.WSURL
+	}
+	u := c.RPCURL
+	if strings.HasPrefix(u, "https://") {
+		u = "wss://" + u[len("https://"):]
+	} else if strings.HasPrefix(u, "http://") {
+		u = "ws://" + u[len("http://"):]
+	}
+	return strings.TrimSuffix(u, "/") + "/ws"
+}
+
+// wsTLSConfig returns the tls configuration verifying dcrd with the certificate in ws_cert,
+// dcrd uses a self signed certificate by default. The certificate is not verified only if
+// ws_insecure_skip_verify is configured.
+func wsTLSConfig(c *Configuration) (*tls.Config, error) {
+	if c.WSInsecureSkipVerify {
+		glog.Warning("rpc: websocket certificate of dcrd is not verified")
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+	if c.WSCert == "" {
+		return nil, nil
+	}
+	pem, err := ioutil.ReadFile(c.WSCert)
+	if err != nil {
+		return nil, errors.Annotatef(err, "ws_cert %v", c.WSCert)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("ws_cert %v contains no certificate", c.WSCert)
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
+
+func newWSNotifier(c *Configuration, pushHandler func(bchain.NotificationType), workHandler WorkHandler) (*wsNotifier, error) {
+	tlsConfig, err := wsTLSConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	auth := base64.StdEncoding.EncodeToString([]byte(c.RPCUser + ":" + c.RPCPass))
+	header.Set("Authorization", "Basic "+auth)
+	handshakeTimeout := wsHandshakeTimeout
+	if c.WSHandshakeTimeout > 0 {
+		handshakeTimeout = time.Duration(c.WSHandshakeTimeout) * time.Second
+	}
+	return &wsNotifier{
+		url:              wsURL(c),
+		header:           header,
+		handshakeTimeout: handshakeTimeout,
+		tlsConfig:        tlsConfig,
+		notifyNewTxs:     c.NotifyNewTransactions,
+		notifyWork:       c.NotifyWork,
+		pushHandler:      pushHandler,
+		workHandler:      workHandler,
+		done:             make(chan struct{}),
+	}, nil
+}
+
+// connect opens the websocket connection to dcrd and subscribes to the notifications
+func (n *wsNotifier) connect() error {
+	dialer := websocket.Dialer{
+		TLSClientConfig:  n.tlsConfig,
+		HandshakeTimeout: n.handshakeTimeout,
+	}
+	conn, _, err := dialer.Dial(n.url, n.header)
+	if err != nil {
+		if e, ok := err.(net.Error); ok && e.Timeout() {
+			return errors.Errorf("websocket handshake with %v timed out after %v", n.url, n.handshakeTimeout)
+		}
+		return errors.Annotatef(err, "websocket dial %v", n.url)
+	}
+	if err = conn.WriteJSON(GenericCmd{ID: 1, Method: "notifyblocks"}); err != nil {
+		conn.Close()
+		return err
+	}
+	if n.notifyNewTxs {
+		if err = conn.WriteJSON(GenericCmd{ID: 2, Method: "notifynewtransactions", Params: []interface{}{false}}); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	if n.notifyWork {
+		if err = conn.WriteJSON(GenericCmd{ID: 3, Method: "notifywork"}); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	n.connLock.Lock()
+	n.conn = conn
+	n.connLock.Unlock()
+	glog.Info("rpc: websocket connected to ", n.url)
+	return nil
+}
+
+// run reads the notifications until the notifier is closed, reconnecting on errors
+func (n *wsNotifier) run() {
+	for {
+		n.connLock.Lock()
+		conn := n.conn
+		n.connLock.Unlock()
+		if conn != nil {
+			n.readNotifications(conn)
+		}
+		select {
+		case <-n.done:
+			return
+		case <-time.After(wsReconnectDelay):
+		}
+		if err := n.connect(); err != nil {
+			glog.Error("rpc: websocket reconnect error ", err)
+		}
+	}
+}
+
+func (n *wsNotifier) readNotifications(conn *websocket.Conn) {
+	for {
+		var m wsNotification
+		if err := conn.ReadJSON(&m); err != nil {
+			select {
+			case <-n.done:
+			default:
+				glog.Error("rpc: websocket read error ", err)
+			}
+			conn.Close()
+			return
+		}
+		n.handleNotification(&m)
+	}
+}
+
+// handleNotification converts a single dcrd notification
+func (n *wsNotifier) handleNotification(m *wsNotification) {
+	if m.Error != nil && m.Error.Message != "" {
+		glog.Error("rpc: websocket error response ", m.Error.Code, ": ", m.Error.Message)
+		return
+	}
+	switch m.Method {
+	case "blockconnected":
+		glog.V(2).Info("rpc: websocket block connected")
+		n.pushHandler(bchain.NotificationNewBlock)
+	case "txaccepted":
+		if len(m.Params) > 0 {
+			var txid string
+			if err := json.Unmarshal(m.Params[0], &txid); err != nil {
+				glog.Error("rpc: websocket txaccepted decode error ", err)
+				return
+			}
+			if glog.V(2) {
+				glog.Info("rpc: websocket new tx ", txid)
+			}
+		}
+		n.pushHandler(bchain.NotificationNewTx)
+	case "work":
+		if n.workHandler == nil {
+			return
+		}
+		w, err := parseWorkNotification(m.Params)
+		if err != nil {
+			glog.Error("rpc: websocket work decode error ", err)
+			return
+		}
+		n.workHandler(w)
+	}
+}
+
+func (n *wsNotifier) close() error {
+	close(n.done)
+	n.connLock.Lock()
+	defer n.connLock.Unlock()
+	if n.conn != nil {
+		return n.conn.Close()
+	}
+	return nil
+}
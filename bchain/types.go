@@ -33,8 +33,24 @@ var (
 	ErrTxidMissing = errors.New("Txid missing")
 	// ErrTxNotFound is returned if transaction was not found
 	ErrTxNotFound = errors.New("Tx not found")
+	// ErrBlockFutureDated is returned if the block timestamp is too far in the future
+	ErrBlockFutureDated = errors.New("Block timestamp too far in the future")
+	// ErrMethodNotSupported is returned if the backend does not implement the rpc method,
+	// usually because the backend version is too old
+	ErrMethodNotSupported = errors.New("Method not supported by backend")
+	// ErrFeatureUnsupported is returned if the feature is known to be missing in the backend
+	// because of its protocol version, the backend is not called then
+	ErrFeatureUnsupported = errors.New("Feature not supported by backend")
 )
 
+// AddressScanResult is the usage of the address derived from an xpub at the index
+type AddressScanResult struct {
+	Index   uint32 `json:"index"`
+	Address string `json:"address"`
+	Txs     uint32 `json:"txs"`
+	Used    bool   `json:"used"`
+}
+
 // Outpoint is txid together with output (or input) index
 type Outpoint struct {
 	Txid string
@@ -55,6 +71,8 @@ type Vin struct {
 	ScriptSig ScriptSig `json:"scriptSig"`
 	Sequence  uint32    `json:"sequence"`
 	Addresses []string  `json:"addresses"`
+	// Tree is the Decred transaction tree of the spent output, 0 for the regular tree, 1 for the stake tree
+	Tree int8 `json:"tree,omitempty"`
 }
 
 // ScriptPubKey contains data about output script
@@ -71,6 +89,8 @@ type Vout struct {
 	JsonValue    json.Number  `json:"value"`
 	N            uint32       `json:"n"`
 	ScriptPubKey ScriptPubKey `json:"scriptPubKey"`
+	// Decred specific
+	Version uint16 `json:"version,omitempty"`
 }
 
 // Tx is blockchain transaction
@@ -93,6 +113,8 @@ type Tx struct {
 type Block struct {
 	BlockHeader
 	Txs []Tx `json:"tx"`
+	// StakeTxs are the transactions of the Decred stake tree, they are indexed only by the ticket index
+	StakeTxs []Tx `json:"stx,omitempty"`
 }
 
 // BlockHeader contains limited data (as needed for indexing) from backend block header
@@ -104,6 +126,10 @@ type BlockHeader struct {
 	Confirmations int    `json:"confirmations"`
 	Size          int    `json:"size"`
 	Time          int64  `json:"time,omitempty"`
+	// ChainWork is the hex encoded total work of the chain up to the block, empty if the backend does not report it
+	ChainWork string `json:"chainwork,omitempty"`
+	// Raw is the serialized block header, empty if the backend does not provide it
+	Raw []byte `json:"-"`
 }
 
 // BlockInfo contains extended block header data and a list of block txids
@@ -115,6 +141,10 @@ type BlockInfo struct {
 	Bits       string      `json:"bits"`
 	Difficulty json.Number `json:"difficulty"`
 	Txids      []string    `json:"tx,omitempty"`
+	// Decred specific
+	StakeDifficulty json.Number `json:"stakedifficulty,omitempty"`
+	// ExtraData is the hex encoded extra data of the header, used by the mining pools
+	ExtraData string `json:"extradata,omitempty"`
 }
 
 // MempoolEntry is used to get data about mempool entry
@@ -135,6 +165,29 @@ type MempoolEntry struct {
 	Depends         []string    `json:"depends"`
 }
 
+// FeeHistogramItem is a bucket of the mempool fee histogram,
+// it contains the transactions paying at least FeeRate
+type FeeHistogramItem struct {
+	FeeRate int64 `json:"feeRate"` // in satoshi per byte
+	Count   int   `json:"count"`
+	Size    int64 `json:"size"`
+}
+
+// CPFPInfo describes the fee bumping of the mempool transaction by its unconfirmed descendants (child pays for parent),
+// the fee rates are in satoshi per kilobyte
+type CPFPInfo struct {
+	Txid string `json:"txid"`
+	// AncestorFeeRate is the fee rate of the transaction together with its unconfirmed ancestors
+	AncestorFeeRate int64 `json:"ancestorFeeRate"`
+	// PackageFeeRate is the highest fee rate of the ancestor sets of the descendants including the transaction,
+	// miners include the transaction at this rate if it is higher than AncestorFeeRate
+	PackageFeeRate int64    `json:"packageFeeRate"`
+	Descendants    []string `json:"descendants,omitempty"`
+	// BumpedBy is the descendant with the ancestor set paying PackageFeeRate
+	BumpedBy string `json:"bumpedBy,omitempty"`
+	Bumped   bool   `json:"bumped"`
+}
+
 // ChainInfo is used to get information about blockchain
 type ChainInfo struct {
 	Chain           string  `json:"chain"`
@@ -148,6 +201,8 @@ type ChainInfo struct {
 	ProtocolVersion string  `json:"protocolversion"`
 	Timeoffset      float64 `json:"timeoffset"`
 	Warnings        string  `json:"warnings"`
+	// SyncProgress is the backend block download progress from 0 to 1, if reported by the backend
+	SyncProgress float64 `json:"syncprogress,omitempty"`
 }
 
 // RPCError defines rpc error returned by backend
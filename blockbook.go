@@ -4,11 +4,13 @@ import (
 	"blockbook/api"
 	"blockbook/bchain"
 	"blockbook/bchain/coins"
+	"blockbook/bchain/coins/dcr"
 	"blockbook/common"
 	"blockbook/db"
 	"blockbook/server"
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"math/rand"
 	"net/http"
@@ -69,6 +71,13 @@ var (
 
 	noTxCache = flag.Bool("notxcache", false, "disable tx cache")
 
+	nodeSyncProgress = flag.Float64("nodesyncprogress", 0, "wait until the verification progress of the backend node reaches the threshold (for example 0.9999) before serving the full public interface, 0 does not wait (Decred only)")
+
+	opReturnIndex = flag.Bool("opreturnindex", false, "index the data of OP_RETURN outputs (Decred only), only the blocks synchronized with the flag are indexed")
+
+	verifyDecredIndexFlag = flag.Bool("verify-decred-index", false, "compare the indexed blocks in blockheight-blockuntil range (default all blocks) with dcrd, write the discrepancies to verifylog and exit")
+	verifyLog             = flag.String("verifylog", "verify-decred-index.log", "path to the log file of the discrepancies found by verify-decred-index")
+
 	computeColumnStats  = flag.Bool("computedbstats", false, "compute column stats and exit")
 	computeFeeStatsFlag = flag.Bool("computefeestats", false, "compute fee stats for blocks in blockheight-blockuntil range and exit")
 	dbStatsPeriodHours  = flag.Int("dbstatsperiod", 24, "period of db stats collection in hours, 0 disables stats collection")
@@ -173,6 +182,7 @@ func mainWithExitCode() int {
 		return exitCodeFatal
 	}
 	defer index.Close()
+	index.SetOpReturnIndex(*opReturnIndex)
 
 	internalState, err = newInternalState(coin, coinShortcut, coinLabel, index)
 	if err != nil {
@@ -209,11 +219,41 @@ func mainWithExitCode() int {
 		return exitCodeOK
 	}
 
+	if *verifyDecredIndexFlag {
+		n, err := verifyDecredIndex(*blockFrom, *blockUntil, *verifyLog)
+		if err != nil && err != db.ErrOperationInterrupted {
+			glog.Error("verifyDecredIndex: ", err)
+			return exitCodeFatal
+		}
+		if n > 0 {
+			return exitCodeFatal
+		}
+		return exitCodeOK
+	}
+
 	syncWorker, err = db.NewSyncWorker(index, chain, *syncWorkers, *syncChunk, *blockFrom, *dryRun, chanOsSignal, metrics, internalState)
 	if err != nil {
 		glog.Errorf("NewSyncWorker %v", err)
 		return exitCodeFatal
 	}
+	if rc, ok := coins.GetBlockChainBackend(chain).(db.ReorgChecker); ok {
+		syncWorker.SetReorgChecker(rc)
+	}
+	if dc, ok := coins.GetBlockChainBackend(chain).(db.DisapprovalChecker); ok {
+		syncWorker.SetDisapprovalChecker(dc, onDisapprovedBlock)
+	}
+	if cf, ok := coins.GetBlockChainBackend(chain).(db.ConcurrentFetcher); ok {
+		syncWorker.SetFetchWorkers(cf.FetchWorkers())
+	}
+	if h, ok := coins.GetBlockChainBackend(chain).(db.DisconnectedTxsHandler); ok {
+		syncWorker.SetDisconnectedTxsHandler(h)
+	}
+	if bp, ok := coins.GetBlockChainBackend(chain).(db.BlockPrefetcher); ok {
+		syncWorker.SetBlockPrefetchDepth(bp.BlockPrefetchDepth())
+	}
+	if d := dcr.GetDecredRPC(coins.GetBlockChainBackend(chain)); d != nil {
+		d.VerifyChainOnStartup(index)
+	}
 
 	// set the DbState to open at this moment, after all important workers are initialized
 	internalState.DbState = common.DbStateOpen
@@ -292,6 +332,13 @@ func mainWithExitCode() int {
 	go storeInternalStateLoop()
 
 	if publicServer != nil {
+		if err = waitForNodeSync(*nodeSyncProgress); err != nil {
+			if err == context.Canceled {
+				return exitCodeOK
+			}
+			glog.Error("waitForNodeSync ", err)
+			return exitCodeFatal
+		}
 		// start full public interface
 		callbacksOnNewBlock = append(callbacksOnNewBlock, publicServer.OnNewBlock)
 		callbacksOnNewTxAddr = append(callbacksOnNewTxAddr, publicServer.OnNewTxAddr)
@@ -338,7 +385,7 @@ func getBlockChainWithRetry(coin string, configfile string, pushHandler func(bch
 	timer := time.NewTimer(time.Second)
 	for i := 0; ; i++ {
 		if chain, mempool, err = coins.NewBlockChain(coin, configfile, pushHandler, metrics); err != nil {
-			if i < seconds {
+			if i < seconds && !isPermanentBackendError(err) {
 				glog.Error("rpc: ", err, " Retrying...")
 				select {
 				case <-chanOsSignal:
@@ -355,6 +402,40 @@ func getBlockChainWithRetry(coin string, configfile string, pushHandler func(bch
 	}
 }
 
+// isPermanentBackendError returns true for the errors returned by dcrd in the response, unlike the network
+// errors they are not resolved by waiting for the backend
+func isPermanentBackendError(err error) bool {
+	if _, ok := errors.Cause(err).(*dcr.DecredRPCError); ok {
+		return !dcr.IsNetworkError(err)
+	}
+	return false
+}
+
+// nodeSyncWaiter is implemented by the backends able to wait for the sync of the node
+type nodeSyncWaiter interface {
+	WaitForNodeSync(ctx context.Context, progressThreshold float64) error
+}
+
+// waitForNodeSync waits until the backend node reaches the sync progress threshold, the wait is interrupted
+// by the os signal and context.Canceled is returned
+func waitForNodeSync(progressThreshold float64) error {
+	nw, ok := coins.GetBlockChainBackend(chain).(nodeSyncWaiter)
+	if !ok || progressThreshold <= 0 {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-chanOsSignal:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	glog.Info("waitForNodeSync: waiting for the backend sync progress ", progressThreshold)
+	return nw.WaitForNodeSync(ctx, progressThreshold)
+}
+
 func startInternalServer() (*server.InternalServer, error) {
 	internalServer, err := server.NewInternalServer(*internalBinding, *certFiles, index, chain, mempool, txCache, internalState)
 	if err != nil {
@@ -521,6 +602,17 @@ func onNewBlockHash(hash string, height uint32) {
 	}
 }
 
+// onDisapprovedBlock requests the resync of the mempool, the backend returned the transactions
+// of the disapproved block to its mempool; the request is dropped if the mempool sync is not running yet,
+// the transactions are picked up by the first resync
+func onDisapprovedBlock(hash string, txids []string) {
+	glog.Info("sync: ", len(txids), " transactions of disapproved block ", hash, " returned to mempool")
+	select {
+	case chanSyncMempool <- struct{}{}:
+	default:
+	}
+}
+
 func syncMempoolLoop() {
 	defer close(chanSyncMempoolDone)
 	glog.Info("syncMempoolLoop starting")
@@ -638,6 +730,38 @@ func normalizeName(s string) string {
 	return s
 }
 
+// verifyDecredIndex compares the indexed blocks with the blocks of dcrd and writes the discrepancies
+// to the log file, it returns the number of the discrepancies
+func verifyDecredIndex(blockFrom, blockTo int, logPath string) (int, error) {
+	dcrRPC := dcr.GetDecredRPC(coins.GetBlockChainBackend(chain))
+	if dcrRPC == nil {
+		return 0, errors.New("The verification requires the Decred backend")
+	}
+	bestHeight, _, err := index.GetBestBlock()
+	if err != nil {
+		return 0, err
+	}
+	lower, higher := uint32(0), bestHeight
+	if blockFrom >= 0 {
+		lower = uint32(blockFrom)
+	}
+	if blockTo >= 0 && uint32(blockTo) < higher {
+		higher = uint32(blockTo)
+	}
+	f, err := os.Create(logPath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	start := time.Now()
+	glog.Info("verifyDecredIndex: verifying blocks ", lower, "-", higher, ", discrepancies are written to ", logPath)
+	n, err := index.VerifyIndex(chain, dcrRPC, lower, higher, func(d *db.IndexDiscrepancy) {
+		fmt.Fprintln(f, d.String())
+	}, chanOsSignal)
+	glog.Info("verifyDecredIndex: finished in ", time.Since(start), ", found ", n, " discrepancies")
+	return n, err
+}
+
 // computeFeeStats computes fee distribution in defined blocks
 func computeFeeStats(stopCompute chan os.Signal, blockFrom, blockTo int, db *db.RocksDB, chain bchain.BlockChain, txCache *db.TxCache, is *common.InternalState, metrics *common.Metrics) error {
 	start := time.Now()
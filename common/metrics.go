@@ -27,6 +27,9 @@ type Metrics struct {
 	DbColumnRows          *prometheus.GaugeVec
 	DbColumnSize          *prometheus.GaugeVec
 	BlockbookAppInfo      *prometheus.GaugeVec
+	BackendRPCLatency     *prometheus.HistogramVec
+	BackendRPCErrors      *prometheus.CounterVec
+	BackendBlocksFetched  prometheus.Counter
 }
 
 // Labels represents a collection of label name -> value mappings.
@@ -187,6 +190,30 @@ func GetMetrics(coin string) (*Metrics, error) {
 		},
 		[]string{"blockbook_version", "blockbook_commit", "blockbook_buildtime", "backend_version", "backend_subversion", "backend_protocol_version"},
 	)
+	metrics.BackendRPCLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:        "blockbook_backend_rpc_latency",
+			Help:        "Latency of backend RPC calls by backend method (in milliseconds)",
+			Buckets:     []float64{0.1, 0.5, 1, 5, 10, 25, 50, 75, 100, 250, 1000},
+			ConstLabels: Labels{"coin": coin},
+		},
+		[]string{"method"},
+	)
+	metrics.BackendRPCErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:        "blockbook_backend_rpc_errors",
+			Help:        "Number of failed backend RPC calls by backend method",
+			ConstLabels: Labels{"coin": coin},
+		},
+		[]string{"method"},
+	)
+	metrics.BackendBlocksFetched = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name:        "blockbook_backend_blocks_fetched",
+			Help:        "Number of blocks fetched from backend",
+			ConstLabels: Labels{"coin": coin},
+		},
+	)
 
 	v := reflect.ValueOf(metrics)
 	for i := 0; i < v.NumField(); i++ {
@@ -28,6 +28,11 @@ type BulkConnect struct {
 	txAddressesMap     map[string]*TxAddresses
 	balances           map[string]*AddrBalance
 	addressContracts   map[string]*AddrContracts
+	opReturns          map[string][]byte
+	tickets            map[string]*TicketInfo
+	blockTickets       map[uint32][]byte
+	swaps              map[string]*AtomicSwapSpend
+	headers            map[uint32][]byte
 	height             uint32
 }
 
@@ -49,6 +54,11 @@ func (d *RocksDB) InitBulkConnect() (*BulkConnect, error) {
 		txAddressesMap:   make(map[string]*TxAddresses),
 		balances:         make(map[string]*AddrBalance),
 		addressContracts: make(map[string]*AddrContracts),
+		opReturns:        make(map[string][]byte),
+		tickets:          make(map[string]*TicketInfo),
+		blockTickets:     make(map[uint32][]byte),
+		swaps:            make(map[string]*AtomicSwapSpend),
+		headers:          make(map[uint32][]byte),
 	}
 	if err := d.SetInconsistentState(true); err != nil {
 		return nil, err
@@ -169,11 +179,60 @@ func (b *BulkConnect) storeBulkAddresses(wb *gorocksdb.WriteBatch) error {
 	return nil
 }
 
+// storeOpReturns writes the cached OP_RETURN data of the connected blocks
+func (b *BulkConnect) storeOpReturns(wb *gorocksdb.WriteBatch) {
+	b.d.storeOpReturnValues(wb, b.opReturns)
+	b.opReturns = make(map[string][]byte)
+}
+
+func (b *BulkConnect) storeTickets(wb *gorocksdb.WriteBatch) error {
+	for height, changed := range b.blockTickets {
+		b.d.storeBlockTickets(wb, height, changed)
+	}
+	if err := b.d.storeTicketsUpdate(wb, b.tickets); err != nil {
+		return err
+	}
+	b.tickets = make(map[string]*TicketInfo)
+	b.blockTickets = make(map[uint32][]byte)
+	return nil
+}
+
+// storeAtomicSwaps writes the cached spends of the atomic swap contracts of the connected blocks
+func (b *BulkConnect) storeAtomicSwaps(wb *gorocksdb.WriteBatch) error {
+	if err := b.d.storeAtomicSwapsUpdate(wb, b.swaps); err != nil {
+		return err
+	}
+	b.swaps = make(map[string]*AtomicSwapSpend)
+	return nil
+}
+
+// storeBlockHeaders writes the cached serialized headers of the connected blocks
+func (b *BulkConnect) storeBlockHeaders(wb *gorocksdb.WriteBatch) {
+	b.d.storeBlockHeaders(wb, b.headers)
+	b.headers = make(map[uint32][]byte)
+}
+
 func (b *BulkConnect) connectBlockBitcoinType(block *bchain.Block, storeBlockTxs bool) error {
 	addresses := make(addressesMap)
 	if err := b.d.processAddressesBitcoinType(block, addresses, b.txAddressesMap, b.balances); err != nil {
 		return err
 	}
+	if err := b.d.processOpReturns(block, b.opReturns); err != nil {
+		return err
+	}
+	changed, err := b.d.processTickets(block, b.tickets)
+	if err != nil {
+		return err
+	}
+	if len(changed) > 0 {
+		b.blockTickets[block.Height] = changed
+	}
+	if err := b.d.processAtomicSwaps(block, b.swaps); err != nil {
+		return err
+	}
+	if len(block.Raw) > 0 {
+		b.headers[block.Height] = block.Raw
+	}
 	var storeAddressesChan, storeBalancesChan chan error
 	var sa bool
 	if len(b.txAddressesMap) > maxBulkTxAddresses || len(b.balances) > maxBulkBalances {
@@ -208,6 +267,15 @@ func (b *BulkConnect) connectBlockBitcoinType(block *bchain.Block, storeBlockTxs
 			if err := b.storeBulkAddresses(wb); err != nil {
 				return err
 			}
+			// the OP_RETURN data, the tickets, the atomic swaps and the headers are written together with the addresses of their blocks
+			b.storeOpReturns(wb)
+			if err := b.storeTickets(wb); err != nil {
+				return err
+			}
+			if err := b.storeAtomicSwaps(wb); err != nil {
+				return err
+			}
+			b.storeBlockHeaders(wb)
 		}
 		if storeBlockTxs {
 			if err := b.d.storeAndCleanupBlockTxs(wb, block); err != nil {
@@ -362,6 +430,14 @@ func (b *BulkConnect) Close() error {
 	if err := b.storeBulkAddresses(wb); err != nil {
 		return err
 	}
+	b.storeOpReturns(wb)
+	if err := b.storeTickets(wb); err != nil {
+		return err
+	}
+	if err := b.storeAtomicSwaps(wb); err != nil {
+		return err
+	}
+	b.storeBlockHeaders(wb)
 	if err := b.d.db.Write(b.d.wo, wb); err != nil {
 		return err
 	}
@@ -21,7 +21,7 @@ import (
 	"github.com/tecbot/gorocksdb"
 )
 
-const dbVersion = 5
+const dbVersion = 6
 
 const packedHeightBytes = 4
 const maxAddrDescLen = 1024
@@ -58,17 +58,18 @@ const (
 
 // RocksDB handle
 type RocksDB struct {
-	path         string
-	db           *gorocksdb.DB
-	wo           *gorocksdb.WriteOptions
-	ro           *gorocksdb.ReadOptions
-	cfh          []*gorocksdb.ColumnFamilyHandle
-	chainParser  bchain.BlockChainParser
-	is           *common.InternalState
-	metrics      *common.Metrics
-	cache        *gorocksdb.Cache
-	maxOpenFiles int
-	cbs          connectBlockStats
+	path          string
+	db            *gorocksdb.DB
+	wo            *gorocksdb.WriteOptions
+	ro            *gorocksdb.ReadOptions
+	cfh           []*gorocksdb.ColumnFamilyHandle
+	chainParser   bchain.BlockChainParser
+	is            *common.InternalState
+	metrics       *common.Metrics
+	cache         *gorocksdb.Cache
+	maxOpenFiles  int
+	cbs           connectBlockStats
+	opReturnIndex bool
 }
 
 const (
@@ -80,6 +81,11 @@ const (
 	// BitcoinType
 	cfAddressBalance
 	cfTxAddresses
+	// DecredType, the columns follow the BitcoinType columns
+	cfOpReturn
+	cfTickets
+	cfSwaps
+	cfHeaders
 	// EthereumType
 	cfAddressContracts = cfAddressBalance
 )
@@ -90,8 +96,23 @@ var cfBaseNames = []string{"default", "height", "addresses", "blockTxs", "transa
 
 // type specific columns
 var cfNamesBitcoinType = []string{"addressBalance", "txAddresses"}
+var cfNamesDecredType = []string{"opReturn", "tickets", "swaps", "headers"}
 var cfNamesEthereumType = []string{"addressContracts"}
 
+// decredTypeParser is implemented by the parsers of the coins indexing the Decred specific columns,
+// the columns are opened only for such parsers
+type decredTypeParser interface {
+	opReturnParser
+	ticketParser
+	atomicSwapParser
+}
+
+// decredParser returns the parser of the Decred specific columns or nil if the columns are not opened
+func (d *RocksDB) decredParser() decredTypeParser {
+	p, _ := d.chainParser.(decredTypeParser)
+	return p
+}
+
 func openDB(path string, c *gorocksdb.Cache, openFiles int) (*gorocksdb.DB, []*gorocksdb.ColumnFamilyHandle, error) {
 	// opts with bloom filter
 	opts := createAndSetDBOptions(10, c, openFiles)
@@ -121,6 +142,9 @@ func NewRocksDB(path string, cacheSize, maxOpenFiles int, parser bchain.BlockCha
 	chainType := parser.GetChainType()
 	if chainType == bchain.ChainBitcoinType {
 		cfNames = append(cfNames, cfNamesBitcoinType...)
+		if _, ok := parser.(decredTypeParser); ok {
+			cfNames = append(cfNames, cfNamesDecredType...)
+		}
 	} else if chainType == bchain.ChainEthereumType {
 		cfNames = append(cfNames, cfNamesEthereumType...)
 	} else {
@@ -134,7 +158,7 @@ func NewRocksDB(path string, cacheSize, maxOpenFiles int, parser bchain.BlockCha
 	}
 	wo := gorocksdb.NewDefaultWriteOptions()
 	ro := gorocksdb.NewDefaultReadOptions()
-	return &RocksDB{path, db, wo, ro, cfh, parser, nil, metrics, c, maxOpenFiles, connectBlockStats{}}, nil
+	return &RocksDB{path, db, wo, ro, cfh, parser, nil, metrics, c, maxOpenFiles, connectBlockStats{}, false}, nil
 }
 
 func (d *RocksDB) closeDB() error {
@@ -295,6 +319,30 @@ func (d *RocksDB) GetAddrDescTransactions(addrDesc bchain.AddressDescriptor, low
 	return nil
 }
 
+// AddressTxCursor is the position of a transaction in the address history,
+// the iteration of the address transactions can continue after it
+type AddressTxCursor struct {
+	Height uint32
+	Txid   string
+}
+
+// GetAddrDescTransactionsAfter finds the address transactions following the cursor in the order of GetAddrDescTransactions,
+// down to the lower height. If the transaction of the cursor is not at its height (it was disconnected),
+// the iteration continues below the height of the cursor.
+func (d *RocksDB) GetAddrDescTransactionsAfter(addrDesc bchain.AddressDescriptor, lower uint32, cursor *AddressTxCursor, fn GetTransactionsCallback) error {
+	found := false
+	return d.GetAddrDescTransactions(addrDesc, lower, cursor.Height, func(txid string, height uint32, indexes []int32) error {
+		if !found {
+			if height == cursor.Height {
+				found = txid == cursor.Txid
+				return nil
+			}
+			found = true
+		}
+		return fn(txid, height, indexes)
+	})
+}
+
 const (
 	opInsert = 0
 	opDelete = 1
@@ -330,6 +378,16 @@ func (d *RocksDB) ConnectBlock(block *bchain.Block) error {
 		if err := d.storeAndCleanupBlockTxs(wb, block); err != nil {
 			return err
 		}
+		if err := d.storeOpReturns(wb, block); err != nil {
+			return err
+		}
+		if err := d.storeTickets(wb, block); err != nil {
+			return err
+		}
+		if err := d.storeAtomicSwaps(wb, block); err != nil {
+			return err
+		}
+		d.storeBlockHeader(wb, block)
 	} else if chainType == bchain.ChainEthereumType {
 		addressContracts := make(map[string]*AddrContracts)
 		blockTxs, err := d.processAddressesEthereumType(block, addresses, addressContracts)
@@ -1279,6 +1337,25 @@ func (d *RocksDB) disconnectTxAddresses(wb *gorocksdb.WriteBatch, height uint32,
 	return nil
 }
 
+// getBlockRangeTxids returns the txids of the blocks in range lower-higher stored in the blockTxs column
+func (d *RocksDB) getBlockRangeTxids(lower uint32, higher uint32) ([]string, error) {
+	var txids []string
+	for height := lower; height <= higher; height++ {
+		bt, err := d.getBlockTxs(height)
+		if err != nil {
+			return nil, err
+		}
+		for i := range bt {
+			txid, err := d.chainParser.UnpackTxid(bt[i].btxID)
+			if err != nil {
+				return nil, err
+			}
+			txids = append(txids, txid)
+		}
+	}
+	return txids, nil
+}
+
 // DisconnectBlockRangeBitcoinType removes all data belonging to blocks in range lower-higher
 // it is able to disconnect only blocks for which there are data in the blockTxs column
 func (d *RocksDB) DisconnectBlockRangeBitcoinType(lower uint32, higher uint32) error {
@@ -1298,6 +1375,9 @@ func (d *RocksDB) DisconnectBlockRangeBitcoinType(lower uint32, higher uint32) e
 	txAddressesToUpdate := make(map[string]*TxAddresses)
 	txsToDelete := make(map[string]struct{})
 	balances := make(map[string]*AddrBalance)
+	opReturns := make(map[string][]byte)
+	tickets := make(map[string]*TicketInfo)
+	swaps := make(map[string]*AtomicSwapSpend)
 	for height := higher; height >= lower; height-- {
 		blockTxs := blocks[height-lower]
 		glog.Info("Disconnecting block ", height, " containing ", len(blockTxs), " transactions")
@@ -1320,13 +1400,32 @@ func (d *RocksDB) DisconnectBlockRangeBitcoinType(lower uint32, higher uint32) e
 			if err := d.disconnectTxAddresses(wb, height, btxID, blockTxs[i].inputs, txa, txAddressesToUpdate, balances); err != nil {
 				return err
 			}
+			if err := d.disconnectOpReturns(btxID, txa, opReturns); err != nil {
+				return err
+			}
+			if err := d.disconnectAtomicSwaps(btxID, blockTxs[i].inputs, swaps); err != nil {
+				return err
+			}
+		}
+		if err := d.disconnectBlockTickets(wb, height, tickets); err != nil {
+			return err
 		}
 		key := packUint(height)
 		wb.DeleteCF(d.cfh[cfBlockTxs], key)
 		wb.DeleteCF(d.cfh[cfHeight], key)
+		if d.decredParser() != nil {
+			wb.DeleteCF(d.cfh[cfHeaders], key)
+		}
 	}
 	d.storeTxAddresses(wb, txAddressesToUpdate)
 	d.storeBalancesDisconnect(wb, balances)
+	d.storeOpReturnValues(wb, opReturns)
+	if err := d.storeTicketsUpdate(wb, tickets); err != nil {
+		return err
+	}
+	if err := d.storeAtomicSwapsUpdate(wb, swaps); err != nil {
+		return err
+	}
 	for s := range txsToDelete {
 		b := []byte(s)
 		wb.DeleteCF(d.cfh[cfTransactions], b)
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"blockbook/bchain"
+
+	"github.com/tecbot/gorocksdb"
+)
+
+// storeBlockHeader adds the serialized header of the block to the headers column,
+// the blocks without the serialized header are skipped
+func (d *RocksDB) storeBlockHeader(wb *gorocksdb.WriteBatch, block *bchain.Block) {
+	if len(block.Raw) == 0 {
+		return
+	}
+	d.storeBlockHeaders(wb, map[uint32][]byte{block.Height: block.Raw})
+}
+
+// storeBlockHeaders adds the serialized headers of the blocks by their heights to the headers column
+func (d *RocksDB) storeBlockHeaders(wb *gorocksdb.WriteBatch, headers map[uint32][]byte) {
+	if d.decredParser() == nil {
+		return
+	}
+	for height, header := range headers {
+		wb.PutCF(d.cfh[cfHeaders], packUint(height), header)
+	}
+}
+
+// GetBlockHeaderBytes returns the serialized block header at the height or nil if the header is not stored
+func (d *RocksDB) GetBlockHeaderBytes(height uint32) ([]byte, error) {
+	if d.decredParser() == nil {
+		return nil, nil
+	}
+	val, err := d.db.GetCF(d.ro, d.cfh[cfHeaders], packUint(height))
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	if len(val.Data()) == 0 {
+		return nil, nil
+	}
+	return append([]byte(nil), val.Data()...), nil
+}
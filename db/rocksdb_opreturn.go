@@ -0,0 +1,195 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"bytes"
+	"encoding/hex"
+
+	vlq "github.com/bsm/go-vlq"
+	"github.com/juju/errors"
+	"github.com/tecbot/gorocksdb"
+)
+
+// opReturn is the OP_RETURN opcode, the first byte of the nulldata scripts
+const opReturn = 0x6a
+
+// opReturnParser is implemented by the parsers able to extract the data embedded in OP_RETURN outputs
+type opReturnParser interface {
+	ParseOpReturnData(script []byte) ([]byte, error)
+}
+
+// OpReturnOutput is the output containing the OP_RETURN data
+type OpReturnOutput struct {
+	Txid string
+	Vout int32
+}
+
+// SetOpReturnIndex enables the index of the OP_RETURN data, the data are indexed only in the blocks
+// connected while the index is enabled and only if the chain parser can extract the data
+func (d *RocksDB) SetOpReturnIndex(enabled bool) {
+	d.opReturnIndex = enabled
+}
+
+func (d *RocksDB) opReturnParser() opReturnParser {
+	if !d.opReturnIndex {
+		return nil
+	}
+	if p := d.decredParser(); p != nil {
+		return p
+	}
+	return nil
+}
+
+// packOpReturnOutput packs the output as btxID followed by varuint vout
+func (d *RocksDB) packOpReturnOutput(btxID []byte, vout int32) []byte {
+	varBuf := make([]byte, vlq.MaxLen32)
+	l := packVaruint(uint(vout), varBuf)
+	buf := make([]byte, 0, len(btxID)+l)
+	buf = append(buf, btxID...)
+	return append(buf, varBuf[:l]...)
+}
+
+func (d *RocksDB) unpackOpReturnOutputs(buf []byte) ([]OpReturnOutput, error) {
+	pl := d.chainParser.PackedTxidLen()
+	var r []OpReturnOutput
+	for len(buf) > 0 {
+		if len(buf) < pl {
+			return nil, errors.New("Inconsistent data in opReturn column")
+		}
+		txid, err := d.chainParser.UnpackTxid(buf[:pl])
+		if err != nil {
+			return nil, err
+		}
+		vout, l := unpackVaruint(buf[pl:])
+		r = append(r, OpReturnOutput{Txid: txid, Vout: int32(vout)})
+		buf = buf[pl+l:]
+	}
+	return r, nil
+}
+
+func (d *RocksDB) getOpReturnValue(data []byte, values map[string][]byte) ([]byte, error) {
+	s := string(data)
+	if v, found := values[s]; found {
+		return v, nil
+	}
+	val, err := d.db.GetCF(d.ro, d.cfh[cfOpReturn], data)
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	v := append([]byte{}, val.Data()...)
+	values[s] = v
+	return v, nil
+}
+
+// storeOpReturns adds the OP_RETURN data of the block outputs to the index
+func (d *RocksDB) storeOpReturns(wb *gorocksdb.WriteBatch, block *bchain.Block) error {
+	values := make(map[string][]byte)
+	if err := d.processOpReturns(block, values); err != nil {
+		return err
+	}
+	d.storeOpReturnValues(wb, values)
+	return nil
+}
+
+// processOpReturns adds the OP_RETURN data of the block outputs to the values map,
+// the values not yet in the map are read from the db
+func (d *RocksDB) processOpReturns(block *bchain.Block, values map[string][]byte) error {
+	p := d.opReturnParser()
+	if p == nil {
+		return nil
+	}
+	for i := range block.Txs {
+		tx := &block.Txs[i]
+		var btxID []byte
+		for j := range tx.Vout {
+			script, err := hex.DecodeString(tx.Vout[j].ScriptPubKey.Hex)
+			if err != nil || len(script) == 0 || script[0] != opReturn {
+				continue
+			}
+			data, err := p.ParseOpReturnData(script)
+			if err != nil || len(data) == 0 {
+				continue
+			}
+			if btxID == nil {
+				if btxID, err = d.chainParser.PackTxid(tx.Txid); err != nil {
+					return err
+				}
+			}
+			v, err := d.getOpReturnValue(data, values)
+			if err != nil {
+				return err
+			}
+			values[string(data)] = append(v, d.packOpReturnOutput(btxID, int32(tx.Vout[j].N))...)
+		}
+	}
+	return nil
+}
+
+// disconnectOpReturns removes the OP_RETURN data of the transaction outputs from the index,
+// the modified values are kept in the values map shared by all the disconnected transactions.
+// The data are removed even if the index is disabled now, the blocks may have been connected
+// while it was enabled.
+func (d *RocksDB) disconnectOpReturns(btxID []byte, txa *TxAddresses, values map[string][]byte) error {
+	var p opReturnParser
+	if dp := d.decredParser(); dp != nil {
+		p = dp
+	}
+	if p == nil {
+		return nil
+	}
+	for i := range txa.Outputs {
+		script := txa.Outputs[i].AddrDesc
+		if len(script) == 0 || script[0] != opReturn {
+			continue
+		}
+		data, err := p.ParseOpReturnData(script)
+		if err != nil || len(data) == 0 {
+			continue
+		}
+		v, err := d.getOpReturnValue(data, values)
+		if err != nil {
+			return err
+		}
+		values[string(data)] = d.removeOpReturnOutput(v, btxID, int32(i))
+	}
+	return nil
+}
+
+// removeOpReturnOutput returns the packed outputs without the output btxID:vout
+func (d *RocksDB) removeOpReturnOutput(buf []byte, btxID []byte, vout int32) []byte {
+	pl := d.chainParser.PackedTxidLen()
+	r := make([]byte, 0, len(buf))
+	for len(buf) >= pl {
+		v, l := unpackVaruint(buf[pl:])
+		if int32(v) != vout || !bytes.Equal(buf[:pl], btxID) {
+			r = append(r, buf[:pl+l]...)
+		}
+		buf = buf[pl+l:]
+	}
+	return r
+}
+
+// storeOpReturnValues writes the modified values, the empty values are deleted
+func (d *RocksDB) storeOpReturnValues(wb *gorocksdb.WriteBatch, values map[string][]byte) {
+	for data, v := range values {
+		if len(v) == 0 {
+			wb.DeleteCF(d.cfh[cfOpReturn], []byte(data))
+		} else {
+			wb.PutCF(d.cfh[cfOpReturn], []byte(data), v)
+		}
+	}
+}
+
+// GetOpReturnOutputs returns the outputs containing the given OP_RETURN data
+func (d *RocksDB) GetOpReturnOutputs(data []byte) ([]OpReturnOutput, error) {
+	if d.decredParser() == nil {
+		return nil, nil
+	}
+	val, err := d.db.GetCF(d.ro, d.cfh[cfOpReturn], data)
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	return d.unpackOpReturnOutputs(val.Data())
+}
@@ -0,0 +1,243 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"bytes"
+
+	vlq "github.com/bsm/go-vlq"
+	"github.com/juju/errors"
+	"github.com/tecbot/gorocksdb"
+)
+
+// AtomicSwapStatus is the way the atomic swap contract was spent
+type AtomicSwapStatus byte
+
+// atomic swap statuses, only the spent contracts can be recognized and are stored
+const (
+	AtomicSwapRedeemed AtomicSwapStatus = iota
+	AtomicSwapRefunded
+)
+
+func (s AtomicSwapStatus) String() string {
+	switch s {
+	case AtomicSwapRedeemed:
+		return "redeemed"
+	case AtomicSwapRefunded:
+		return "refunded"
+	}
+	return "unknown"
+}
+
+// atomicSwapParser is implemented by the parsers of the chains with the indexed atomic swap contracts
+type atomicSwapParser interface {
+	// GetAtomicSwapSpend returns the contract and the secret revealed by the input spending an atomic swap contract,
+	// the secret is nil for the refunds, false if the input does not spend an atomic swap contract
+	GetAtomicSwapSpend(tx *bchain.Tx, input int) (contract []byte, secret []byte, ok bool)
+}
+
+// AtomicSwapSpend is the spend of the atomic swap contract output stored in the swaps column,
+// the column is keyed by the contract output
+type AtomicSwapSpend struct {
+	Status      AtomicSwapStatus
+	SpendTxid   string
+	SpendHeight uint32
+	Contract    []byte
+	Secret      []byte
+}
+
+func (d *RocksDB) atomicSwapParser() atomicSwapParser {
+	if p := d.decredParser(); p != nil {
+		return p
+	}
+	return nil
+}
+
+// packAtomicSwapSpend packs the spend as status, spending btxID, varuint spend height
+// and varuint length prefixed contract and secret
+func (d *RocksDB) packAtomicSwapSpend(as *AtomicSwapSpend) ([]byte, error) {
+	btxID, err := d.chainParser.PackTxid(as.SpendTxid)
+	if err != nil {
+		return nil, err
+	}
+	varBuf := make([]byte, vlq.MaxLen32)
+	buf := make([]byte, 0, 1+len(btxID)+3*vlq.MaxLen32+len(as.Contract)+len(as.Secret))
+	buf = append(buf, byte(as.Status))
+	buf = append(buf, btxID...)
+	l := packVaruint(uint(as.SpendHeight), varBuf)
+	buf = append(buf, varBuf[:l]...)
+	l = packVaruint(uint(len(as.Contract)), varBuf)
+	buf = append(buf, varBuf[:l]...)
+	buf = append(buf, as.Contract...)
+	l = packVaruint(uint(len(as.Secret)), varBuf)
+	buf = append(buf, varBuf[:l]...)
+	return append(buf, as.Secret...), nil
+}
+
+func (d *RocksDB) unpackAtomicSwapSpend(buf []byte) (*AtomicSwapSpend, error) {
+	pl := d.chainParser.PackedTxidLen()
+	if len(buf) < 1+pl {
+		return nil, errors.New("Inconsistent data in swaps column")
+	}
+	var as AtomicSwapSpend
+	as.Status = AtomicSwapStatus(buf[0])
+	txid, err := d.chainParser.UnpackTxid(buf[1 : 1+pl])
+	if err != nil {
+		return nil, err
+	}
+	as.SpendTxid = txid
+	buf = buf[1+pl:]
+	h, l := unpackVaruint(buf)
+	as.SpendHeight = uint32(h)
+	buf = buf[l:]
+	cl, l := unpackVaruint(buf)
+	buf = buf[l:]
+	if len(buf) < int(cl) {
+		return nil, errors.New("Inconsistent data in swaps column")
+	}
+	as.Contract = append([]byte(nil), buf[:cl]...)
+	buf = buf[cl:]
+	sl, l := unpackVaruint(buf)
+	buf = buf[l:]
+	if len(buf) < int(sl) {
+		return nil, errors.New("Inconsistent data in swaps column")
+	}
+	if sl > 0 {
+		as.Secret = append([]byte(nil), buf[:sl]...)
+	}
+	return &as, nil
+}
+
+// getAtomicSwapSpend returns the spend from the map of the modified spends or from the db,
+// the spends found in the db are added to the map
+func (d *RocksDB) getAtomicSwapSpend(key []byte, swaps map[string]*AtomicSwapSpend) (*AtomicSwapSpend, error) {
+	s := string(key)
+	if as, found := swaps[s]; found {
+		return as, nil
+	}
+	val, err := d.db.GetCF(d.ro, d.cfh[cfSwaps], key)
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	if len(val.Data()) == 0 {
+		return nil, nil
+	}
+	as, err := d.unpackAtomicSwapSpend(val.Data())
+	if err != nil {
+		return nil, err
+	}
+	swaps[s] = as
+	return as, nil
+}
+
+// storeAtomicSwaps adds the inputs of the block spending the atomic swap contracts to the swaps column
+func (d *RocksDB) storeAtomicSwaps(wb *gorocksdb.WriteBatch, block *bchain.Block) error {
+	swaps := make(map[string]*AtomicSwapSpend)
+	if err := d.processAtomicSwaps(block, swaps); err != nil {
+		return err
+	}
+	return d.storeAtomicSwapsUpdate(wb, swaps)
+}
+
+// processAtomicSwaps adds the inputs of the block spending the atomic swap contracts to the swaps map
+func (d *RocksDB) processAtomicSwaps(block *bchain.Block, swaps map[string]*AtomicSwapSpend) error {
+	p := d.atomicSwapParser()
+	if p == nil {
+		return nil
+	}
+	for i := range block.Txs {
+		tx := &block.Txs[i]
+		for j := range tx.Vin {
+			vin := &tx.Vin[j]
+			if vin.Txid == "" {
+				continue
+			}
+			contract, secret, ok := p.GetAtomicSwapSpend(tx, j)
+			if !ok {
+				continue
+			}
+			btxID, err := d.chainParser.PackTxid(vin.Txid)
+			if err != nil {
+				return err
+			}
+			as := &AtomicSwapSpend{
+				Status:      AtomicSwapRefunded,
+				SpendTxid:   tx.Txid,
+				SpendHeight: block.Height,
+				Contract:    contract,
+				Secret:      secret,
+			}
+			if secret != nil {
+				as.Status = AtomicSwapRedeemed
+			}
+			swaps[string(d.packOpReturnOutput(btxID, int32(vin.Vout)))] = as
+		}
+	}
+	return nil
+}
+
+// disconnectAtomicSwaps removes the contract spends of the transaction, the modified spends are kept
+// in the swaps map shared by all the disconnected transactions
+func (d *RocksDB) disconnectAtomicSwaps(btxID []byte, inputs []outpoint, swaps map[string]*AtomicSwapSpend) error {
+	if d.atomicSwapParser() == nil {
+		return nil
+	}
+	for i := range inputs {
+		if inputs[i].index < 0 {
+			continue
+		}
+		key := d.packOpReturnOutput(inputs[i].btxID, inputs[i].index)
+		as, err := d.getAtomicSwapSpend(key, swaps)
+		if err != nil {
+			return err
+		}
+		if as == nil {
+			continue
+		}
+		spendBtxID, err := d.chainParser.PackTxid(as.SpendTxid)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(spendBtxID, btxID) {
+			swaps[string(key)] = nil
+		}
+	}
+	return nil
+}
+
+// storeAtomicSwapsUpdate writes the modified contract spends, the nil spends are deleted
+func (d *RocksDB) storeAtomicSwapsUpdate(wb *gorocksdb.WriteBatch, swaps map[string]*AtomicSwapSpend) error {
+	for s, as := range swaps {
+		if as == nil {
+			wb.DeleteCF(d.cfh[cfSwaps], []byte(s))
+			continue
+		}
+		buf, err := d.packAtomicSwapSpend(as)
+		if err != nil {
+			return err
+		}
+		wb.PutCF(d.cfh[cfSwaps], []byte(s), buf)
+	}
+	return nil
+}
+
+// GetAtomicSwapSpend returns the spend of the atomic swap contract output or nil if the output
+// is not spent or is not a contract known to the index
+func (d *RocksDB) GetAtomicSwapSpend(txid string, vout int32) (*AtomicSwapSpend, error) {
+	if d.atomicSwapParser() == nil {
+		return nil, nil
+	}
+	btxID, err := d.chainParser.PackTxid(txid)
+	if err != nil {
+		return nil, err
+	}
+	val, err := d.db.GetCF(d.ro, d.cfh[cfSwaps], d.packOpReturnOutput(btxID, vout))
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	if len(val.Data()) == 0 {
+		return nil, nil
+	}
+	return d.unpackAtomicSwapSpend(val.Data())
+}
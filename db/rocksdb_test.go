@@ -7,8 +7,10 @@ import (
 	"blockbook/bchain/coins/btc"
 	"blockbook/common"
 	"blockbook/tests/dbtestdata"
+	"bytes"
 	"encoding/binary"
 	"encoding/hex"
+	"fmt"
 	"io/ioutil"
 	"math/big"
 	"os"
@@ -36,6 +38,42 @@ type testBitcoinParser struct {
 	*btc.BitcoinParser
 }
 
+// testDecredTypeParser is the bitcoin test parser with the interfaces of the Decred specific columns,
+// it extracts the OP_RETURN data and recognizes the tickets, votes and atomic swap redemptions listed by their txids
+type testDecredTypeParser struct {
+	*btc.BitcoinParser
+	// tickets are the commitments of the ticket purchases by txid
+	tickets map[string]bchain.AddressDescriptor
+	// votes are the tickets spent by the votes by txid
+	votes map[string]string
+	// swapRedemptions are the transactions redeeming an atomic swap contract by their first input
+	swapRedemptions map[string]bool
+}
+
+func (p *testDecredTypeParser) ParseOpReturnData(script []byte) ([]byte, error) {
+	if len(script) < 2 || int(script[1]) != len(script)-2 {
+		return nil, errors.New("Unsupported OP_RETURN script")
+	}
+	return script[2:], nil
+}
+
+func (p *testDecredTypeParser) GetTicketCommitment(tx *bchain.Tx) (bchain.AddressDescriptor, bool) {
+	addrDesc, ok := p.tickets[tx.Txid]
+	return addrDesc, ok
+}
+
+func (p *testDecredTypeParser) GetSpentTicket(tx *bchain.Tx) (string, bool, bool) {
+	ticket, ok := p.votes[tx.Txid]
+	return ticket, ok, ok
+}
+
+func (p *testDecredTypeParser) GetAtomicSwapSpend(tx *bchain.Tx, input int) ([]byte, []byte, bool) {
+	if input == 0 && p.swapRedemptions[tx.Txid] {
+		return []byte("contract"), []byte("secret"), true
+	}
+	return nil, nil, false
+}
+
 func bitcoinTestnetParser() *btc.BitcoinParser {
 	return btc.NewBitcoinParser(
 		btc.GetChainParams("test"),
@@ -486,6 +524,23 @@ func verifyGetTransactions(t *testing.T, d *RocksDB, addr string, low, high uint
 	}
 }
 
+func verifyGetTransactionsAfter(t *testing.T, d *RocksDB, addr string, cursor *AddressTxCursor, wantTxids []string) {
+	addrDesc, err := d.chainParser.GetAddrDescFromAddress(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotTxids := make([]string, 0)
+	if err := d.GetAddrDescTransactionsAfter(addrDesc, 0, cursor, func(txid string, height uint32, indexes []int32) error {
+		gotTxids = append(gotTxids, txid)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotTxids, wantTxids) {
+		t.Errorf("GetAddrDescTransactionsAfter(%v) = %v, want %v", cursor, gotTxids, wantTxids)
+	}
+}
+
 // override PackTx and UnpackTx to default BaseParser functionality
 // BitcoinParser uses tx hex which is not available for the test transactions
 func (p *testBitcoinParser) PackTx(tx *bchain.Tx, height uint32, blockTime int64) ([]byte, error) {
@@ -566,6 +621,9 @@ func TestRocksDB_Index_BitcoinType(t *testing.T) {
 		{dbtestdata.TxidB2T1, 0},
 	}, nil)
 	verifyGetTransactions(t, d, "mtGXQvBowMkBpnhLckhxhbwYK44Gs9eBad", 500000, 1000000, []txidIndex{}, errors.New("checksum mismatch"))
+	verifyGetTransactionsAfter(t, d, dbtestdata.Addr6, &AddressTxCursor{Height: 225494, Txid: dbtestdata.TxidB2T2}, []string{dbtestdata.TxidB2T1})
+	verifyGetTransactionsAfter(t, d, dbtestdata.Addr2, &AddressTxCursor{Height: 225494, Txid: dbtestdata.TxidB2T1}, []string{dbtestdata.TxidB1T1})
+	verifyGetTransactionsAfter(t, d, dbtestdata.Addr2, &AddressTxCursor{Height: 225493, Txid: dbtestdata.TxidB1T1}, []string{})
 
 	// GetBestBlock
 	height, hash, err := d.GetBestBlock()
@@ -1071,3 +1129,437 @@ func Test_packAddrBalance_unpackAddrBalance(t *testing.T) {
 		})
 	}
 }
+
+func TestRocksDB_VerifyIndex(t *testing.T) {
+	d := setupRocksDB(t, &testBitcoinParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+	chain, err := dbtestdata.NewFakeBlockChain(d.chainParser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block1 := dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser)
+	block2 := dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser)
+	if err := d.ConnectBlock(block1); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ConnectBlock(block2); err != nil {
+		t.Fatal(err)
+	}
+	var found []*IndexDiscrepancy
+	report := func(id *IndexDiscrepancy) { found = append(found, id) }
+	n, err := d.VerifyIndex(chain, nil, block1.Height, block2.Height, report, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 || len(found) != 0 {
+		t.Fatalf("VerifyIndex() found %d discrepancies %v in a consistent index", n, found)
+	}
+
+	btxID, err := d.chainParser.PackTxid(block2.Txs[1].Txid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.db.DeleteCF(d.wo, d.cfh[cfTxAddresses], btxID); err != nil {
+		t.Fatal(err)
+	}
+	n, err = d.VerifyIndex(chain, nil, block1.Height, block2.Height, report, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*IndexDiscrepancy{{Height: block2.Height, Hash: block2.Hash, Txid: block2.Txs[1].Txid, Message: "transaction is not indexed"}}
+	if n != 1 || !reflect.DeepEqual(found, want) {
+		t.Errorf("VerifyIndex() = %d, %v, want %v", n, found, want)
+	}
+}
+
+func TestRocksDB_BlockHeaderBytes(t *testing.T) {
+	d := setupRocksDB(t, &testDecredTypeParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+	block1 := dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser)
+	block2 := dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser)
+	block1.Raw = []byte{5, 6}
+	block2.Raw = []byte{1, 2, 3, 4}
+	// the headers are cached by the bulk connect until the addresses of the blocks are written
+	bc, err := d.InitBulkConnect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.ConnectBlock(block1, false); err != nil {
+		t.Fatal(err)
+	}
+	if h, err := d.GetBlockHeaderBytes(block1.Height); err != nil || h != nil {
+		t.Errorf("GetBlockHeaderBytes(%d) before Close = %v, %v, want nil", block1.Height, h, err)
+	}
+	if err := bc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if h, err := d.GetBlockHeaderBytes(block1.Height); err != nil || !bytes.Equal(h, block1.Raw) {
+		t.Errorf("GetBlockHeaderBytes(%d) = %v, %v, want %v", block1.Height, h, err, block1.Raw)
+	}
+	if err := d.ConnectBlock(block2); err != nil {
+		t.Fatal(err)
+	}
+	if h, err := d.GetBlockHeaderBytes(block2.Height); err != nil || !bytes.Equal(h, block2.Raw) {
+		t.Errorf("GetBlockHeaderBytes(%d) = %v, %v, want %v", block2.Height, h, err, block2.Raw)
+	}
+	if err := d.DisconnectBlockRangeBitcoinType(block2.Height, block2.Height); err != nil {
+		t.Fatal(err)
+	}
+	if h, err := d.GetBlockHeaderBytes(block2.Height); err != nil || h != nil {
+		t.Errorf("GetBlockHeaderBytes(%d) after disconnect = %v, %v, want nil", block2.Height, h, err)
+	}
+}
+
+func TestRocksDB_DecredTypeColumns(t *testing.T) {
+	d := setupRocksDB(t, &testBitcoinParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	got := append([]string{}, cfNames...)
+	closeAndDestroyRocksDB(t, d)
+	want := append(append([]string{}, cfBaseNames...), cfNamesBitcoinType...)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bitcoin columns = %v, want %v", got, want)
+	}
+	d = setupRocksDB(t, &testDecredTypeParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	got = append([]string{}, cfNames...)
+	closeAndDestroyRocksDB(t, d)
+	want = append(want, cfNamesDecredType...)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decred columns = %v, want %v", got, want)
+	}
+}
+
+func Test_BulkConnect_OpReturns(t *testing.T) {
+	d := setupRocksDB(t, &testDecredTypeParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+	d.SetOpReturnIndex(true)
+	bc, err := d.InitBulkConnect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.ConnectBlock(dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.ConnectBlock(dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser), false); err != nil {
+		t.Fatal(err)
+	}
+	data := hexToBytes(dbtestdata.TxidB2T1Output3OpReturn)[2:]
+	// the data are cached by the bulk connect until the addresses of the blocks are written
+	if o, err := d.GetOpReturnOutputs(data); err != nil || len(o) != 0 {
+		t.Errorf("GetOpReturnOutputs() before Close = %v, %v, want none", o, err)
+	}
+	if err := bc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := []OpReturnOutput{{Txid: dbtestdata.TxidB2T1, Vout: 2}}
+	if o, err := d.GetOpReturnOutputs(data); err != nil || !reflect.DeepEqual(o, want) {
+		t.Errorf("GetOpReturnOutputs() = %v, %v, want %v", o, err, want)
+	}
+}
+
+func TestRocksDB_DisconnectOpReturns_IndexDisabled(t *testing.T) {
+	d := setupRocksDB(t, &testDecredTypeParser{
+		BitcoinParser: btc.NewBitcoinParser(btc.GetChainParams("test"), &btc.Configuration{BlockAddressesToKeep: 10}),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+	d.SetOpReturnIndex(true)
+	block2 := dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser)
+	for _, b := range []*bchain.Block{dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser), block2} {
+		if err := d.ConnectBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data := hexToBytes(dbtestdata.TxidB2T1Output3OpReturn)[2:]
+	if o, err := d.GetOpReturnOutputs(data); err != nil || len(o) != 1 {
+		t.Fatalf("GetOpReturnOutputs() = %v, %v, want 1 output", o, err)
+	}
+	// the block indexed with the index enabled is removed from the index after the index is disabled
+	d.SetOpReturnIndex(false)
+	if err := d.DisconnectBlockRangeBitcoinType(block2.Height, block2.Height); err != nil {
+		t.Fatal(err)
+	}
+	if o, err := d.GetOpReturnOutputs(data); err != nil || len(o) != 0 {
+		t.Errorf("GetOpReturnOutputs() after disconnect = %v, %v, want none", o, err)
+	}
+}
+
+const (
+	testTicketTxid = "a56e8a1c1a0ccb5b5d28a59a5f21a8e4f1b1d0e0b2a4d6c8e0f1a3b5c7d9e1f3"
+	testVoteTxid   = "166d9e11484535d0d195d0ed704b91696bd39ca2a42391b4de0f546a5c1a5f28"
+)
+
+// testStakeTreeBlocks returns the test blocks 1 and 2 with the stake trees, the ticket purchased
+// in the 1st block is spent by the vote in the 2nd block
+func testStakeTreeBlocks(d *RocksDB) (*bchain.Block, *bchain.Block) {
+	block1 := dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser)
+	block1.StakeTxs = []bchain.Tx{{Txid: testTicketTxid}}
+	block2 := dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser)
+	block2.StakeTxs = []bchain.Tx{{Txid: testVoteTxid}}
+	return block1, block2
+}
+
+// newTestTicketParser returns the parser recognizing the tickets of testStakeTreeBlocks,
+// it keeps the block txs of both blocks so that they can be disconnected
+func newTestTicketParser() *testDecredTypeParser {
+	return &testDecredTypeParser{
+		BitcoinParser: btc.NewBitcoinParser(btc.GetChainParams("test"), &btc.Configuration{BlockAddressesToKeep: 10}),
+		tickets:       map[string]bchain.AddressDescriptor{testTicketTxid: bchain.AddressDescriptor("commitment")},
+		votes:         map[string]string{testVoteTxid: testTicketTxid},
+	}
+}
+
+func verifyTicketInfo(t *testing.T, d *RocksDB, want *TicketInfo) {
+	t.Helper()
+	got, err := d.GetTicketInfo(testTicketTxid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTicketInfo() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRocksDB_StakeTreeTickets(t *testing.T) {
+	d := setupRocksDB(t, newTestTicketParser())
+	defer closeAndDestroyRocksDB(t, d)
+	block1, block2 := testStakeTreeBlocks(d)
+	live := &TicketInfo{PurchaseHeight: block1.Height, CommittedAddrDesc: bchain.AddressDescriptor("commitment"), Status: TicketLive}
+	voted := &TicketInfo{PurchaseHeight: block1.Height, CommittedAddrDesc: bchain.AddressDescriptor("commitment"), Status: TicketVoted,
+		SpendTxid: testVoteTxid, SpendHeight: block2.Height}
+
+	if err := d.ConnectBlock(block1); err != nil {
+		t.Fatal(err)
+	}
+	verifyTicketInfo(t, d, live)
+	// the stake transactions are not indexed as the regular transactions
+	if ta, err := d.GetTxAddresses(testTicketTxid); err != nil || ta != nil {
+		t.Errorf("GetTxAddresses() of the ticket = %+v, %v, want nil", ta, err)
+	}
+	if err := d.ConnectBlock(block2); err != nil {
+		t.Fatal(err)
+	}
+	verifyTicketInfo(t, d, voted)
+	if bi, err := d.GetBlockInfo(block2.Height); err != nil || bi == nil || bi.Txs != uint32(len(block2.Txs)) {
+		t.Errorf("GetBlockInfo() = %+v, %v, want %d transactions", bi, err, len(block2.Txs))
+	}
+
+	// the disconnected vote returns the ticket to the live state, the disconnected purchase removes it
+	if err := d.DisconnectBlockRangeBitcoinType(block2.Height, block2.Height); err != nil {
+		t.Fatal(err)
+	}
+	verifyTicketInfo(t, d, live)
+	if err := d.DisconnectBlockRangeBitcoinType(block1.Height, block1.Height); err != nil {
+		t.Fatal(err)
+	}
+	verifyTicketInfo(t, d, nil)
+}
+
+func Test_BulkConnect_Tickets(t *testing.T) {
+	d := setupRocksDB(t, newTestTicketParser())
+	defer closeAndDestroyRocksDB(t, d)
+	block1, block2 := testStakeTreeBlocks(d)
+	bc, err := d.InitBulkConnect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.ConnectBlock(block1, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.ConnectBlock(block2, false); err != nil {
+		t.Fatal(err)
+	}
+	// the tickets are cached by the bulk connect until the addresses of the blocks are written
+	verifyTicketInfo(t, d, nil)
+	if err := bc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	verifyTicketInfo(t, d, &TicketInfo{PurchaseHeight: block1.Height, CommittedAddrDesc: bchain.AddressDescriptor("commitment"), Status: TicketVoted,
+		SpendTxid: testVoteTxid, SpendHeight: block2.Height})
+}
+
+func Test_BulkConnect_AtomicSwaps(t *testing.T) {
+	p := newTestTicketParser()
+	p.swapRedemptions = map[string]bool{dbtestdata.TxidB2T1: true}
+	d := setupRocksDB(t, p)
+	defer closeAndDestroyRocksDB(t, d)
+	block1, block2 := testStakeTreeBlocks(d)
+	contract := &block2.Txs[0].Vin[0]
+	bc, err := d.InitBulkConnect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.ConnectBlock(block1, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.ConnectBlock(block2, false); err != nil {
+		t.Fatal(err)
+	}
+	// the spends are cached by the bulk connect until the addresses of the blocks are written
+	if as, err := d.GetAtomicSwapSpend(contract.Txid, int32(contract.Vout)); err != nil || as != nil {
+		t.Fatalf("GetAtomicSwapSpend() before Close = %+v, %v, want nil", as, err)
+	}
+	if err := bc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	as, err := d.GetAtomicSwapSpend(contract.Txid, int32(contract.Vout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &AtomicSwapSpend{Status: AtomicSwapRedeemed, SpendTxid: dbtestdata.TxidB2T1, SpendHeight: block2.Height,
+		Contract: []byte("contract"), Secret: []byte("secret")}
+	if !reflect.DeepEqual(as, want) {
+		t.Errorf("GetAtomicSwapSpend() = %+v, want %+v", as, want)
+	}
+}
+
+// TestRocksDB_GetAddrDescTransactionsAfter_Paging pages through the history of an address with more than 10000
+// transactions in several blocks, the page boundaries fall inside the blocks and between them
+func TestRocksDB_GetAddrDescTransactionsAfter_Paging(t *testing.T) {
+	d := setupRocksDB(t, &testBitcoinParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+	const blocks, txsInBlock, pageSize = 3, 3401, 1000
+	script := dbtestdata.AddressToPubKeyHex(dbtestdata.Addr1, d.chainParser)
+	n := 0
+	for h := uint32(1); h <= blocks; h++ {
+		block := &bchain.Block{
+			BlockHeader: bchain.BlockHeader{Height: h, Hash: fmt.Sprintf("%064x", 0x10000000+h), Time: 1534858021 + int64(h)},
+		}
+		for i := 0; i < txsInBlock; i++ {
+			block.Txs = append(block.Txs, bchain.Tx{
+				Txid: fmt.Sprintf("%064x", n),
+				Vin:  []bchain.Vin{},
+				Vout: []bchain.Vout{{N: 0, ScriptPubKey: bchain.ScriptPubKey{Hex: script}, ValueSat: *big.NewInt(1)}},
+			})
+			n++
+		}
+		if err := d.ConnectBlock(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	addrDesc, err := d.chainParser.GetAddrDescFromAddress(dbtestdata.Addr1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var all []string
+	if err := d.GetAddrDescTransactions(addrDesc, 0, ^uint32(0), func(txid string, height uint32, indexes []int32) error {
+		all = append(all, txid)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != blocks*txsInBlock {
+		t.Fatalf("GetAddrDescTransactions() returned %d transactions, want %d", len(all), blocks*txsInBlock)
+	}
+
+	// the first page is taken from the start of the history, the next ones follow the cursor of the last transaction
+	var paged []string
+	var cursor *AddressTxCursor
+	for pages := 0; ; pages++ {
+		if pages > len(all)/pageSize+1 {
+			t.Fatal("paging does not end")
+		}
+		page := make([]AddressTxCursor, 0, pageSize)
+		fn := func(txid string, height uint32, indexes []int32) error {
+			page = append(page, AddressTxCursor{Height: height, Txid: txid})
+			if len(page) >= pageSize {
+				return &StopIteration{}
+			}
+			return nil
+		}
+		if cursor == nil {
+			err = d.GetAddrDescTransactions(addrDesc, 0, ^uint32(0), fn)
+		} else {
+			err = d.GetAddrDescTransactionsAfter(addrDesc, 0, cursor, fn)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range page {
+			paged = append(paged, page[i].Txid)
+		}
+		if len(page) < pageSize {
+			break
+		}
+		cursor = &page[len(page)-1]
+	}
+	if !reflect.DeepEqual(paged, all) {
+		t.Errorf("paging returned %d transactions, want %d in the order of GetAddrDescTransactions", len(paged), len(all))
+	}
+}
+
+// testNextBlockChain returns the next block after the blocks of the embedded chain
+type testNextBlockChain struct {
+	bchain.BlockChain
+	next *bchain.Block
+}
+
+func (c *testNextBlockChain) GetBestBlockHeight() (uint32, error) {
+	return c.next.Height, nil
+}
+
+func (c *testNextBlockChain) GetBlockHash(height uint32) (string, error) {
+	if height == c.next.Height {
+		return c.next.Hash, nil
+	}
+	return c.BlockChain.GetBlockHash(height)
+}
+
+func (c *testNextBlockChain) GetBlock(hash string, height uint32) (*bchain.Block, error) {
+	if hash == c.next.Hash {
+		return c.next, nil
+	}
+	return c.BlockChain.GetBlock(hash, height)
+}
+
+func TestRocksDB_VerifyIndex_Disapproved(t *testing.T) {
+	d := setupRocksDB(t, &testBitcoinParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+	fakeChain, err := dbtestdata.NewFakeBlockChain(d.chainParser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block1 := dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser)
+	block2 := dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser)
+	next := testChildBlock(block2)
+	next.Hash = "block3"
+	chain := &testNextBlockChain{BlockChain: fakeChain, next: next}
+	// the block disapproved by the next block is indexed without its transactions
+	if err := d.ConnectBlock(block1); err != nil {
+		t.Fatal(err)
+	}
+	block2.Txs = nil
+	if err := d.ConnectBlock(block2); err != nil {
+		t.Fatal(err)
+	}
+	dc := &testDisapprovalChecker{disapproved: map[string]bool{block2.Hash: true}}
+	var found []*IndexDiscrepancy
+	report := func(id *IndexDiscrepancy) { found = append(found, id) }
+	n, err := d.VerifyIndex(chain, dc, block1.Height, block2.Height, report, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 || len(found) != 0 {
+		t.Fatalf("VerifyIndex() found %d discrepancies %v in a consistent index", n, found)
+	}
+
+	// without the disapproval the missing transactions are reported
+	n, err = d.VerifyIndex(chain, nil, block1.Height, block2.Height, report, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 || len(found) != 5 || found[0].Message != "indexed 0 transactions, backend returned 4" {
+		t.Errorf("VerifyIndex() = %d, %v, want 5 discrepancies of the disapproved block", n, found)
+	}
+}
@@ -0,0 +1,270 @@
+package db
+
+import (
+	"blockbook/bchain"
+
+	vlq "github.com/bsm/go-vlq"
+	"github.com/juju/errors"
+	"github.com/tecbot/gorocksdb"
+)
+
+// TicketStatus is the state of the Decred ticket stored in the tickets column
+type TicketStatus byte
+
+// ticket statuses, the expiration of an unspent ticket depends on the current height and is not stored
+const (
+	TicketLive TicketStatus = iota
+	TicketVoted
+	TicketRevoked
+)
+
+func (s TicketStatus) String() string {
+	switch s {
+	case TicketLive:
+		return "live"
+	case TicketVoted:
+		return "voted"
+	case TicketRevoked:
+		return "revoked"
+	}
+	return "unknown"
+}
+
+// ticketParser is implemented by the parsers of the chains with stake tickets
+type ticketParser interface {
+	// GetTicketCommitment returns the reward commitment address of the ticket purchase, false if the tx is not a ticket purchase
+	GetTicketCommitment(tx *bchain.Tx) (bchain.AddressDescriptor, bool)
+	// GetSpentTicket returns the ticket spent by the vote or revocation, false for other transactions
+	GetSpentTicket(tx *bchain.Tx) (ticket string, voted bool, ok bool)
+}
+
+// TicketInfo is the lifecycle of a ticket stored in the tickets column
+type TicketInfo struct {
+	PurchaseHeight    uint32
+	CommittedAddrDesc bchain.AddressDescriptor
+	Status            TicketStatus
+	SpendTxid         string
+	SpendHeight       uint32
+}
+
+func (d *RocksDB) ticketParser() ticketParser {
+	if p := d.decredParser(); p != nil {
+		return p
+	}
+	return nil
+}
+
+// packTicketInfo packs the ticket as varuint purchase height, status, varuint length prefixed committed address
+// and for spent tickets the spending btxID followed by varuint spend height
+func (d *RocksDB) packTicketInfo(ti *TicketInfo) ([]byte, error) {
+	varBuf := make([]byte, vlq.MaxLen32)
+	buf := make([]byte, 0, 2*vlq.MaxLen32+len(ti.CommittedAddrDesc)+d.chainParser.PackedTxidLen()+vlq.MaxLen32)
+	l := packVaruint(uint(ti.PurchaseHeight), varBuf)
+	buf = append(buf, varBuf[:l]...)
+	buf = append(buf, byte(ti.Status))
+	l = packVaruint(uint(len(ti.CommittedAddrDesc)), varBuf)
+	buf = append(buf, varBuf[:l]...)
+	buf = append(buf, ti.CommittedAddrDesc...)
+	if ti.Status != TicketLive {
+		btxID, err := d.chainParser.PackTxid(ti.SpendTxid)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, btxID...)
+		l = packVaruint(uint(ti.SpendHeight), varBuf)
+		buf = append(buf, varBuf[:l]...)
+	}
+	return buf, nil
+}
+
+func (d *RocksDB) unpackTicketInfo(buf []byte) (*TicketInfo, error) {
+	var ti TicketInfo
+	h, l := unpackVaruint(buf)
+	ti.PurchaseHeight = uint32(h)
+	buf = buf[l:]
+	if len(buf) == 0 {
+		return nil, errors.New("Inconsistent data in tickets column")
+	}
+	ti.Status = TicketStatus(buf[0])
+	al, l := unpackVaruint(buf[1:])
+	buf = buf[1+l:]
+	if len(buf) < int(al) {
+		return nil, errors.New("Inconsistent data in tickets column")
+	}
+	ti.CommittedAddrDesc = append(bchain.AddressDescriptor{}, buf[:al]...)
+	buf = buf[al:]
+	if ti.Status != TicketLive {
+		pl := d.chainParser.PackedTxidLen()
+		if len(buf) < pl {
+			return nil, errors.New("Inconsistent data in tickets column")
+		}
+		txid, err := d.chainParser.UnpackTxid(buf[:pl])
+		if err != nil {
+			return nil, err
+		}
+		ti.SpendTxid = txid
+		sh, _ := unpackVaruint(buf[pl:])
+		ti.SpendHeight = uint32(sh)
+	}
+	return &ti, nil
+}
+
+// getTicketInfo returns the ticket from the map of the modified tickets or from the db,
+// the tickets found in the db are added to the map
+func (d *RocksDB) getTicketInfo(btxID []byte, tickets map[string]*TicketInfo) (*TicketInfo, error) {
+	s := string(btxID)
+	if ti, found := tickets[s]; found {
+		return ti, nil
+	}
+	val, err := d.db.GetCF(d.ro, d.cfh[cfTickets], btxID)
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	if len(val.Data()) == 0 {
+		return nil, nil
+	}
+	ti, err := d.unpackTicketInfo(val.Data())
+	if err != nil {
+		return nil, err
+	}
+	tickets[s] = ti
+	return ti, nil
+}
+
+// storeTickets adds the ticket purchases of the block to the tickets column and marks the tickets spent by votes and revocations
+func (d *RocksDB) storeTickets(wb *gorocksdb.WriteBatch, block *bchain.Block) error {
+	tickets := make(map[string]*TicketInfo)
+	changed, err := d.processTickets(block, tickets)
+	if err != nil {
+		return err
+	}
+	d.storeBlockTickets(wb, block.Height, changed)
+	return d.storeTicketsUpdate(wb, tickets)
+}
+
+// processTickets adds the ticket purchases of the stake tree of the block to the tickets map and marks the tickets
+// spent by votes and revocations, the tickets not yet in the map are read from the db.
+// It returns the packed btxIDs of the tickets changed by the block.
+func (d *RocksDB) processTickets(block *bchain.Block, tickets map[string]*TicketInfo) ([]byte, error) {
+	p := d.ticketParser()
+	if p == nil {
+		return nil, nil
+	}
+	var changed []byte
+	for i := range block.StakeTxs {
+		tx := &block.StakeTxs[i]
+		if addrDesc, ok := p.GetTicketCommitment(tx); ok {
+			btxID, err := d.chainParser.PackTxid(tx.Txid)
+			if err != nil {
+				return nil, err
+			}
+			tickets[string(btxID)] = &TicketInfo{PurchaseHeight: block.Height, CommittedAddrDesc: addrDesc}
+			changed = append(changed, btxID...)
+			continue
+		}
+		ticket, voted, ok := p.GetSpentTicket(tx)
+		if !ok {
+			continue
+		}
+		btxID, err := d.chainParser.PackTxid(ticket)
+		if err != nil {
+			return nil, err
+		}
+		ti, err := d.getTicketInfo(btxID, tickets)
+		if err != nil {
+			return nil, err
+		}
+		// the ticket purchased before the index was created
+		if ti == nil {
+			continue
+		}
+		ti.Status = TicketRevoked
+		if voted {
+			ti.Status = TicketVoted
+		}
+		ti.SpendTxid = tx.Txid
+		ti.SpendHeight = block.Height
+		changed = append(changed, btxID...)
+	}
+	return changed, nil
+}
+
+// storeBlockTickets stores the tickets changed by the block under the packed height, the stake tree
+// is not in the blockTxs column and the record is needed to disconnect the block
+func (d *RocksDB) storeBlockTickets(wb *gorocksdb.WriteBatch, height uint32, changed []byte) {
+	if len(changed) > 0 {
+		wb.PutCF(d.cfh[cfTickets], packUint(height), changed)
+	}
+}
+
+// disconnectBlockTickets removes the tickets purchased in the block and returns the tickets spent in it to the live state,
+// the modified tickets are kept in the tickets map shared by all the disconnected blocks
+func (d *RocksDB) disconnectBlockTickets(wb *gorocksdb.WriteBatch, height uint32, tickets map[string]*TicketInfo) error {
+	if d.ticketParser() == nil {
+		return nil
+	}
+	key := packUint(height)
+	val, err := d.db.GetCF(d.ro, d.cfh[cfTickets], key)
+	if err != nil {
+		return err
+	}
+	defer val.Free()
+	changed := val.Data()
+	pl := d.chainParser.PackedTxidLen()
+	for ; len(changed) >= pl; changed = changed[pl:] {
+		btxID := append([]byte(nil), changed[:pl]...)
+		ti, err := d.getTicketInfo(btxID, tickets)
+		if err != nil {
+			return err
+		}
+		if ti == nil {
+			continue
+		}
+		if ti.PurchaseHeight == height {
+			tickets[string(btxID)] = nil
+		} else if ti.Status != TicketLive && ti.SpendHeight == height {
+			ti.Status = TicketLive
+			ti.SpendTxid = ""
+			ti.SpendHeight = 0
+		}
+	}
+	wb.DeleteCF(d.cfh[cfTickets], key)
+	return nil
+}
+
+// storeTicketsUpdate writes the modified tickets, the nil tickets are deleted
+func (d *RocksDB) storeTicketsUpdate(wb *gorocksdb.WriteBatch, tickets map[string]*TicketInfo) error {
+	for s, ti := range tickets {
+		if ti == nil {
+			wb.DeleteCF(d.cfh[cfTickets], []byte(s))
+			continue
+		}
+		buf, err := d.packTicketInfo(ti)
+		if err != nil {
+			return err
+		}
+		wb.PutCF(d.cfh[cfTickets], []byte(s), buf)
+	}
+	return nil
+}
+
+// GetTicketInfo returns the lifecycle of the ticket or nil if the ticket is not in the index
+func (d *RocksDB) GetTicketInfo(txid string) (*TicketInfo, error) {
+	if d.ticketParser() == nil {
+		return nil, nil
+	}
+	btxID, err := d.chainParser.PackTxid(txid)
+	if err != nil {
+		return nil, err
+	}
+	val, err := d.db.GetCF(d.ro, d.cfh[cfTickets], btxID)
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	if len(val.Data()) == 0 {
+		return nil, nil
+	}
+	return d.unpackTicketInfo(val.Data())
+}
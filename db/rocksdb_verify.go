@@ -0,0 +1,168 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/golang/glog"
+)
+
+// IndexDiscrepancy is a difference between the indexed block and the block returned by the backend,
+// Txid is empty for the differences of the block itself
+type IndexDiscrepancy struct {
+	Height  uint32
+	Hash    string
+	Txid    string
+	Message string
+}
+
+func (d *IndexDiscrepancy) String() string {
+	if d.Txid == "" {
+		return fmt.Sprintf("height %d, block %s: %s", d.Height, d.Hash, d.Message)
+	}
+	return fmt.Sprintf("height %d, block %s, tx %s: %s", d.Height, d.Hash, d.Txid, d.Message)
+}
+
+// VerifyIndex fetches the blocks lower to higher from the backend and compares them with the index,
+// the hashes, the transactions and their outputs are checked. The discrepancies are passed to report,
+// the number of the discrepancies is returned. The backend errors stop the verification.
+// The regular transactions of the blocks disapproved by the votes of the next block are not indexed,
+// dc detects the disapproved blocks and is nil for the coins without the votes.
+func (d *RocksDB) VerifyIndex(chain bchain.BlockChain, dc DisapprovalChecker, lower, higher uint32, report func(*IndexDiscrepancy), stopVerify chan os.Signal) (int, error) {
+	found := 0
+	add := func(height uint32, hash, txid, format string, args ...interface{}) {
+		found++
+		report(&IndexDiscrepancy{Height: height, Hash: hash, Txid: txid, Message: fmt.Sprintf(format, args...)})
+	}
+	bestHeight, err := chain.GetBestBlockHeight()
+	if err != nil {
+		return found, err
+	}
+	// the next block fetched to check the disapproval of the previous block is reused in the next iteration
+	var next *bchain.Block
+	for height := lower; height <= higher; height++ {
+		select {
+		case <-stopVerify:
+			return found, ErrOperationInterrupted
+		default:
+		}
+		hash, err := chain.GetBlockHash(height)
+		if err != nil {
+			return found, err
+		}
+		bi, err := d.GetBlockInfo(height)
+		if err != nil {
+			return found, err
+		}
+		if bi == nil {
+			add(height, hash, "", "block is not indexed")
+			continue
+		}
+		if bi.Hash != hash {
+			add(height, hash, "", "indexed block hash %s", bi.Hash)
+		}
+		block := next
+		if block == nil || block.Hash != hash {
+			if block, err = chain.GetBlock(hash, height); err != nil {
+				return found, err
+			}
+		}
+		next = nil
+		if dc != nil && height < bestHeight {
+			if next, err = d.verifyDisapproval(chain, dc, block); err != nil {
+				return found, err
+			}
+		}
+		if int(bi.Txs) != len(block.Txs) {
+			add(height, hash, "", "indexed %d transactions, backend returned %d", bi.Txs, len(block.Txs))
+		}
+		if err = d.verifyBlockTxs(block, add); err != nil {
+			return found, err
+		}
+		if height%1000 == 0 {
+			glog.Info("VerifyIndex: verified block ", height, ", discrepancies ", found)
+		}
+	}
+	return found, nil
+}
+
+// verifyDisapproval fetches the block after the block and removes the transactions of the block
+// if the votes of the next block disapproved it, the next block is returned
+func (d *RocksDB) verifyDisapproval(chain bchain.BlockChain, dc DisapprovalChecker, block *bchain.Block) (*bchain.Block, error) {
+	hash, err := chain.GetBlockHash(block.Height + 1)
+	if err != nil {
+		return nil, err
+	}
+	next, err := chain.GetBlock(hash, block.Height+1)
+	if err != nil {
+		return nil, err
+	}
+	disapproved, err := dc.IsParentDisapproved(next)
+	if err != nil {
+		return nil, err
+	}
+	if disapproved {
+		block.Txs = nil
+	}
+	return next, nil
+}
+
+// verifyBlockTxs compares the transactions of the block with the txAddresses column and with the blockTxs column,
+// which is kept only for the blocks close to the tip
+func (d *RocksDB) verifyBlockTxs(block *bchain.Block, add func(height uint32, hash, txid, format string, args ...interface{})) error {
+	btxIDs := make(map[string]struct{}, len(block.Txs))
+	for i := range block.Txs {
+		tx := &block.Txs[i]
+		btxID, err := d.chainParser.PackTxid(tx.Txid)
+		if err != nil {
+			return err
+		}
+		btxIDs[string(btxID)] = struct{}{}
+		ta, err := d.getTxAddresses(btxID)
+		if err != nil {
+			return err
+		}
+		if ta == nil {
+			add(block.Height, block.Hash, tx.Txid, "transaction is not indexed")
+			continue
+		}
+		if ta.Height != block.Height {
+			add(block.Height, block.Hash, tx.Txid, "indexed at height %d", ta.Height)
+		}
+		if len(ta.Inputs) != len(tx.Vin) {
+			add(block.Height, block.Hash, tx.Txid, "indexed %d inputs, backend returned %d", len(ta.Inputs), len(tx.Vin))
+		}
+		if len(ta.Outputs) != len(tx.Vout) {
+			add(block.Height, block.Hash, tx.Txid, "indexed %d outputs, backend returned %d", len(ta.Outputs), len(tx.Vout))
+			continue
+		}
+		for j := range tx.Vout {
+			output := &tx.Vout[j]
+			tao := &ta.Outputs[j]
+			if tao.ValueSat.Cmp(&output.ValueSat) != 0 {
+				add(block.Height, block.Hash, tx.Txid, "output %d indexed value %s, backend returned %s", j, tao.ValueSat.String(), output.ValueSat.String())
+			}
+			// the outputs without a valid descriptor are indexed without it
+			addrDesc, err := d.chainParser.GetAddrDescFromVout(output)
+			if err != nil || len(addrDesc) > maxAddrDescLen {
+				addrDesc = nil
+			}
+			if !bytes.Equal(tao.AddrDesc, addrDesc) {
+				add(block.Height, block.Hash, tx.Txid, "output %d indexed address descriptor %s, backend returned %s", j, tao.AddrDesc, addrDesc)
+			}
+		}
+	}
+	bt, err := d.getBlockTxs(block.Height)
+	if err != nil {
+		return err
+	}
+	for i := range bt {
+		if _, found := btxIDs[string(bt[i].btxID)]; !found {
+			txid, _ := d.chainParser.UnpackTxid(bt[i].btxID)
+			add(block.Height, block.Hash, txid, "indexed transaction is not in the block")
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"blockbook/bchain"
 	"blockbook/common"
+	"context"
 	"os"
 	"sync"
 	"sync/atomic"
@@ -23,8 +24,46 @@ type SyncWorker struct {
 	chanOsSignal           chan os.Signal
 	metrics                *common.Metrics
 	is                     *common.InternalState
+	reorgChecker           ReorgChecker
+	disapprovalChecker     DisapprovalChecker
+	onDisapprovedBlock     OnDisapprovedBlockFunc
+	fetchWorkers           int
+	prefetchDepth          int
+	disconnectedTxsHandler DisconnectedTxsHandler
 }
 
+// ReorgChecker is implemented by the backends able to find the fork point of the indexed chain themselves
+type ReorgChecker interface {
+	CheckForReorg(lastKnownHash string, lastKnownHeight uint32) (bool, uint32, error)
+}
+
+// DisapprovalChecker is implemented by the backends of the coins in which the stakeholders can disapprove
+// the regular transactions of the previous block (Decred)
+type DisapprovalChecker interface {
+	IsParentDisapproved(block *bchain.Block) (bool, error)
+}
+
+// ConcurrentFetcher is implemented by the backends which can serve several blocks at once,
+// FetchWorkers returns the number of the blocks fetched concurrently in the regular sync
+type ConcurrentFetcher interface {
+	FetchWorkers() int
+}
+
+// BlockPrefetcher is implemented by the backends with a configurable number of the block hashes
+// resolved ahead of the initial sync
+type BlockPrefetcher interface {
+	BlockPrefetchDepth() int
+}
+
+// DisconnectedTxsHandler is implemented by the backends caching the data of the transactions,
+// RemoveDisconnectedTxs is called with the transactions of the disconnected blocks
+type DisconnectedTxsHandler interface {
+	RemoveDisconnectedTxs(txids []string)
+}
+
+// OnDisapprovedBlockFunc is called with the transactions removed from the index because their block was disapproved
+type OnDisapprovedBlockFunc func(hash string, txids []string)
+
 // NewSyncWorker creates new SyncWorker and returns its handle
 func NewSyncWorker(db *RocksDB, chain bchain.BlockChain, syncWorkers, syncChunk int, minStartHeight int, dryRun bool, chanOsSignal chan os.Signal, metrics *common.Metrics, is *common.InternalState) (*SyncWorker, error) {
 	if minStartHeight < 0 {
@@ -43,6 +82,36 @@ func NewSyncWorker(db *RocksDB, chain bchain.BlockChain, syncWorkers, syncChunk
 	}, nil
 }
 
+// SetReorgChecker sets the backend used to detect the chain reorganizations, the local and remote block hashes
+// are compared only if the check fails
+func (w *SyncWorker) SetReorgChecker(rc ReorgChecker) {
+	w.reorgChecker = rc
+}
+
+// SetDisapprovalChecker sets the backend used to detect the disapproved blocks, the onDisapproved function
+// is called after the transactions of a disapproved block are removed from the index
+func (w *SyncWorker) SetDisapprovalChecker(dc DisapprovalChecker, onDisapproved OnDisapprovedBlockFunc) {
+	w.disapprovalChecker = dc
+	w.onDisapprovedBlock = onDisapproved
+}
+
+// SetFetchWorkers sets the number of the goroutines fetching the blocks in the regular sync,
+// the blocks are still connected in the order of their heights; 0 or 1 means sequential fetching
+func (w *SyncWorker) SetFetchWorkers(n int) {
+	w.fetchWorkers = n
+}
+
+// SetBlockPrefetchDepth sets the number of the block hashes resolved concurrently ahead of the initial sync,
+// 0 means the hashes are resolved one by one by the sync loop
+func (w *SyncWorker) SetBlockPrefetchDepth(n int) {
+	w.prefetchDepth = n
+}
+
+// SetDisconnectedTxsHandler sets the backend notified about the transactions of the disconnected blocks
+func (w *SyncWorker) SetDisconnectedTxsHandler(h DisconnectedTxsHandler) {
+	w.disconnectedTxsHandler = h
+}
+
 var errSynced = errors.New("synced")
 
 // ErrOperationInterrupted is returned when operation is interrupted by OS signal
@@ -98,14 +167,13 @@ func (w *SyncWorker) resyncIndex(onNewBlock bchain.OnNewBlockFunc, initialSync b
 		return errSynced
 	}
 	if localBestHash != "" {
-		remoteHash, err := w.chain.GetBlockHash(localBestHeight)
-		// for some coins (eth) remote can be at lower best height after rollback
-		if err != nil && err != bchain.ErrBlockNotFound {
+		forked, err := w.isForked(localBestHeight, localBestHash)
+		if err != nil {
 			return err
 		}
-		if remoteHash != localBestHash {
-			// forked - the remote hash differs from the local hash at the same height
-			glog.Info("resync: local is forked at height ", localBestHeight, ", local hash ", localBestHash, ", remote hash", remoteHash)
+		if forked {
+			// the index follows the tip preferred by the backend even if it has less work than the local tip,
+			// for example if the local tip was invalidated in the backend
 			return w.handleFork(localBestHeight, localBestHash, onNewBlock, initialSync)
 		}
 		glog.Info("resync: local at ", localBestHeight, " is behind")
@@ -144,6 +212,32 @@ func (w *SyncWorker) resyncIndex(onNewBlock bchain.OnNewBlockFunc, initialSync b
 	return w.connectBlocks(onNewBlock, initialSync)
 }
 
+// isForked checks if the local best block is not on the chain of the backend. The reorg checker is used if it is set,
+// if it fails, the local hash is compared with the remote hash at the same height.
+func (w *SyncWorker) isForked(localBestHeight uint32, localBestHash string) (bool, error) {
+	if w.reorgChecker != nil {
+		reorg, forkHeight, err := w.reorgChecker.CheckForReorg(localBestHash, localBestHeight)
+		if err == nil {
+			if reorg {
+				glog.Info("resync: local is forked at height ", localBestHeight, ", local hash ", localBestHash, ", common ancestor at height ", forkHeight)
+			}
+			return reorg, nil
+		}
+		glog.Warning("resync: reorg check of ", localBestHash, " failed, comparing the block hashes, ", err)
+	}
+	remoteHash, err := w.chain.GetBlockHash(localBestHeight)
+	// for some coins (eth) remote can be at lower best height after rollback
+	if err != nil && err != bchain.ErrBlockNotFound {
+		return false, err
+	}
+	if remoteHash != localBestHash {
+		// forked - the remote hash differs from the local hash at the same height
+		glog.Info("resync: local is forked at height ", localBestHeight, ", local hash ", localBestHash, ", remote hash", remoteHash)
+		return true, nil
+	}
+	return false, nil
+}
+
 func (w *SyncWorker) handleFork(localBestHeight uint32, localBestHash string, onNewBlock bchain.OnNewBlockFunc, initialSync bool) error {
 	// find forked blocks, disconnect them and then synchronize again
 	var height uint32
@@ -177,7 +271,11 @@ func (w *SyncWorker) connectBlocks(onNewBlock bchain.OnNewBlockFunc, initialSync
 	done := make(chan struct{})
 	defer close(done)
 
-	go w.getBlockChain(bch, done)
+	if w.fetchWorkers > 1 {
+		go w.getBlockChainConcurrent(bch, done)
+	} else {
+		go w.getBlockChain(bch, done)
+	}
 
 	var lastRes, empty blockResult
 
@@ -186,6 +284,12 @@ func (w *SyncWorker) connectBlocks(onNewBlock bchain.OnNewBlockFunc, initialSync
 		if res.err != nil {
 			return res.err
 		}
+		// the votes in the block can disapprove the previous block, which must be handled before the block is connected
+		if w.disapprovalChecker != nil && res.block.Height > 0 {
+			if err := w.HandleDisapprovedBlock(res.block); err != nil {
+				return err
+			}
+		}
 		err := w.db.ConnectBlock(res.block)
 		if err != nil {
 			return err
@@ -236,10 +340,6 @@ func (w *SyncWorker) connectBlocks(onNewBlock bchain.OnNewBlockFunc, initialSync
 
 // ConnectBlocksParallel uses parallel goroutines to get data from blockchain daemon
 func (w *SyncWorker) ConnectBlocksParallel(lower, higher uint32) error {
-	type hashHeight struct {
-		hash   string
-		height uint32
-	}
 	var err error
 	var wg sync.WaitGroup
 	bch := make([]chan *bchain.Block, w.syncWorkers)
@@ -259,6 +359,7 @@ func (w *SyncWorker) ConnectBlocksParallel(lower, higher uint32) error {
 		}
 		lastBlock := lower - 1
 		keep := uint32(w.chain.GetChainParser().KeepBlockAddresses())
+		var pending *bchain.Block
 	WriteBlockLoop:
 		for {
 			select {
@@ -270,15 +371,35 @@ func (w *SyncWorker) ConnectBlocksParallel(lower, higher uint32) error {
 				if b.Height != lastBlock+1 {
 					glog.Fatal("writeBlockWorker skipped block, expected block ", lastBlock+1, ", new block ", b.Height)
 				}
-				err := bc.ConnectBlock(b, b.Height+keep > higher)
-				if err != nil {
-					glog.Fatal("writeBlockWorker ", b.Height, " ", b.Hash, " error ", err)
+				// the votes in the block can disapprove the previous block, the previous block is held back until
+				// the next one arrives; the parent of the first block is already indexed and is handled outside of the bulk
+				if pending == nil {
+					if b.Height > 0 {
+						if err := w.HandleDisapprovedBlock(b); err != nil {
+							glog.Fatal("writeBlockWorker ", b.Height, " ", b.Hash, " error ", err)
+						}
+					}
+				} else {
+					if err := w.removeDisapprovedTxs(pending, b); err != nil {
+						glog.Fatal("writeBlockWorker ", b.Height, " ", b.Hash, " error ", err)
+					}
+					if err := bc.ConnectBlock(pending, pending.Height+keep > higher); err != nil {
+						glog.Fatal("writeBlockWorker ", pending.Height, " ", pending.Hash, " error ", err)
+					}
 				}
+				pending = b
 				lastBlock = b.Height
 			case <-terminating:
+				pending = nil
 				break WriteBlockLoop
 			}
 		}
+		// the last block is checked by the votes of its successor when the successor is connected
+		if pending != nil {
+			if err := bc.ConnectBlock(pending, pending.Height+keep > higher); err != nil {
+				glog.Fatal("writeBlockWorker ", pending.Height, " ", pending.Hash, " error ", err)
+			}
+		}
 		err = bc.Close()
 		if err != nil {
 			glog.Error("sync: bulkconnect.Close error ", err)
@@ -322,38 +443,57 @@ func (w *SyncWorker) ConnectBlocksParallel(lower, higher uint32) error {
 		go getBlockWorker(i)
 	}
 	go writeBlockWorker()
+	var prefetched chan hashHeight
+	if w.prefetchDepth > 0 {
+		stopPrefetch := make(chan struct{})
+		defer close(stopPrefetch)
+		prefetched = w.prefetchBlockHashes(lower, higher, stopPrefetch)
+	}
 	var hash string
 	start := time.Now()
 	msTime := time.Now().Add(1 * time.Minute)
 ConnectLoop:
 	for h := lower; h <= higher; {
-		select {
-		case <-w.chanOsSignal:
+		interrupted := false
+		if prefetched == nil {
+			select {
+			case <-w.chanOsSignal:
+				interrupted = true
+			default:
+				hash, err = w.chain.GetBlockHash(h)
+				if err != nil {
+					glog.Error("GetBlockHash error ", err)
+					w.metrics.IndexResyncErrors.With(common.Labels{"error": "failure"}).Inc()
+					time.Sleep(time.Millisecond * 500)
+					continue
+				}
+			}
+		} else {
+			select {
+			case <-w.chanOsSignal:
+				interrupted = true
+			case hh := <-prefetched:
+				hash = hh.hash
+			}
+		}
+		if interrupted {
 			glog.Info("connectBlocksParallel interrupted at height ", h)
 			err = ErrOperationInterrupted
 			// signal all workers to terminate their loops (error loops are interrupted below)
 			close(terminating)
 			break ConnectLoop
-		default:
-			hash, err = w.chain.GetBlockHash(h)
-			if err != nil {
-				glog.Error("GetBlockHash error ", err)
-				w.metrics.IndexResyncErrors.With(common.Labels{"error": "failure"}).Inc()
-				time.Sleep(time.Millisecond * 500)
-				continue
-			}
-			hch <- hashHeight{hash, h}
-			if h > 0 && h%1000 == 0 {
-				glog.Info("connecting block ", h, " ", hash, ", elapsed ", time.Since(start), " ", w.db.GetAndResetConnectBlockStats())
-				start = time.Now()
-			}
-			if msTime.Before(time.Now()) {
-				glog.Info(w.db.GetMemoryStats())
-				w.metrics.IndexDBSize.Set(float64(w.db.DatabaseSizeOnDisk()))
-				msTime = time.Now().Add(10 * time.Minute)
-			}
-			h++
 		}
+		hch <- hashHeight{hash, h}
+		if h > 0 && h%1000 == 0 {
+			glog.Info("connecting block ", h, " ", hash, ", elapsed ", time.Since(start), " ", w.db.GetAndResetConnectBlockStats())
+			start = time.Now()
+		}
+		if msTime.Before(time.Now()) {
+			glog.Info(w.db.GetMemoryStats())
+			w.metrics.IndexDBSize.Set(float64(w.db.DatabaseSizeOnDisk()))
+			msTime = time.Now().Add(10 * time.Minute)
+		}
+		h++
 	}
 	close(hch)
 	// signal stop to workers that are in a error loop
@@ -367,6 +507,64 @@ ConnectLoop:
 	return err
 }
 
+type hashHeight struct {
+	hash   string
+	height uint32
+}
+
+// prefetchBlockHashes resolves the hashes of the blocks lower to higher ahead of the sync loop, up to prefetchDepth
+// hashes are fetched concurrently and are passed to the returned channel in the order of their heights.
+// The failed calls are retried until stop is closed.
+func (w *SyncWorker) prefetchBlockHashes(lower, higher uint32, stop chan struct{}) chan hashHeight {
+	out := make(chan hashHeight)
+	// each pending channel receives the hash of one height, the buffered channels together with the one
+	// awaited by the forwarding goroutine limit the number of the running calls to prefetchDepth
+	pending := make(chan chan hashHeight, w.prefetchDepth-1)
+	go func() {
+		defer close(pending)
+		for h := lower; h <= higher; h++ {
+			r := make(chan hashHeight, 1)
+			select {
+			case pending <- r:
+			case <-stop:
+				return
+			}
+			go func(h uint32) {
+				for {
+					hash, err := w.chain.GetBlockHash(h)
+					if err == nil {
+						r <- hashHeight{hash, h}
+						return
+					}
+					glog.Error("GetBlockHash error ", err)
+					w.metrics.IndexResyncErrors.With(common.Labels{"error": "failure"}).Inc()
+					select {
+					case <-time.After(time.Millisecond * 500):
+					case <-stop:
+						return
+					}
+				}
+			}(h)
+		}
+	}()
+	go func() {
+		defer close(out)
+		for r := range pending {
+			select {
+			case hh := <-r:
+				select {
+				case out <- hh:
+				case <-stop:
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return out
+}
+
 type blockResult struct {
 	block *bchain.Block
 	err   error
@@ -400,14 +598,214 @@ func (w *SyncWorker) getBlockChain(out chan blockResult, done chan struct{}) {
 	}
 }
 
+// fetchBufferPerWorker is the number of the blocks per fetch worker which can be fetched ahead of the indexer
+const fetchBufferPerWorker = 4
+
+// getBlockChainConcurrent fetches the blocks from startHeight to the current best height of the backend
+// by fetchWorkers goroutines and passes them to out in the order of their heights.
+// The fetching stops at the first error, when the fetched blocks do not form a chain or when done is closed.
+func (w *SyncWorker) getBlockChainConcurrent(out chan blockResult, done chan struct{}) {
+	defer close(out)
+
+	bestHeight, err := w.chain.GetBestBlockHeight()
+	if err != nil {
+		out <- blockResult{err: err}
+		return
+	}
+	if bestHeight < w.startHeight {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	type fetchedBlock struct {
+		height uint32
+		res    blockResult
+	}
+	// slots limit the number of the blocks fetched ahead of the block expected by the indexer
+	slots := make(chan struct{}, w.fetchWorkers*fetchBufferPerWorker)
+	heights := make(chan uint32)
+	fetched := make(chan fetchedBlock)
+	go func() {
+		defer close(heights)
+		for h := w.startHeight; h <= bestHeight; h++ {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case heights <- h:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < w.fetchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for h := range heights {
+				block, err := w.chain.GetBlock("", h)
+				select {
+				case fetched <- fetchedBlock{height: h, res: blockResult{block: block, err: err}}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(fetched)
+	}()
+
+	pending := make(map[uint32]blockResult)
+	next := w.startHeight
+	prevHash := w.startHash
+	for f := range fetched {
+		pending[f.height] = f.res
+		for {
+			res, found := pending[next]
+			if !found {
+				break
+			}
+			delete(pending, next)
+			<-slots
+			if res.err != nil {
+				if res.err != bchain.ErrBlockNotFound {
+					select {
+					case out <- blockResult{err: res.err}:
+					case <-ctx.Done():
+					}
+				}
+				return
+			}
+			// the first block must be the one at startHash, the following ones must link to the previous block
+			linked := res.block.Hash == prevHash
+			if next != w.startHeight {
+				linked = res.block.Prev == prevHash
+			}
+			if !linked && prevHash != "" {
+				glog.Warningf("sync: block %d %s does not link to %s, probably a reorg, stopping the fetch", res.block.Height, res.block.Hash, prevHash)
+				return
+			}
+			select {
+			case out <- res:
+			case <-ctx.Done():
+				return
+			}
+			prevHash = res.block.Hash
+			if next == bestHeight {
+				return
+			}
+			next++
+		}
+	}
+}
+
 // DisconnectBlocks removes all data belonging to blocks in range lower-higher,
 func (w *SyncWorker) DisconnectBlocks(lower uint32, higher uint32, hashes []string) error {
 	glog.Infof("sync: disconnecting blocks %d-%d", lower, higher)
 	ct := w.chain.GetChainParser().GetChainType()
 	if ct == bchain.ChainBitcoinType {
-		return w.db.DisconnectBlockRangeBitcoinType(lower, higher)
+		if w.disconnectedTxsHandler == nil {
+			return w.db.DisconnectBlockRangeBitcoinType(lower, higher)
+		}
+		txids, err := w.db.getBlockRangeTxids(lower, higher)
+		if err != nil {
+			return err
+		}
+		if err := w.db.DisconnectBlockRangeBitcoinType(lower, higher); err != nil {
+			return err
+		}
+		w.disconnectedTxsHandler.RemoveDisconnectedTxs(txids)
+		return nil
 	} else if ct == bchain.ChainEthereumType {
 		return w.db.DisconnectBlockRangeEthereumType(lower, higher)
 	}
 	return errors.New("Unknown chain type")
 }
+
+// HandleDisapprovedBlock checks if the votes in the block disapproved the previous block and if so,
+// removes the transactions of the previous block from the index. The previous block itself stays in the index without
+// the transactions, the blocks connected after it are disconnected and must be synchronized again.
+// The backend returns the transactions of the disapproved block to its mempool, the onDisapproved function
+// set by SetDisapprovalChecker should resynchronize the mempool to put them back to the mempool index.
+func (w *SyncWorker) HandleDisapprovedBlock(block *bchain.Block) error {
+	if w.disapprovalChecker == nil {
+		return nil
+	}
+	disapproved, err := w.disapprovalChecker.IsParentDisapproved(block)
+	if err != nil {
+		return err
+	}
+	if !disapproved {
+		return nil
+	}
+	parent, err := w.chain.GetBlock(block.Prev, 0)
+	if err != nil {
+		return err
+	}
+	bi, err := w.db.GetBlockInfo(parent.Height)
+	if err != nil {
+		return err
+	}
+	// the block is not indexed or its transactions were already removed
+	if bi == nil || bi.Hash != parent.Hash || bi.Txs == 0 {
+		return nil
+	}
+	localBestHeight, _, err := w.db.GetBestBlock()
+	if err != nil {
+		return err
+	}
+	glog.Info("sync: block ", parent.Height, " ", parent.Hash, " disapproved, removing its ", len(parent.Txs), " transactions")
+	if err := w.DisconnectBlocks(parent.Height, localBestHeight, nil); err != nil {
+		return err
+	}
+	txids := disapprovedTxids(parent)
+	if err := w.db.ConnectBlock(parent); err != nil {
+		return err
+	}
+	if w.onDisapprovedBlock != nil {
+		w.onDisapprovedBlock(parent.Hash, txids)
+	}
+	return nil
+}
+
+// removeDisapprovedTxs removes the transactions of the not yet connected block if the votes in the next block disapproved it,
+// the block is then connected without the transactions
+func (w *SyncWorker) removeDisapprovedTxs(block, next *bchain.Block) error {
+	if w.disapprovalChecker == nil {
+		return nil
+	}
+	disapproved, err := w.disapprovalChecker.IsParentDisapproved(next)
+	if err != nil || !disapproved {
+		return err
+	}
+	glog.Info("sync: block ", block.Height, " ", block.Hash, " disapproved, connecting it without its ", len(block.Txs), " transactions")
+	txids := disapprovedTxids(block)
+	if w.onDisapprovedBlock != nil {
+		w.onDisapprovedBlock(block.Hash, txids)
+	}
+	return nil
+}
+
+// disapprovedTxids removes the transactions from the disapproved block and returns their txids
+func disapprovedTxids(block *bchain.Block) []string {
+	txids := make([]string, len(block.Txs))
+	for i := range block.Txs {
+		txids[i] = block.Txs[i].Txid
+	}
+	block.Txs = nil
+	return txids
+}
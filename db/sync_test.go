@@ -0,0 +1,502 @@
+// +build unittest
+
+package db
+
+import (
+	"blockbook/bchain"
+	"blockbook/tests/dbtestdata"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testDisapprovalChecker reports the disapproval of the parent blocks in the disapproved map
+type testDisapprovalChecker struct {
+	disapproved map[string]bool
+}
+
+func (c *testDisapprovalChecker) IsParentDisapproved(block *bchain.Block) (bool, error) {
+	return c.disapproved[block.Prev], nil
+}
+
+// testChildBlock returns the next block after the parent, its votes are reported by testDisapprovalChecker
+func testChildBlock(parent *bchain.Block) *bchain.Block {
+	return &bchain.Block{BlockHeader: bchain.BlockHeader{Height: parent.Height + 1, Prev: parent.Hash}}
+}
+
+func TestSyncWorker_HandleDisapprovedBlock(t *testing.T) {
+	d := setupRocksDB(t, &testBitcoinParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+
+	chain, err := dbtestdata.NewFakeBlockChain(d.chainParser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block1 := dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser)
+	if err := d.ConnectBlock(block1); err != nil {
+		t.Fatal(err)
+	}
+	block2 := dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser)
+	if err := d.ConnectBlock(block2); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewSyncWorker(d, chain, 1, 0, 0, false, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var returned []string
+	calls := 0
+	w.SetDisapprovalChecker(&testDisapprovalChecker{disapproved: map[string]bool{block2.Hash: true}}, func(hash string, txids []string) {
+		if hash != block2.Hash {
+			t.Errorf("onDisapprovedBlock hash = %v, want %v", hash, block2.Hash)
+		}
+		returned = txids
+		calls++
+	})
+
+	// the approved block is kept untouched
+	if err := w.HandleDisapprovedBlock(testChildBlock(block1)); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 0 {
+		t.Fatalf("onDisapprovedBlock called for approved block")
+	}
+
+	if err := w.HandleDisapprovedBlock(testChildBlock(block2)); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{dbtestdata.TxidB2T1, dbtestdata.TxidB2T2, dbtestdata.TxidB2T3, dbtestdata.TxidB2T4}
+	if !reflect.DeepEqual(returned, want) {
+		t.Errorf("onDisapprovedBlock txids = %v, want %v", returned, want)
+	}
+
+	// the block stays the best block, without transactions
+	height, hash, err := d.GetBestBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height != block2.Height || hash != block2.Hash {
+		t.Fatalf("GetBestBlock() = %v %v, want %v %v", height, hash, block2.Height, block2.Hash)
+	}
+	bi, err := d.GetBlockInfo(block2.Height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bi == nil || bi.Txs != 0 {
+		t.Fatalf("GetBlockInfo() = %+v, want block without transactions", bi)
+	}
+	for _, txid := range want {
+		ta, err := d.GetTxAddresses(txid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ta != nil {
+			t.Errorf("GetTxAddresses(%v) = %+v, want nil", txid, ta)
+		}
+	}
+	// the outputs of the 1st block spent by the disapproved block are unspent again
+	verifyGetTransactions(t, d, dbtestdata.Addr2, 0, 1000000, []txidIndex{
+		{dbtestdata.TxidB1T1, 1},
+	}, nil)
+	verifyGetTransactions(t, d, dbtestdata.Addr8, 0, 1000000, []txidIndex{}, nil)
+
+	// the block is handled only once
+	if err := w.HandleDisapprovedBlock(testChildBlock(block2)); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("onDisapprovedBlock called %d times, want 1", calls)
+	}
+}
+
+func TestSyncWorker_removeDisapprovedTxs(t *testing.T) {
+	w := &SyncWorker{}
+	block1 := &bchain.Block{BlockHeader: bchain.BlockHeader{Height: 1, Hash: "block1"}, Txs: []bchain.Tx{{Txid: "tx1"}, {Txid: "tx2"}}}
+	block2 := &bchain.Block{BlockHeader: bchain.BlockHeader{Height: 2, Hash: "block2", Prev: "block1"}, Txs: []bchain.Tx{{Txid: "tx3"}}}
+	var returned []string
+	w.SetDisapprovalChecker(&testDisapprovalChecker{disapproved: map[string]bool{"block1": true}}, func(hash string, txids []string) {
+		if hash != "block1" {
+			t.Errorf("onDisapprovedBlock hash = %v, want block1", hash)
+		}
+		returned = txids
+	})
+	// the approved block keeps its transactions
+	if err := w.removeDisapprovedTxs(block2, testChildBlock(block2)); err != nil {
+		t.Fatal(err)
+	}
+	if len(block2.Txs) != 1 || returned != nil {
+		t.Fatalf("approved block changed, txs %v, returned %v", block2.Txs, returned)
+	}
+	if err := w.removeDisapprovedTxs(block1, block2); err != nil {
+		t.Fatal(err)
+	}
+	if block1.Txs != nil {
+		t.Errorf("Txs = %v, want nil", block1.Txs)
+	}
+	if !reflect.DeepEqual(returned, []string{"tx1", "tx2"}) {
+		t.Errorf("onDisapprovedBlock txids = %v, want [tx1 tx2]", returned)
+	}
+}
+
+// testReorgChecker reports the reorg of the orphaned blocks, the orphans map the hash to the height of the common ancestor
+type testReorgChecker struct {
+	orphans map[string]uint32
+	err     error
+}
+
+func (c *testReorgChecker) CheckForReorg(lastKnownHash string, lastKnownHeight uint32) (bool, uint32, error) {
+	forkHeight, reorg := c.orphans[lastKnownHash]
+	return reorg, forkHeight, c.err
+}
+
+// testDisconnectedTxsHandler records the transactions of the disconnected blocks
+type testDisconnectedTxsHandler struct {
+	txids []string
+}
+
+func (h *testDisconnectedTxsHandler) RemoveDisconnectedTxs(txids []string) {
+	h.txids = append(h.txids, txids...)
+}
+
+func TestSyncWorker_DisconnectBlocks_DisconnectedTxs(t *testing.T) {
+	d := setupRocksDB(t, &testBitcoinParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+
+	chain, err := dbtestdata.NewFakeBlockChain(d.chainParser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block1 := dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser)
+	if err := d.ConnectBlock(block1); err != nil {
+		t.Fatal(err)
+	}
+	block2 := dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser)
+	if err := d.ConnectBlock(block2); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewSyncWorker(d, chain, 1, 0, 0, false, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &testDisconnectedTxsHandler{}
+	w.SetDisconnectedTxsHandler(h)
+	if err := w.DisconnectBlocks(block2.Height, block2.Height, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{dbtestdata.TxidB2T1, dbtestdata.TxidB2T2, dbtestdata.TxidB2T3, dbtestdata.TxidB2T4}
+	if !reflect.DeepEqual(h.txids, want) {
+		t.Errorf("RemoveDisconnectedTxs() txids = %v, want %v", h.txids, want)
+	}
+}
+
+func TestSyncWorker_isForked(t *testing.T) {
+	chain := &latencyBlockChain{bestHeight: 10}
+	tests := []struct {
+		name    string
+		checker ReorgChecker
+		hash    string
+		want    bool
+	}{
+		{name: "hashes match", hash: latencyBlockHash(5), want: false},
+		{name: "hashes differ", hash: latencyBlockHash(6), want: true},
+		{name: "checker reorg", checker: &testReorgChecker{orphans: map[string]uint32{latencyBlockHash(5): 4}}, hash: latencyBlockHash(5), want: true},
+		{name: "checker no reorg", checker: &testReorgChecker{}, hash: latencyBlockHash(6), want: false},
+		// the failing checker does not stop the sync, the fork is found by the comparison of the hashes
+		{name: "checker error", checker: &testReorgChecker{err: fmt.Errorf("Common ancestor not found")}, hash: latencyBlockHash(6), want: true},
+		{name: "checker error, hashes match", checker: &testReorgChecker{err: fmt.Errorf("Common ancestor not found")}, hash: latencyBlockHash(5), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &SyncWorker{chain: chain, reorgChecker: tt.checker}
+			got, err := w.isForked(5, tt.hash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("isForked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// competingBlockChain is the fake chain in which the test block 2 is replaced by the competing block
+// with less chain work
+type competingBlockChain struct {
+	bchain.BlockChain
+	block1, block2, competing *bchain.Block
+}
+
+func (c *competingBlockChain) GetBestBlockHash() (string, error) {
+	return c.competing.Hash, nil
+}
+
+func (c *competingBlockChain) GetBestBlockHeight() (uint32, error) {
+	return c.competing.Height, nil
+}
+
+func (c *competingBlockChain) GetBlockHash(height uint32) (string, error) {
+	switch height {
+	case c.block1.Height:
+		return c.block1.Hash, nil
+	case c.competing.Height:
+		return c.competing.Hash, nil
+	}
+	return "", bchain.ErrBlockNotFound
+}
+
+func (c *competingBlockChain) GetBlockHeader(hash string) (*bchain.BlockHeader, error) {
+	for _, b := range []*bchain.Block{c.block1, c.block2, c.competing} {
+		if b.Hash == hash {
+			return &b.BlockHeader, nil
+		}
+	}
+	return nil, bchain.ErrBlockNotFound
+}
+
+func (c *competingBlockChain) GetBlock(hash string, height uint32) (*bchain.Block, error) {
+	for _, b := range []*bchain.Block{c.block1, c.competing} {
+		if b.Hash == hash || hash == "" && b.Height == height {
+			return b, nil
+		}
+	}
+	return nil, bchain.ErrBlockNotFound
+}
+
+func TestSyncWorker_ResyncIndex_CompetingTip(t *testing.T) {
+	d := setupRocksDB(t, &testBitcoinParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+	fake, err := dbtestdata.NewFakeBlockChain(d.chainParser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block1 := dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser)
+	block2 := dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser)
+	block2.ChainWork = "0200"
+	for _, b := range []*bchain.Block{block1, block2} {
+		if err := d.ConnectBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	competing := &bchain.Block{BlockHeader: bchain.BlockHeader{
+		Hash:      "00000000eb0443fd7dc4a1ed5c686a8e995057805f9a161d9a5a77a95e72b7b6",
+		Prev:      block1.Hash,
+		Height:    block2.Height,
+		Time:      block2.Time,
+		ChainWork: "0100",
+	}}
+	remote1 := *block1
+	remote1.Next = competing.Hash
+	chain := &competingBlockChain{BlockChain: fake, block1: &remote1, block2: block2, competing: competing}
+
+	w, err := NewSyncWorker(d, chain, 1, 0, 0, false, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the backend prefers the competing tip although it has less work than the local tip
+	w.SetReorgChecker(&testReorgChecker{orphans: map[string]uint32{block2.Hash: block1.Height}})
+	if err := w.resyncIndex(nil, false); err != nil {
+		t.Fatal(err)
+	}
+	height, hash, err := d.GetBestBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height != competing.Height || hash != competing.Hash {
+		t.Errorf("GetBestBlock() = %v %v, want the competing tip %v %v", height, hash, competing.Height, competing.Hash)
+	}
+	for _, txid := range []string{dbtestdata.TxidB2T1, dbtestdata.TxidB2T2} {
+		if ta, err := d.GetTxAddresses(txid); err != nil || ta != nil {
+			t.Errorf("GetTxAddresses(%v) = %+v, %v, want the transaction of the orphaned block removed", txid, ta, err)
+		}
+	}
+}
+
+// latencyBlockChain serves empty blocks up to bestHeight, each GetBlock call takes the latency
+// plus a part of it depending on the height, so that the concurrent fetches finish out of order.
+// GetBlockHash takes the hashLatency in the same way.
+type latencyBlockChain struct {
+	bchain.BlockChain
+	bestHeight  uint32
+	latency     time.Duration
+	hashLatency time.Duration
+}
+
+func latencyBlockHash(height uint32) string {
+	return fmt.Sprintf("%064x", height)
+}
+
+func (c *latencyBlockChain) GetBestBlockHeight() (uint32, error) {
+	return c.bestHeight, nil
+}
+
+func (c *latencyBlockChain) GetBlockHash(height uint32) (string, error) {
+	if height > c.bestHeight {
+		return "", bchain.ErrBlockNotFound
+	}
+	time.Sleep(c.hashLatency + c.hashLatency*time.Duration(height%3)/2)
+	return latencyBlockHash(height), nil
+}
+
+func (c *latencyBlockChain) GetBlock(hash string, height uint32) (*bchain.Block, error) {
+	if height > c.bestHeight {
+		return nil, bchain.ErrBlockNotFound
+	}
+	time.Sleep(c.latency + c.latency*time.Duration(height%3)/2)
+	block := &bchain.Block{BlockHeader: bchain.BlockHeader{
+		Hash:   latencyBlockHash(height),
+		Prev:   latencyBlockHash(height - 1),
+		Height: height,
+	}}
+	if height < c.bestHeight {
+		block.Next = latencyBlockHash(height + 1)
+	}
+	return block, nil
+}
+
+func drainBlockChain(w *SyncWorker, concurrent bool) ([]uint32, error) {
+	out := make(chan blockResult, 8)
+	done := make(chan struct{})
+	defer close(done)
+	if concurrent {
+		go w.getBlockChainConcurrent(out, done)
+	} else {
+		go w.getBlockChain(out, done)
+	}
+	var heights []uint32
+	for res := range out {
+		if res.err != nil {
+			return heights, res.err
+		}
+		heights = append(heights, res.block.Height)
+	}
+	return heights, nil
+}
+
+func TestSyncWorker_getBlockChainConcurrent(t *testing.T) {
+	chain := &latencyBlockChain{bestHeight: 50, latency: time.Millisecond}
+	w := &SyncWorker{chain: chain, fetchWorkers: 4, startHeight: 10, startHash: latencyBlockHash(10)}
+	heights, err := drainBlockChain(w, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(heights) != 41 {
+		t.Fatalf("got %d blocks, want 41", len(heights))
+	}
+	for i, h := range heights {
+		if h != uint32(10+i) {
+			t.Fatalf("block %d has height %d, want %d", i, h, 10+i)
+		}
+	}
+
+	// the fetch stops when the first block is not the expected one
+	w.startHash = latencyBlockHash(9)
+	heights, err = drainBlockChain(w, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(heights) != 0 {
+		t.Errorf("got %d blocks of a different chain, want none", len(heights))
+	}
+
+	// the fetch is cancelled when done is closed
+	w.startHash = latencyBlockHash(10)
+	out := make(chan blockResult)
+	done := make(chan struct{})
+	go w.getBlockChainConcurrent(out, done)
+	<-out
+	close(done)
+	for range out {
+	}
+}
+
+// BenchmarkSyncWorker_getBlockChain compares the sequential and the concurrent fetching of a 1000 block window
+// from a backend with 1ms latency of a block, the ns/op is the time to fetch the whole window
+func BenchmarkSyncWorker_getBlockChain(b *testing.B) {
+	chain := &latencyBlockChain{bestHeight: 1000, latency: time.Millisecond}
+	for _, bm := range []struct {
+		name    string
+		workers int
+	}{
+		{"sequential", 1},
+		{"concurrent-4", 4},
+		{"concurrent-8", 8},
+		{"concurrent-16", 16},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			w := &SyncWorker{chain: chain, fetchWorkers: bm.workers, startHeight: 1, startHash: latencyBlockHash(1)}
+			for i := 0; i < b.N; i++ {
+				heights, err := drainBlockChain(w, bm.workers > 1)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(heights) != 1000 {
+					b.Fatalf("got %d blocks, want 1000", len(heights))
+				}
+			}
+		})
+	}
+}
+
+func TestSyncWorker_prefetchBlockHashes(t *testing.T) {
+	chain := &latencyBlockChain{bestHeight: 100, hashLatency: time.Millisecond}
+	w := &SyncWorker{chain: chain, prefetchDepth: 4}
+	stop := make(chan struct{})
+	h := uint32(10)
+	for hh := range w.prefetchBlockHashes(10, 50, stop) {
+		if hh.height != h || hh.hash != latencyBlockHash(h) {
+			t.Fatalf("got hash %v of height %d, want height %d", hh.hash, hh.height, h)
+		}
+		h++
+	}
+	close(stop)
+	if h != 51 {
+		t.Fatalf("got hashes up to height %d, want 50", h-1)
+	}
+
+	// the prefetching is stopped when stop is closed
+	stop = make(chan struct{})
+	hashes := w.prefetchBlockHashes(0, 100, stop)
+	<-hashes
+	close(stop)
+	for range hashes {
+	}
+}
+
+// BenchmarkSyncWorker_prefetchBlockHashes compares resolving the hashes of 20 blocks one by one with the prefetching
+// from a backend with 100ms latency of GetBlockHash, the ns/op is the time to resolve all the hashes
+func BenchmarkSyncWorker_prefetchBlockHashes(b *testing.B) {
+	chain := &latencyBlockChain{bestHeight: 1000, hashLatency: 100 * time.Millisecond}
+	for _, depth := range []int{0, 4, 8, 16} {
+		b.Run(fmt.Sprint("depth-", depth), func(b *testing.B) {
+			w := &SyncWorker{chain: chain, prefetchDepth: depth}
+			for i := 0; i < b.N; i++ {
+				if depth == 0 {
+					for h := uint32(1); h <= 20; h++ {
+						if _, err := chain.GetBlockHash(h); err != nil {
+							b.Fatal(err)
+						}
+					}
+					continue
+				}
+				stop := make(chan struct{})
+				n := 0
+				for range w.prefetchBlockHashes(1, 20, stop) {
+					n++
+				}
+				close(stop)
+				if n != 20 {
+					b.Fatalf("got %d hashes, want 20", n)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package server
 import (
 	"blockbook/api"
 	"blockbook/bchain"
+	"blockbook/bchain/coins"
+	"blockbook/bchain/coins/dcr"
 	"blockbook/common"
 	"blockbook/db"
 	"context"
@@ -54,6 +56,7 @@ type PublicServer struct {
 	is               *common.InternalState
 	templates        []*template.Template
 	debug            bool
+	decred           *dcr.DecredRPC
 }
 
 // NewPublicServer creates new public server http interface to blockbook and returns its handle
@@ -101,6 +104,9 @@ func NewPublicServer(binding string, certFiles string, db *db.RocksDB, chain bch
 		debug:            debugMode,
 	}
 	s.templates = s.parseTemplates()
+	if d := dcr.GetDecredRPC(coins.GetBlockChainBackend(chain)); d != nil {
+		s.decred = d
+	}
 
 	// map only basic functions, the rest is enabled by method MapFullPublicInterface
 	serveMux.Handle(path+"favicon.ico", http.FileServer(http.Dir("./static/")))
@@ -109,6 +115,10 @@ func NewPublicServer(binding string, certFiles string, db *db.RocksDB, chain bch
 	serveMux.HandleFunc(path, s.htmlTemplateHandler(s.explorerIndex))
 	// default API handler
 	serveMux.HandleFunc(path+"api/", s.jsonHandler(s.apiIndex, apiV2))
+	// decred serves the address history from the dcrd address index while the index is being built
+	if s.decred != nil {
+		serveMux.HandleFunc(path+"api/v2/address/", s.jsonHandler(s.apiAddress, apiV2))
+	}
 
 	return s, nil
 }
@@ -179,12 +189,18 @@ func (s *PublicServer) ConnectFullPublicInterface() {
 	serveMux.HandleFunc(path+"api/v2/block-index/", s.jsonHandler(s.apiBlockIndex, apiV2))
 	serveMux.HandleFunc(path+"api/v2/tx-specific/", s.jsonHandler(s.apiTxSpecific, apiV2))
 	serveMux.HandleFunc(path+"api/v2/tx/", s.jsonHandler(s.apiTx, apiV2))
-	serveMux.HandleFunc(path+"api/v2/address/", s.jsonHandler(s.apiAddress, apiV2))
+	if s.decred == nil {
+		serveMux.HandleFunc(path+"api/v2/address/", s.jsonHandler(s.apiAddress, apiV2))
+	}
 	serveMux.HandleFunc(path+"api/v2/xpub/", s.jsonHandler(s.apiXpub, apiV2))
 	serveMux.HandleFunc(path+"api/v2/utxo/", s.jsonHandler(s.apiUtxo, apiV2))
 	serveMux.HandleFunc(path+"api/v2/block/", s.jsonHandler(s.apiBlock, apiV2))
 	serveMux.HandleFunc(path+"api/v2/sendtx/", s.jsonHandler(s.apiSendTx, apiV2))
 	serveMux.HandleFunc(path+"api/v2/estimatefee/", s.jsonHandler(s.apiEstimateFee, apiV2))
+	// decred specific
+	if s.decred != nil {
+		s.connectDecredInterface(serveMux, path)
+	}
 	// socket.io interface
 	serveMux.Handle(path+"socket.io/", s.socketio.GetHandler())
 	// websocket interface
@@ -545,6 +561,9 @@ func (s *PublicServer) explorerTx(w http.ResponseWriter, r *http.Request) (tpl,
 	if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 {
 		txid := r.URL.Path[i+1:]
 		tx, err = s.api.GetTransaction(txid, false, true)
+		if err == nil {
+			err = s.api.SetDecredAddressReuse(tx)
+		}
 		if err != nil {
 			return errorTpl, nil, err
 		}
@@ -593,6 +612,9 @@ func (s *PublicServer) getAddressQueryParams(r *http.Request, accountDetails api
 	if ec != nil {
 		to = 0
 	}
+	if s.decred != nil {
+		from, to = decredHeightRange(r, from, to)
+	}
 	filterParam := r.URL.Query().Get("filter")
 	if len(filterParam) > 0 {
 		if filterParam == "inputs" {
@@ -632,10 +654,11 @@ func (s *PublicServer) getAddressQueryParams(r *http.Request, accountDetails api
 		gap = 0
 	}
 	return page, pageSize, accountDetails, &api.AddressFilter{
-		Vout:           voutFilter,
-		TokensToReturn: tokensToReturn,
-		FromHeight:     uint32(from),
-		ToHeight:       uint32(to),
+		Vout:            voutFilter,
+		TokensToReturn:  tokensToReturn,
+		FromHeight:      uint32(from),
+		ToHeight:        uint32(to),
+		PaginationToken: r.URL.Query().Get("paginationToken"),
 	}, filterParam, gap
 }
 
@@ -912,6 +935,9 @@ func (s *PublicServer) apiBlockIndex(r *http.Request, apiVersion int) (interface
 }
 
 func (s *PublicServer) apiTx(r *http.Request, apiVersion int) (interface{}, error) {
+	if s.decred != nil && strings.HasSuffix(r.URL.Path, "/proof") {
+		return s.apiDecredTxProof(r, apiVersion)
+	}
 	var txid string
 	i := strings.LastIndexByte(r.URL.Path, '/')
 	if i > 0 {
@@ -932,6 +958,9 @@ func (s *PublicServer) apiTx(r *http.Request, apiVersion int) (interface{}, erro
 		}
 	}
 	tx, err = s.api.GetTransaction(txid, spendingTxs, false)
+	if err == nil {
+		err = s.api.SetDecredAddressReuse(tx)
+	}
 	if err == nil && apiVersion == apiV1 {
 		return s.api.TxToV1(tx), nil
 	}
@@ -968,6 +997,11 @@ func (s *PublicServer) apiAddress(r *http.Request, apiVersion int) (interface{},
 	s.metrics.ExplorerViews.With(common.Labels{"action": "api-address"}).Inc()
 	page, pageSize, details, filter, _, _ := s.getAddressQueryParams(r, api.AccountDetailsTxidHistory, txsInAPI)
 	address, err = s.api.GetAddress(addressParam, page, pageSize, details, filter)
+	if err == nil && s.decred != nil {
+		if minConf, ec := strconv.Atoi(r.URL.Query().Get("minconf")); ec == nil {
+			err = s.api.SetDecredBalanceMinConf(address, minConf)
+		}
+	}
 	if err == nil && apiVersion == apiV1 {
 		return s.api.AddressToV1(address), nil
 	}
@@ -1017,6 +1051,9 @@ func (s *PublicServer) apiUtxo(r *http.Request, apiVersion int) (interface{}, er
 			utxo, err = s.api.GetAddressUtxo(r.URL.Path[i+1:], onlyConfirmed)
 			s.metrics.ExplorerViews.With(common.Labels{"action": "api-address-utxo"}).Inc()
 		}
+		if err == nil && s.decred != nil && r.URL.Query().Get("mature") == "true" {
+			utxo = decredMatureUtxos(utxo)
+		}
 		if err == nil && apiVersion == apiV1 {
 			return s.api.AddressUtxoToV1(utxo), nil
 		}
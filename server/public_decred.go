@@ -0,0 +1,426 @@
+package server
+
+import (
+	"blockbook/api"
+	"blockbook/bchain/coins/dcr"
+	"blockbook/common"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// connectDecredInterface maps the api calls available only for Decred backend
+func (s *PublicServer) connectDecredInterface(serveMux *http.ServeMux, path string) {
+	serveMux.HandleFunc(path+"api/v2/decred/peers", s.jsonHandler(s.apiDecredPeers, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/blockchaininfo", s.jsonHandler(s.apiDecredBlockchainInfo, apiV2))
+	serveMux.HandleFunc(path+"api/v2/fees", s.jsonHandler(s.apiDecredFees, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/blockheader/", s.jsonHandler(s.apiDecredBlockHeader, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/mempoolinfo", s.jsonHandler(s.apiDecredMempoolInfo, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/addednodes", s.jsonHandler(s.apiDecredAddedNodes, apiV2))
+	serveMux.HandleFunc(path+"api/v2/health/backend", s.jsonHandler(s.apiDecredBackendHealth, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/opreturn/", s.jsonHandler(s.apiDecredOpReturn, apiV2))
+	serveMux.HandleFunc(path+"api/v2/mempool", s.jsonHandler(s.apiDecredMempool, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/networkstats", s.jsonHandler(s.apiDecredNetworkStats, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/ticket/", s.jsonHandler(s.apiDecredTicket, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/swap/", s.jsonHandler(s.apiDecredAtomicSwap, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/cpfp/", s.jsonHandler(s.apiDecredCPFP, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/participation", s.jsonHandler(s.apiDecredParticipation, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/mininginfo", s.jsonHandler(s.apiDecredMiningInfo, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/mixing/", s.jsonHandler(s.apiDecredMixing, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/blockstats", s.jsonHandler(s.apiDecredBlockStats, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/addressscan/", s.jsonHandler(s.apiDecredAddressScan, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/subsidy", s.jsonHandler(s.apiDecredSubsidy, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/hardforks", s.jsonHandler(s.apiDecredHardForks, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/status", s.jsonHandler(s.apiDecredStatus, apiV2))
+	serveMux.HandleFunc(path+"api/v2/decred/cluster/", s.jsonHandler(s.apiDecredCluster, apiV2))
+	if s.decred.WalletAPIEnabled() {
+		serveMux.HandleFunc(path+"api/v2/decred/wallet/accounts", s.jsonHandler(s.apiDecredWalletAccounts, apiV2))
+		serveMux.HandleFunc(path+"api/v2/decred/wallet/accountaddress/", s.jsonHandler(s.apiDecredWalletAccountAddress, apiV2))
+	}
+	if s.decred.TestnetFaucetEnabled() {
+		serveMux.HandleFunc(path+"api/v2/decred/testnet/faucet", s.jsonHandler(s.apiDecredTestnetFaucet, apiV2))
+	}
+}
+
+func (s *PublicServer) apiDecredPeers(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-peers"}).Inc()
+	return s.decred.GetPeerInfo()
+}
+
+func (s *PublicServer) apiDecredAddedNodes(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-addednodes"}).Inc()
+	return s.decred.GetAddedNodeInfo()
+}
+
+// apiDecredBackendHealth returns the status of the connection to dcrd, the unhealthy status
+// is returned as a regular response with the connection error
+func (s *PublicServer) apiDecredBackendHealth(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-health-backend"}).Inc()
+	h, _ := s.decred.CheckDecredNodeHealth()
+	return h, nil
+}
+
+func (s *PublicServer) apiDecredBlockchainInfo(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-blockchaininfo"}).Inc()
+	info, err := s.decred.GetDecredBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	return info.Result, nil
+}
+
+func (s *PublicServer) apiDecredFees(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-fees"}).Inc()
+	return s.decred.GetMempoolFeeHistogram()
+}
+
+func (s *PublicServer) apiDecredBlockHeader(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-blockheader"}).Inc()
+	var hash string
+	if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 {
+		hash = r.URL.Path[i+1:]
+	}
+	if len(hash) == 0 {
+		return nil, api.NewAPIError("Missing block hash", true)
+	}
+	h, err := s.decred.GetDecredBlockHeader(hash)
+	if err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Block not found, %v", err), true)
+	}
+	return h, nil
+}
+
+func (s *PublicServer) apiDecredNetworkStats(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-networkstats"}).Inc()
+	return s.decred.GetDecredNetworkStats()
+}
+
+func (s *PublicServer) apiDecredStatus(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-status"}).Inc()
+	return s.decred.GetConnectionStatus()
+}
+
+func (s *PublicServer) apiDecredMiningInfo(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-mininginfo"}).Inc()
+	return s.decred.GetMiningInfo()
+}
+
+// defaultParticipationWindow is the default window of the participation rate, half a day of the mainnet blocks
+const defaultParticipationWindow = 144
+
+// apiDecredParticipation returns the staking participation rate, api/v2/decred/participation[?window={blocks}]
+func (s *PublicServer) apiDecredParticipation(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-participation"}).Inc()
+	window := defaultParticipationWindow
+	if w := r.URL.Query().Get("window"); w != "" {
+		var err error
+		if window, err = strconv.Atoi(w); err != nil {
+			return nil, api.NewAPIError("Parameter 'window' is not a number", true)
+		}
+	}
+	if window <= 0 || window > dcr.MaxParticipationWindow {
+		return nil, api.NewAPIError(fmt.Sprintf("Parameter 'window' must be between 1 and %d", dcr.MaxParticipationWindow), true)
+	}
+	p, err := s.decred.GetParticipationRate(window)
+	if err != nil {
+		return nil, api.NewAPIError(err.Error(), true)
+	}
+	return p, nil
+}
+
+// defaultBlockSizeStatsCount is the default number of the blocks of the block size statistics
+const defaultBlockSizeStatsCount = 144
+
+// apiDecredBlockStats returns the statistics of the block sizes, api/v2/decred/blockstats[?count={blocks}]
+func (s *PublicServer) apiDecredBlockStats(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-blockstats"}).Inc()
+	count := defaultBlockSizeStatsCount
+	if c := r.URL.Query().Get("count"); c != "" {
+		var err error
+		if count, err = strconv.Atoi(c); err != nil {
+			return nil, api.NewAPIError("Parameter 'count' is not a number", true)
+		}
+	}
+	bs, err := s.decred.GetBlockSizeStats(count)
+	if err != nil {
+		return nil, api.NewAPIError(err.Error(), true)
+	}
+	return bs, nil
+}
+
+// defaultSubsidyPoints is the default number of the points of the subsidy schedule
+const defaultSubsidyPoints = 100
+
+// apiDecredSubsidy returns the block subsidy schedule, api/v2/decred/subsidy[?start={height}&count={points}],
+// the schedule starts at the best block by default
+func (s *PublicServer) apiDecredSubsidy(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-subsidy"}).Inc()
+	var start int64
+	if h := r.URL.Query().Get("start"); h != "" {
+		v, err := strconv.ParseUint(h, 10, 32)
+		if err != nil {
+			return nil, api.NewAPIError("Parameter 'start' is not a valid block height", true)
+		}
+		start = int64(v)
+	} else {
+		bestHeight, _, err := s.db.GetBestBlock()
+		if err != nil {
+			return nil, err
+		}
+		start = int64(bestHeight)
+	}
+	count := defaultSubsidyPoints
+	if c := r.URL.Query().Get("count"); c != "" {
+		var err error
+		if count, err = strconv.Atoi(c); err != nil {
+			return nil, api.NewAPIError("Parameter 'count' is not a number", true)
+		}
+	}
+	sp, err := s.chainParser.(*dcr.DecredParser).GetSubsidySchedule(start, count)
+	if err != nil {
+		return nil, api.NewAPIError(err.Error(), true)
+	}
+	return sp, nil
+}
+
+func (s *PublicServer) apiDecredWalletAccounts(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-wallet-accounts"}).Inc()
+	return s.decred.GetAccounts()
+}
+
+type resultAccountAddress struct {
+	Account string `json:"account"`
+	Address string `json:"address"`
+}
+
+// apiDecredWalletAccountAddress returns the receiving address of the wallet account, api/v2/decred/wallet/accountaddress/{account}
+func (s *PublicServer) apiDecredWalletAccountAddress(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-wallet-accountaddress"}).Inc()
+	var account string
+	if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 {
+		account = r.URL.Path[i+1:]
+	}
+	if len(account) == 0 {
+		return nil, api.NewAPIError("Missing account", true)
+	}
+	address, err := s.decred.GetAccountAddress(account)
+	if err != nil {
+		return nil, err
+	}
+	return resultAccountAddress{Account: account, Address: address}, nil
+}
+
+func (s *PublicServer) apiDecredHardForks(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-hardforks"}).Inc()
+	return s.decred.GetHardForkStatus()
+}
+
+func (s *PublicServer) apiDecredMempoolInfo(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-mempoolinfo"}).Inc()
+	return s.decred.GetDecredMempoolInfo()
+}
+
+func (s *PublicServer) apiDecredOpReturn(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-opreturn"}).Inc()
+	var data string
+	if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 {
+		data = r.URL.Path[i+1:]
+	}
+	b, err := hex.DecodeString(data)
+	if err != nil || len(b) == 0 {
+		return nil, api.NewAPIError("Missing or invalid hex encoded data", true)
+	}
+	return s.api.GetDecredOpReturnTxs(b)
+}
+
+func (s *PublicServer) apiDecredTicket(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-ticket"}).Inc()
+	var txid string
+	if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 {
+		txid = r.URL.Path[i+1:]
+	}
+	if len(txid) == 0 {
+		return nil, api.NewAPIError("Missing txid", true)
+	}
+	return s.api.GetDecredTicket(txid)
+}
+
+// apiDecredAtomicSwap returns the spent atomic swap contract locked in the output, api/v2/decred/swap/{txid}:{vout}
+func (s *PublicServer) apiDecredAtomicSwap(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-swap"}).Inc()
+	var outpoint string
+	if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 {
+		outpoint = r.URL.Path[i+1:]
+	}
+	i := strings.LastIndexByte(outpoint, ':')
+	if i <= 0 {
+		return nil, api.NewAPIError("Missing or invalid outpoint, use txid:vout", true)
+	}
+	vout, err := strconv.ParseUint(outpoint[i+1:], 10, 31)
+	if err != nil {
+		return nil, api.NewAPIError("Invalid vout", true)
+	}
+	return s.api.GetDecredAtomicSwap(outpoint[:i], int32(vout))
+}
+
+// apiDecredCPFP returns the fee bumping of the mempool transaction by its descendants, api/v2/decred/cpfp/{txid}
+func (s *PublicServer) apiDecredCPFP(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-cpfp"}).Inc()
+	var txid string
+	if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 {
+		txid = r.URL.Path[i+1:]
+	}
+	if len(txid) == 0 {
+		return nil, api.NewAPIError("Missing txid", true)
+	}
+	info, err := s.decred.DetectCPFP(txid)
+	if dcr.IsNotFound(err) {
+		return nil, api.NewAPIError(fmt.Sprintf("Transaction %v not found in mempool", txid), true)
+	}
+	return info, err
+}
+
+func (s *PublicServer) apiDecredMixing(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-mixing"}).Inc()
+	var txid string
+	if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 {
+		txid = r.URL.Path[i+1:]
+	}
+	if len(txid) == 0 {
+		return nil, api.NewAPIError("Missing txid", true)
+	}
+	return s.api.GetDecredMixingSession(txid)
+}
+
+// apiDecredTxProof returns the merkle proof of the transaction, api/v2/tx/{txid}/proof[?block={hash}]
+func (s *PublicServer) apiDecredTxProof(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-txproof"}).Inc()
+	var txid string
+	p := strings.TrimSuffix(r.URL.Path, "/proof")
+	if i := strings.LastIndexByte(p, '/'); i > 0 {
+		txid = p[i+1:]
+	}
+	if len(txid) == 0 {
+		return nil, api.NewAPIError("Missing txid", true)
+	}
+	proof, err := s.decred.GetTxMerkleProof(txid, r.URL.Query().Get("block"))
+	if err != nil {
+		return nil, api.NewAPIError(fmt.Sprintf("Proof not found, %v", err), true)
+	}
+	return proof, nil
+}
+
+// apiDecredMempool returns a page of the mempool txids, with verbose=true including the fee and size of the transactions
+func (s *PublicServer) apiDecredMempool(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-mempool"}).Inc()
+	page, ec := strconv.Atoi(r.URL.Query().Get("page"))
+	if ec != nil {
+		page = 0
+	}
+	if verbose, _ := strconv.ParseBool(r.URL.Query().Get("verbose")); verbose {
+		return s.api.GetDecredMempoolVerbose(page, mempoolTxsOnPage)
+	}
+	return s.api.GetMempool(page, mempoolTxsOnPage)
+}
+
+// decredHeightRange returns the block range given by the startHeight and endHeight
+// parameters, which take precedence over the generic from and to parameters
+func decredHeightRange(r *http.Request, from, to int) (int, int) {
+	if h, ec := strconv.Atoi(r.URL.Query().Get("startHeight")); ec == nil && h >= 0 {
+		from = h
+	}
+	if h, ec := strconv.Atoi(r.URL.Query().Get("endHeight")); ec == nil && h >= 0 {
+		to = h
+	}
+	return from, to
+}
+
+// decredMatureUtxos removes the utxos which cannot be spent yet because of the coinbase maturity
+func decredMatureUtxos(utxos []api.Utxo) []api.Utxo {
+	r := utxos[:0]
+	for _, u := range utxos {
+		if u.Mature == nil || *u.Mature {
+			r = append(r, u)
+		}
+	}
+	return r
+}
+
+// defaultAddressScanGap is the default gap limit of the address scan, the gap limit of BIP44
+const defaultAddressScanGap = 20
+
+// decredUint32Param returns the value of the numeric query parameter or the default value if it is missing
+func decredUint32Param(r *http.Request, name string, def uint32) (uint32, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		return 0, api.NewAPIError(fmt.Sprintf("Parameter '%v' is not a number", name), true)
+	}
+	return uint32(n), nil
+}
+
+// apiDecredAddressScan scans the addresses derived from the xpub for the first gap of unused addresses,
+// api/v2/decred/addressscan/{xpub}[?branch={0|1}&start={index}&gap={count}]
+func (s *PublicServer) apiDecredAddressScan(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-addressscan"}).Inc()
+	var xpub string
+	if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 {
+		xpub = r.URL.Path[i+1:]
+	}
+	if len(xpub) == 0 {
+		return nil, api.NewAPIError("Missing xpub", true)
+	}
+	branch, err := decredUint32Param(r, "branch", 0)
+	if err != nil {
+		return nil, err
+	}
+	start, err := decredUint32Param(r, "start", 0)
+	if err != nil {
+		return nil, err
+	}
+	gap, err := decredUint32Param(r, "gap", defaultAddressScanGap)
+	if err != nil {
+		return nil, err
+	}
+	return s.api.ScanAddressRange(xpub, branch, start, gap)
+}
+
+// defaultClusterDepth is the default number of the common-input links followed from the seed address
+const defaultClusterDepth = 2
+
+// apiDecredCluster returns the addresses probably owned by the owner of the address, api/v2/decred/cluster/{address}[?depth={links}]
+func (s *PublicServer) apiDecredCluster(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-cluster"}).Inc()
+	var address string
+	if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 {
+		address = r.URL.Path[i+1:]
+	}
+	if len(address) == 0 {
+		return nil, api.NewAPIError("Missing address", true)
+	}
+	depth, err := decredUint32Param(r, "depth", defaultClusterDepth)
+	if err != nil {
+		return nil, err
+	}
+	return s.api.ClusterAddresses(address, int(depth))
+}
+
+// apiDecredTestnetFaucet funds the address passed in the query or in the form with test coins,
+// the endpoint is registered only if the faucet is enabled
+func (s *PublicServer) apiDecredTestnetFaucet(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-decred-testnet-faucet"}).Inc()
+	address := r.FormValue("address")
+	if address == "" {
+		return nil, api.NewAPIError("Missing address", true)
+	}
+	f, err := s.decred.FundTestAddress(address, r.FormValue("amount"))
+	if err != nil {
+		return nil, api.NewAPIError(err.Error(), true)
+	}
+	return f, nil
+}
@@ -497,6 +497,7 @@ func (s *SocketIoServer) getBlockHeader(height uint32, hash string) (res resultG
 	res.Result.Confirmations = bh.Confirmations
 	res.Result.Height = int(bh.Height)
 	res.Result.NextHash = bh.Next
+	res.Result.ChainWork = bh.ChainWork
 	return
 }
 
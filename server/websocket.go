@@ -417,7 +417,14 @@ func (s *WebsocketServer) getAccountUtxo(descriptor string) (interface{}, error)
 }
 
 func (s *WebsocketServer) getTransaction(txid string) (interface{}, error) {
-	return s.api.GetTransaction(txid, false, false)
+	tx, err := s.api.GetTransaction(txid, false, false)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.api.SetDecredAddressReuse(tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
 }
 
 func (s *WebsocketServer) getTransactionSpecific(txid string) (interface{}, error) {
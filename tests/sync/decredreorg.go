@@ -0,0 +1,73 @@
+// +build integration
+
+package sync
+
+import (
+	"blockbook/bchain/coins"
+	"blockbook/bchain/coins/dcr"
+	"blockbook/db"
+	"os"
+	"testing"
+)
+
+// testDecredReorg invalidates the best block in dcrd and checks that the fork is handled,
+// the block is reconsidered at the end so that the backend returns to the original chain
+func testDecredReorg(t *testing.T, h *TestHandler) {
+	backend := dcr.GetDecredRPC(coins.GetBlockChainBackend(h.Chain))
+	if backend == nil {
+		t.Skip("Decred backend required")
+	}
+	tipHeight, err := h.Chain.GetBestBlockHeight()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tipHash, err := h.Chain.GetBlockHash(tipHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lower := tipHeight - 2
+
+	withRocksDBAndSyncWorker(t, h, lower, func(d *db.RocksDB, sw *db.SyncWorker, ch chan os.Signal) {
+		if err := sw.ConnectBlocksParallel(lower, tipHeight); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := backend.InvalidateBlock(tipHash); err != nil {
+			if err == dcr.ErrAdminCallsDisabled {
+				t.Skip(err)
+			}
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := backend.ReconsiderBlock(tipHash); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		if err := db.HandleFork(sw, tipHeight, tipHash, nil, true); err != nil && err != db.ErrOperationInterrupted {
+			t.Fatal(err)
+		}
+
+		height, hash, err := d.GetBestBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if height != tipHeight-1 {
+			t.Fatalf("Best block height mismatch after invalidate: %d != %d", height, tipHeight-1)
+		}
+		remoteHash, err := h.Chain.GetBlockHash(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hash != remoteHash {
+			t.Fatalf("Best block hash mismatch after invalidate: %s != %s", hash, remoteHash)
+		}
+		bi, err := d.GetBlockInfo(tipHeight)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bi != nil {
+			t.Fatalf("Invalidated block %d is still in the index", tipHeight)
+		}
+	})
+}
@@ -18,6 +18,7 @@ var testMap = map[string]func(t *testing.T, th *TestHandler){
 	"ConnectBlocks":         testConnectBlocks,
 	"ConnectBlocksParallel": testConnectBlocksParallel,
 	"HandleFork":            testHandleFork,
+	"DecredReorg":           testDecredReorg,
 }
 
 type TestHandler struct {